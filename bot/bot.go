@@ -1,24 +1,40 @@
 package bot
 
 import (
+	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"github.com/bwmarrin/discordgo"
 	"github.com/juan-medina/cecibot/config"
+	"github.com/juan-medina/cecibot/interactions"
+	"github.com/juan-medina/cecibot/message"
 	"github.com/juan-medina/cecibot/processor"
 	"github.com/juan-medina/cecibot/prototype"
+	"github.com/juan-medina/cecibot/scheduler"
 	"go.uber.org/zap"
+	"net"
+	"net/http"
 	"os"
 	"os/signal"
+	"sort"
 	"strings"
 	"syscall"
+	"time"
 )
 
+const interactionsShutdownTimeout = 5 * time.Second
+
 type discordClient interface {
 	Open() error
 	Close() error
 	AddHandler(interface{}) func()
 	ChannelMessageSend(channelID string, content string) (*discordgo.Message, error)
+	ChannelMessageSendComplex(channelID string, data *discordgo.MessageSend) (*discordgo.Message, error)
+	ChannelMessageEditComplex(m *discordgo.MessageEdit) (*discordgo.Message, error)
+	MessageReactionAdd(channelID, messageID, emojiID string) error
+	UserChannelCreate(recipientID string) (*discordgo.Channel, error)
+	GuildMember(guildID, userID string) (*discordgo.Member, error)
 }
 
 var errInvalidDiscordClient = errors.New("invalid discord client")
@@ -26,10 +42,13 @@ var errInvalidDiscordClient = errors.New("invalid discord client")
 type waitFunc func()
 
 type bot struct {
-	cfg     config.Config
-	discord discordClient
-	prc     prototype.Processor
-	wait    waitFunc
+	cfg          config.Config
+	discord      discordClient
+	prc          prototype.Processor
+	wait         waitFunc
+	interactions *http.Server
+	handler      *interactions.Handler
+	scheduler    *scheduler.Scheduler
 }
 
 func (b *bot) GetConfig() config.Config {
@@ -83,12 +102,116 @@ func (b *bot) connect() error {
 	return nil
 }
 
+func (b *bot) startInteractions() error {
+	address := b.cfg.GetInteractionsAddress()
+	if address == "" {
+		return nil
+	}
+
+	log, _ := zap.NewProduction()
+	defer log.Sync()
+
+	client := interactions.NewClient(b.cfg.GetApplicationId(), b.cfg.GetToken())
+	handler, err := interactions.New(b.prc, b.cfg.GetPublicKey(), client)
+	if err != nil {
+		return err
+	}
+
+	if session, ok := b.discord.(*discordgo.Session); ok {
+		handler.SharedGuilds = func(userID string) []prototype.GuildRef {
+			return b.sharedGuilds(session.State, userID)
+		}
+	}
+
+	log.Info("Serving interactions.", zap.String("address", address))
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+
+	server := &http.Server{Handler: handler}
+	go func() {
+		if err := server.Serve(listener); err != http.ErrServerClosed {
+			log.Error("Error serving interactions.", zap.Error(err))
+		}
+	}()
+	b.interactions = server
+	b.handler = handler
+
+	log.Info("Registering slash commands.")
+	if err := client.Register(b.prc.GetCommands()); err != nil {
+		log.Error("Error registering slash commands.", zap.Error(err))
+	}
+
+	return nil
+}
+
+func (b *bot) stopInteractions() {
+	if b.interactions == nil {
+		return
+	}
+
+	log, _ := zap.NewProduction()
+	defer log.Sync()
+
+	log.Info("Stopping interactions.")
+	ctx, cancel := context.WithTimeout(context.Background(), interactionsShutdownTimeout)
+	defer cancel()
+
+	if err := b.interactions.Shutdown(ctx); err != nil {
+		log.Error("Error stopping interactions.", zap.Error(err))
+	}
+	b.handler.Wait()
+	b.interactions = nil
+}
+
+func (b *bot) startScheduler() error {
+	offsets, err := scheduler.ParseOffsets(b.cfg.GetReminders())
+	if err != nil {
+		return err
+	}
+
+	weeks, err := scheduler.ParseWeeks(b.cfg.GetScheduleWeeks())
+	if err != nil {
+		return err
+	}
+
+	store := b.prc.GetStore()
+	if store == nil {
+		return nil
+	}
+
+	log, _ := zap.NewProduction()
+	defer log.Sync()
+
+	log.Info("Starting raid scheduler.")
+	b.scheduler = scheduler.New(store, offsets, weeks, b, scheduler.SystemClock)
+	b.scheduler.Start()
+	return nil
+}
+
+func (b *bot) stopScheduler() {
+	if b.scheduler == nil {
+		return
+	}
+
+	log, _ := zap.NewProduction()
+	defer log.Sync()
+
+	log.Info("Stopping raid scheduler.")
+	b.scheduler.Stop()
+	b.scheduler = nil
+}
+
 func (b *bot) disconnect() {
 	log, _ := zap.NewProduction()
 	defer log.Sync()
 
 	log.Info("Bot is disconnecting.")
 
+	b.stopScheduler()
+	b.stopInteractions()
+
 	var err error
 
 	if b.discord == nil {
@@ -131,7 +254,21 @@ func (b bot) Run() error {
 
 	defer b.disconnect()
 
+	err = b.startInteractions()
+	if err != nil {
+		log.Error("Error starting interactions", zap.Error(err))
+		return err
+	}
+
+	err = b.startScheduler()
+	if err != nil {
+		log.Error("Error starting scheduler", zap.Error(err))
+		return err
+	}
+
 	b.discord.AddHandler(b.onChannelMessage)
+	b.discord.AddHandler(b.onReactionAdd)
+	b.discord.AddHandler(b.onReactionRemove)
 
 	log.Info("Bot started.")
 
@@ -142,15 +279,102 @@ func (b bot) Run() error {
 	return nil
 }
 
-func (b bot) sendMessage(channelID string, text string) {
+func (b bot) sendMessage(channelID string, text string) *discordgo.Message {
 
 	log, _ := zap.NewProduction()
 	defer log.Sync()
 
-	_, err := b.discord.ChannelMessageSend(channelID, text)
+	var sent *discordgo.Message
+	for _, chunk := range message.Split(text, message.MaxLength) {
+		var err error
+		sent, err = b.discord.ChannelMessageSend(channelID, chunk)
+
+		if err != nil {
+			log.Error("Error sending message", zap.Error(err))
+			return nil
+		}
+	}
+	return sent
+}
+
+func (b bot) sendComplexMessage(channelID string, msg prototype.Message) *discordgo.Message {
+	log, _ := zap.NewProduction()
+	defer log.Sync()
+
+	// long text goes first on its own, the embed and the files travel with the last chunk
+	chunks := message.Split(msg.Text, message.MaxLength)
+	for _, chunk := range chunks[:len(chunks)-1] {
+		_, err := b.discord.ChannelMessageSend(channelID, chunk)
+
+		if err != nil {
+			log.Error("Error sending message", zap.Error(err))
+			return nil
+		}
+	}
+
+	data := &discordgo.MessageSend{Content: chunks[len(chunks)-1]}
+	if msg.Embed != nil {
+		data.Embed = message.Embed(msg.Embed)
+	}
+	for _, file := range msg.Files {
+		data.Files = append(data.Files, &discordgo.File{
+			Name:        file.Name,
+			ContentType: file.ContentType,
+			Reader:      bytes.NewReader(file.Data),
+		})
+	}
+
+	sent, err := b.discord.ChannelMessageSendComplex(channelID, data)
 
 	if err != nil {
 		log.Error("Error sending message", zap.Error(err))
+		return nil
+	}
+	return sent
+}
+
+func (b bot) sendResponseMessage(channelID string, msg prototype.Message) {
+	var sent *discordgo.Message
+	if msg.Embed == nil && len(msg.Files) == 0 {
+		sent = b.sendMessage(channelID, msg.Text)
+	} else {
+		sent = b.sendComplexMessage(channelID, msg)
+	}
+
+	if sent == nil {
+		return
+	}
+
+	for _, reaction := range msg.Reactions {
+		b.addReaction(channelID, sent.ID, reaction)
+	}
+	if msg.Sent != nil {
+		msg.Sent(channelID, sent.ID)
+	}
+}
+
+func (b bot) editMessage(edit prototype.Edit) {
+	log, _ := zap.NewProduction()
+	defer log.Sync()
+
+	data := &discordgo.MessageEdit{ID: edit.MessageId, Channel: edit.Channel, Content: &edit.Message.Text}
+	if edit.Message.Embed != nil {
+		data.Embed = message.Embed(edit.Message.Embed)
+	}
+
+	if _, err := b.discord.ChannelMessageEditComplex(data); err != nil {
+		log.Error("Error editing message", zap.Error(err))
+	}
+}
+
+func (b bot) addReaction(channelID string, messageID string, emoji string) {
+	log, _ := zap.NewProduction()
+	defer log.Sync()
+
+	err := b.discord.MessageReactionAdd(channelID, messageID, emoji)
+
+	if err != nil {
+		log.Error("Error adding reaction", zap.Error(err))
 		return
 	}
 }
@@ -159,34 +383,219 @@ func (b bot) isSelfMessage(m *discordgo.MessageCreate, botUser *discordgo.User)
 	return m.Author.ID == botUser.ID
 }
 
-func (b bot) removeBotMention(m *discordgo.MessageCreate, botUser *discordgo.User) string {
-	text := strings.Replace(m.Content, botUser.Mention()+" ", "", -1)
-	return strings.TrimSpace(text)
+func (b bot) getMessageToBoot(m *discordgo.MessageCreate, mentions botMentions) string {
+	if text, found := mentions.strip(m.Content); found {
+		return text
+	}
+
+	if prefix := b.prc.GetPrefix(m.GuildID); prefix != "" && strings.HasPrefix(m.Content, prefix) {
+		return strings.TrimSpace(strings.TrimPrefix(m.Content, prefix))
+	}
+
+	// direct messages are always for the bot
+	if m.GuildID == "" {
+		return strings.TrimSpace(m.Content)
+	}
+	return ""
 }
 
-func (b bot) getMessageToBoot(m *discordgo.MessageCreate, botUser *discordgo.User) string {
-	for _, element := range m.Mentions {
-		if element.ID == botUser.ID {
-			return b.removeBotMention(m, botUser)
+// member looks for the member in the state, asking discord when the state does not have it, as it only has the
+// members that have been active, and keeping the answer in the state for the next time
+func (b bot) member(state *discordgo.State, guildID string, userID string) bool {
+	if _, err := state.Member(guildID, userID); err == nil {
+		return true
+	}
+
+	member, err := b.discord.GuildMember(guildID, userID)
+	if err != nil {
+		return false
+	}
+	member.GuildID = guildID
+	_ = state.MemberAdd(member)
+	return true
+}
+
+func (b bot) sharedGuilds(state *discordgo.State, userID string) []prototype.GuildRef {
+	state.RLock()
+	guilds := make([]prototype.GuildRef, 0, len(state.Guilds))
+	for _, guild := range state.Guilds {
+		guilds = append(guilds, prototype.GuildRef{Id: guild.ID, Name: guild.Name})
+	}
+	state.RUnlock()
+
+	result := make([]prototype.GuildRef, 0)
+	for _, guild := range guilds {
+		if b.member(state, guild.Id, userID) {
+			result = append(result, guild)
 		}
 	}
-	return ""
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Name == result[j].Name {
+			return result[i].Id < result[j].Id
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// the private responses go to the user direct messages, and the notices to the ones of their members
+func (b bot) sendResponse(channelID string, userID string, mention string, response *prototype.Response) {
+	for _, edit := range response.Edits {
+		b.editMessage(edit)
+	}
+
+	for _, notice := range response.Notices {
+		b.SendDirect(notice.Member, notice.Message)
+	}
+
+	if response.Private {
+		log, _ := zap.NewProduction()
+		defer log.Sync()
+
+		channel, err := b.discord.UserChannelCreate(userID)
+		if err != nil {
+			log.Error("Error creating private channel", zap.Error(err))
+			return
+		}
+		channelID = channel.ID
+		mention = ""
+	}
+
+	for i, msg := range response.Messages {
+		if i == 0 && mention != "" {
+			msg.Text = strings.TrimSpace(fmt.Sprintf("%s %s", mention, msg.Text))
+		}
+		b.sendResponseMessage(channelID, msg)
+	}
+}
+
+func (b bot) Send(channelID string, msg prototype.Message) {
+	b.sendResponseMessage(channelID, msg)
 }
 
-func (b bot) replyToMessage(m *discordgo.MessageCreate, text string) {
-	b.sendMessage(m.ChannelID, fmt.Sprintf("%s %s", m.Author.Mention(), text))
+func (b bot) SendDirect(userID string, msg prototype.Message) {
+	b.sendResponse("", userID, "", &prototype.Response{Messages: []prototype.Message{msg}, Private: true})
 }
 
-func (b bot) getResponseToMessage(text string, author string) string {
-	return b.prc.ProcessMessage(text, author)
+func (b bot) replyToMessage(m *discordgo.MessageCreate, response *prototype.Response) {
+	mention := m.Author.Mention()
+	if m.GuildID == "" {
+		mention = ""
+	}
+
+	b.sendResponse(m.ChannelID, m.Author.ID, mention, response)
+
+	for _, reaction := range response.Reactions {
+		b.addReaction(m.ChannelID, m.ID, reaction)
+	}
+}
+
+func (b bot) newRequest(m *discordgo.MessageCreate, text string, log *zap.Logger) *prototype.Request {
+	req := &prototype.Request{
+		Text:       text,
+		Author:     m.Author.ID,
+		AuthorName: m.Author.Username,
+		Guild:      m.GuildID,
+		Channel:    m.ChannelID,
+		MessageId:  m.ID,
+		DM:         m.GuildID == "",
+		Log: log.With(
+			zap.String("author", m.Author.ID),
+			zap.String("guild", m.GuildID),
+			zap.String("channel", m.ChannelID),
+			zap.String("message", m.ID),
+		),
+	}
+
+	if m.Member != nil {
+		if m.Member.Nick != "" {
+			req.AuthorName = m.Member.Nick
+		}
+		req.Roles = m.Member.Roles
+	}
+
+	for _, user := range m.Mentions {
+		req.Mentions = append(req.Mentions, user.ID)
+	}
+
+	for _, attachment := range m.Attachments {
+		req.Attachments = append(req.Attachments, attachment.URL)
+	}
+
+	if timestamp, err := m.Timestamp.Parse(); err == nil {
+		req.Timestamp = timestamp
+	}
+
+	return req
+}
+
+func (b bot) getResponseToMessage(req *prototype.Request) *prototype.Response {
+	return b.prc.ProcessMessage(req)
 }
 
 func (b bot) onChannelMessage(s *discordgo.Session, m *discordgo.MessageCreate) {
 	if !b.isSelfMessage(m, s.State.User) {
-		if text := b.getMessageToBoot(m, s.State.User); text != "" {
-			if response := b.getResponseToMessage(text, m.Author.ID); response != "" {
+		if text := b.getMessageToBoot(m, newBotMentions(s.State, m.GuildID)); text != "" {
+			log, _ := zap.NewProduction()
+			defer log.Sync()
+
+			req := b.newRequest(m, text, log)
+			if req.DM {
+				req.Guilds = b.sharedGuilds(s.State, m.Author.ID)
+			}
+
+			if response := b.getResponseToMessage(req); !response.IsEmpty() {
 				b.replyToMessage(m, response)
 			}
 		}
 	}
 }
+
+func (b bot) newReaction(state *discordgo.State, r *discordgo.MessageReaction, added bool, log *zap.Logger) *prototype.Reaction {
+	reaction := &prototype.Reaction{
+		Emoji:     r.Emoji.Name,
+		Member:    r.UserID,
+		Guild:     r.GuildID,
+		Channel:   r.ChannelID,
+		MessageId: r.MessageID,
+		Added:     added,
+		Log: log.With(
+			zap.String("member", r.UserID),
+			zap.String("guild", r.GuildID),
+			zap.String("channel", r.ChannelID),
+			zap.String("message", r.MessageID),
+		),
+	}
+
+	if member, err := state.Member(r.GuildID, r.UserID); err == nil {
+		reaction.MemberName = member.Nick
+		if reaction.MemberName == "" && member.User != nil {
+			reaction.MemberName = member.User.Username
+		}
+	}
+
+	return reaction
+}
+
+func (b bot) onReaction(state *discordgo.State, r *discordgo.MessageReaction, added bool) {
+	// the bot adds reactions to its own messages
+	if state.User != nil && r.UserID == state.User.ID {
+		return
+	}
+
+	log, _ := zap.NewProduction()
+	defer log.Sync()
+
+	if response := b.prc.ProcessReaction(b.newReaction(state, r, added, log)); !response.IsEmpty() {
+		b.sendResponse(r.ChannelID, r.UserID, "<@"+r.UserID+">", response)
+	}
+}
+
+func (b bot) onReactionAdd(s *discordgo.Session, r *discordgo.MessageReactionAdd) {
+	b.onReaction(s.State, r.MessageReaction, true)
+}
+
+func (b bot) onReactionRemove(s *discordgo.Session, r *discordgo.MessageReactionRemove) {
+	b.onReaction(s.State, r.MessageReaction, false)
+}
@@ -3,11 +3,20 @@ package bot
 import (
 	"errors"
 	"github.com/bwmarrin/discordgo"
+	"github.com/juan-medina/cecibot/command"
+	"github.com/juan-medina/cecibot/config"
 	"github.com/juan-medina/cecibot/prototype"
+	"go.uber.org/zap"
+	"io/ioutil"
+	"reflect"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 )
 
 type fakeCfg struct {
+	reminders string
 }
 
 func (f fakeCfg) GetOwner() string {
@@ -18,18 +27,74 @@ func (f fakeCfg) GetToken() string {
 	return "12345"
 }
 
+func (f fakeCfg) GetStorage() string {
+	return "memory"
+}
+
+func (f fakeCfg) GetStoragePath() string {
+	return ""
+}
+
+func (f fakeCfg) GetPrefix() string {
+	return ""
+}
+
+func (f fakeCfg) GetApplicationId() string {
+	return ""
+}
+
+func (f fakeCfg) GetPublicKey() string {
+	return ""
+}
+
+func (f fakeCfg) GetInteractionsAddress() string {
+	return ""
+}
+
+func (f fakeCfg) GetReminders() string {
+	return f.reminders
+}
+
+func (f fakeCfg) GetScheduleWeeks() string {
+	return "2"
+}
+
+func (f fakeCfg) GetLegacyGuild() string {
+	return ""
+}
+
+func (f fakeCfg) GetTimezone() string {
+	return ""
+}
+
 var fakeError = errors.New("fake error")
 
 type FakeDiscordClientSpy struct {
-	failOnOpen               bool
-	failOnClose              bool
-	failOnChannelMessageSend bool
-	failOnAddHandler         bool
-	failure                  bool
-	lastError                error
-	lastMethod               string
-	lastMessage              string
-	lastChannelTo            string
+	failOnOpen                      bool
+	failOnClose                     bool
+	failOnChannelMessageSend        bool
+	failOnChannelMessageSendComplex bool
+	failOnChannelMessageEditComplex bool
+	failOnMessageReactionAdd        bool
+	failOnUserChannelCreate         bool
+	failOnAddHandler                bool
+	failure                         bool
+	lastError                       error
+	lastMethod                      string
+	lastMessage                     string
+	lastEmbed                       *discordgo.MessageEmbed
+	lastFiles                       []*discordgo.File
+	lastEdit                        *discordgo.MessageEdit
+	lastChannelTo                   string
+	lastReaction                    string
+	reactions                       []string
+	sentMessages                    []string
+	members                         map[string]bool
+	memberLookups                   int
+}
+
+func (f *FakeDiscordClientSpy) sent(channelID string) *discordgo.Message {
+	return &discordgo.Message{ID: "sent" + strconv.Itoa(len(f.sentMessages)), ChannelID: channelID}
 }
 
 func (f *FakeDiscordClientSpy) recordError(method string, err error) error {
@@ -77,7 +142,56 @@ func (f *FakeDiscordClientSpy) ChannelMessageSend(channelID string, content stri
 	f.recordSuccess("ChannelMessageSend()")
 	f.lastMessage = content
 	f.lastChannelTo = channelID
-	return nil, nil
+	f.sentMessages = append(f.sentMessages, content)
+	return f.sent(channelID), nil
+}
+
+func (f *FakeDiscordClientSpy) ChannelMessageSendComplex(channelID string, data *discordgo.MessageSend) (*discordgo.Message, error) {
+	if f.failOnChannelMessageSendComplex {
+		return nil, f.recordError("ChannelMessageSendComplex()", fakeError)
+	}
+	f.recordSuccess("ChannelMessageSendComplex()")
+	f.lastMessage = data.Content
+	f.lastEmbed = data.Embed
+	f.lastFiles = data.Files
+	f.lastChannelTo = channelID
+	f.sentMessages = append(f.sentMessages, data.Content)
+	return f.sent(channelID), nil
+}
+
+func (f *FakeDiscordClientSpy) ChannelMessageEditComplex(m *discordgo.MessageEdit) (*discordgo.Message, error) {
+	if f.failOnChannelMessageEditComplex {
+		return nil, f.recordError("ChannelMessageEditComplex()", fakeError)
+	}
+	f.recordSuccess("ChannelMessageEditComplex()")
+	f.lastEdit = m
+	return &discordgo.Message{ID: m.ID, ChannelID: m.Channel}, nil
+}
+
+func (f *FakeDiscordClientSpy) MessageReactionAdd(channelID, messageID, emojiID string) error {
+	if f.failOnMessageReactionAdd {
+		return f.recordError("MessageReactionAdd()", fakeError)
+	}
+	f.recordSuccess("MessageReactionAdd()")
+	f.lastReaction = messageID + ":" + emojiID
+	f.reactions = append(f.reactions, f.lastReaction)
+	return nil
+}
+
+func (f *FakeDiscordClientSpy) UserChannelCreate(recipientID string) (*discordgo.Channel, error) {
+	if f.failOnUserChannelCreate {
+		return nil, f.recordError("UserChannelCreate()", fakeError)
+	}
+	f.recordSuccess("UserChannelCreate()")
+	return &discordgo.Channel{ID: "dm-" + recipientID}, nil
+}
+
+func (f *FakeDiscordClientSpy) GuildMember(guildID, userID string) (*discordgo.Member, error) {
+	f.memberLookups++
+	if !f.members[guildID+"/"+userID] {
+		return nil, fakeError
+	}
+	return &discordgo.Member{User: &discordgo.User{ID: userID}}, nil
 }
 
 func assertSpySuccess(t *testing.T, spy *FakeDiscordClientSpy, method string) bool {
@@ -113,7 +227,31 @@ func assertSpyFailure(t *testing.T, spy *FakeDiscordClientSpy, method string, er
 }
 
 type fakeProcessor struct {
-	failOnInit bool
+	failOnInit   bool
+	prefixes     map[string]string
+	lastRequest  *prototype.Request
+	lastReaction *prototype.Reaction
+}
+
+func (f *fakeProcessor) GetStore() prototype.RaidDataStore {
+	return nil
+}
+
+func (f *fakeProcessor) GetPrefix(guild string) string {
+	return f.prefixes[guild]
+}
+
+func (f *fakeProcessor) SetPrefix(guild string, prefix string) error {
+	f.prefixes[guild] = prefix
+	return nil
+}
+
+func (f *fakeProcessor) GetCommands() []*prototype.Command {
+	return []*prototype.Command{command.New("hello", "Say *hello*.", "", nil)}
+}
+
+func (f *fakeProcessor) Complete(req *prototype.Request) []prototype.Choice {
+	return []prototype.Choice{{Name: req.Text, Value: req.Text}}
 }
 
 func (f *fakeProcessor) IsOwner(userId string) bool {
@@ -128,6 +266,14 @@ func (f *fakeProcessor) GetHelp() string {
 	return ""
 }
 
+func (f *fakeProcessor) GetHelpEmbed() *prototype.Embed {
+	return nil
+}
+
+func (f *fakeProcessor) GetConfig() config.Config {
+	return fakeCfg{}
+}
+
 func (f *fakeProcessor) Init(bot prototype.Bot) error {
 	if f.failOnInit {
 		return fakeError
@@ -138,8 +284,20 @@ func (f *fakeProcessor) Init(bot prototype.Bot) error {
 func (f fakeProcessor) End() {
 }
 
-func (f fakeProcessor) ProcessMessage(text string, author string) string {
-	return author + " told me : " + text
+func (f *fakeProcessor) ProcessMessage(req *prototype.Request) *prototype.Response {
+	f.lastRequest = req
+	return command.Text(req.Author + " told me : " + req.Text + " in " + req.Guild)
+}
+
+func (f *fakeProcessor) Send(channel string, message prototype.Message) {
+}
+
+func (f *fakeProcessor) ProcessReaction(reaction *prototype.Reaction) *prototype.Response {
+	f.lastReaction = reaction
+	if reaction.Added {
+		return command.Private(reaction.MemberName + " reacted with " + reaction.Emoji)
+	}
+	return nil
 }
 
 func TestNew(t *testing.T) {
@@ -270,12 +428,32 @@ func Test_bot_sendMessage(t *testing.T) {
 		assertSpySuccess(t, discord, "ChannelMessageSend()")
 	})
 
+	t.Run("it should split long messages", func(t *testing.T) {
+		discord.sentMessages = nil
+		text := strings.TrimSuffix(strings.Repeat(strings.Repeat("x", 99)+"\n", 30), "\n")
+		b.sendMessage("chanel", text)
+
+		if !assertSpySuccess(t, discord, "ChannelMessageSend()") {
+			return
+		}
+		if len(discord.sentMessages) != 2 {
+			t.Fatalf("want 2 messages, got %d", len(discord.sentMessages))
+		}
+		if got := strings.Join(discord.sentMessages, ""); got != text {
+			t.Errorf("want messages sent in order")
+		}
+	})
+
 	t.Run("it should fail sending message"+
 		"e", func(t *testing.T) {
 		discord.failOnChannelMessageSend = true
-		b.sendMessage("chanel", "text")
+		discord.sentMessages = nil
+		b.sendMessage("chanel", strings.Repeat("x\n", 2000))
 
 		assertSpyFailure(t, discord, "ChannelMessageSend()", fakeError)
+		if len(discord.sentMessages) != 0 {
+			t.Errorf("want no more messages after a failure, got %d", len(discord.sentMessages))
+		}
 	})
 }
 
@@ -342,6 +520,23 @@ func Test_bot_Run(t *testing.T) {
 		assertSpyFailure(t, discord, "Close()", fakeError)
 	})
 
+	t.Run("it should fail with invalid reminders", func(t *testing.T) {
+		discord := &FakeDiscordClientSpy{}
+		b := &bot{
+			cfg:     fakeCfg{reminders: "tomorrow"},
+			discord: discord,
+			prc:     prc,
+		}
+
+		b.wait = noop
+		if err := b.Run(); err == nil {
+			t.Errorf("want error, got nil")
+			return
+		}
+
+		assertSpySuccess(t, discord, "Close()")
+	})
+
 	t.Run("it should not fail on failure on addHandler", func(t *testing.T) {
 		discord := &FakeDiscordClientSpy{}
 		discord.failOnAddHandler = true
@@ -398,75 +593,10 @@ func Test_bot_isSelfMessage(t *testing.T) {
 	})
 }
 
-func Test_bot_removeBotMention(t *testing.T) {
-
-	cfg := fakeCfg{}
-	prc := &fakeProcessor{}
-
-	discord := &FakeDiscordClientSpy{}
-	discord.failOnClose = true
-	b := &bot{
-		cfg:     cfg,
-		discord: discord,
-		prc:     prc,
-	}
-
-	botUser := &discordgo.User{ID: "123"}
-
-	t.Run("we should remove the mention", func(t *testing.T) {
-		m := &discordgo.MessageCreate{
-			Message: &discordgo.Message{
-				Author:  &discordgo.User{ID: "456"},
-				Content: "<@123> this is a message",
-			},
-		}
-
-		got := b.removeBotMention(m, botUser)
-		want := "this is a message"
-
-		if got != want {
-			t.Errorf("got %q, want %q", got, want)
-		}
-	})
-
-	t.Run("we should not remove the mention", func(t *testing.T) {
-		m := &discordgo.MessageCreate{
-			Message: &discordgo.Message{
-				Author:  &discordgo.User{ID: "456"},
-				Content: "<@456> this is a another",
-			},
-		}
-
-		got := b.removeBotMention(m, botUser)
-		want := "<@456> this is a another"
-
-		if got != want {
-			t.Errorf("got %q, want %q", got, want)
-		}
-	})
-
-	t.Run("there is not mention", func(t *testing.T) {
-		m := &discordgo.MessageCreate{
-			Message: &discordgo.Message{
-				Author:  &discordgo.User{ID: "456"},
-				Content: "there is no mention",
-			},
-		}
-
-		got := b.removeBotMention(m, botUser)
-		want := "there is no mention"
-
-		if got != want {
-			t.Errorf("got %q, want %q", got, want)
-		}
-	})
-
-}
-
 func Test_bot_getMessageToBoot(t *testing.T) {
 
 	cfg := fakeCfg{}
-	prc := &fakeProcessor{}
+	prc := &fakeProcessor{prefixes: map[string]string{"guild1": "!", "": "?"}}
 
 	discord := &FakeDiscordClientSpy{}
 	discord.failOnClose = true
@@ -477,6 +607,7 @@ func Test_bot_getMessageToBoot(t *testing.T) {
 	}
 
 	botUser := &discordgo.User{ID: "123"}
+	mentions := botMentions{user: botUser.ID, roles: map[string]bool{"999": true}}
 
 	t.Run("we should get the message in a mention", func(t *testing.T) {
 		m := &discordgo.MessageCreate{
@@ -487,7 +618,7 @@ func Test_bot_getMessageToBoot(t *testing.T) {
 			},
 		}
 
-		got := b.getMessageToBoot(m, botUser)
+		got := b.getMessageToBoot(m, mentions)
 		want := "this is a message"
 
 		if got != want {
@@ -499,12 +630,13 @@ func Test_bot_getMessageToBoot(t *testing.T) {
 		m := &discordgo.MessageCreate{
 			Message: &discordgo.Message{
 				Author:   &discordgo.User{ID: "456"},
+				GuildID:  "guild1",
 				Content:  "this is a message",
 				Mentions: []*discordgo.User{},
 			},
 		}
 
-		got := b.getMessageToBoot(m, botUser)
+		got := b.getMessageToBoot(m, mentions)
 		want := ""
 
 		if got != want {
@@ -512,6 +644,40 @@ func Test_bot_getMessageToBoot(t *testing.T) {
 		}
 	})
 
+	tests := []struct {
+		name    string
+		guild   string
+		content string
+		want    string
+	}{
+		{"we should get the message with the guild prefix", "guild1", "!raid list", "raid list"},
+		{"we should get the message with a space after the prefix", "guild1", "! raid list", "raid list"},
+		{"we should not get the message with other prefix", "guild1", "?raid list", ""},
+		{"we should get the message with the default prefix", "", "?raid list", "raid list"},
+		{"we should not get the message without a prefix", "guild2", "!raid list", ""},
+		{"we should get direct messages without a prefix", "", "raid list", "raid list"},
+		{"we should get the message with a nickname mention", "guild1", "<@!123> raid list", "raid list"},
+		{"we should get the message with a mention of the bot role", "guild1", "<@&999> raid list", "raid list"},
+		{"we should get the message with a mention at the end", "guild1", "raid list <@123>", "raid list"},
+		{"we should not get the message with a mention of other user", "guild1", "<@456> raid list", ""},
+		{"we should prefer the mention over the prefix", "guild1", "!raid <@123> list", "!raid list"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &discordgo.MessageCreate{
+				Message: &discordgo.Message{
+					Author:   &discordgo.User{ID: "456"},
+					GuildID:  tt.guild,
+					Content:  tt.content,
+					Mentions: []*discordgo.User{},
+				},
+			}
+
+			if got := b.getMessageToBoot(m, mentions); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
 }
 
 func Test_bot_replyToMessage(t *testing.T) {
@@ -530,13 +696,14 @@ func Test_bot_replyToMessage(t *testing.T) {
 	m := &discordgo.MessageCreate{
 		Message: &discordgo.Message{
 			ChannelID: "chanel1",
+			GuildID:   "guild1",
 			Author:    &discordgo.User{ID: "456"},
 			Content:   "this is a message",
 			Mentions:  []*discordgo.User{},
 		},
 	}
 
-	b.replyToMessage(m, "hello world")
+	b.replyToMessage(m, command.Text("hello world"))
 
 	wantChannel := "chanel1"
 	gotChannel := discord.lastChannelTo
@@ -549,6 +716,172 @@ func Test_bot_replyToMessage(t *testing.T) {
 	if wantMessage != gotMessage {
 		t.Errorf("want message %q, got %q", wantMessage, gotMessage)
 	}
+
+	t.Run("should reply with embeds", func(t *testing.T) {
+		b.replyToMessage(m, command.Embed(&prototype.Embed{
+			Title:  "title",
+			Colour: 0xff0000,
+			Fields: []prototype.EmbedField{{Name: "name", Value: "value", Inline: true}},
+			Footer: "footer",
+		}))
+
+		if !assertSpySuccess(t, discord, "ChannelMessageSendComplex()") {
+			return
+		}
+
+		want := &discordgo.MessageEmbed{
+			Title:  "title",
+			Color:  0xff0000,
+			Fields: []*discordgo.MessageEmbedField{{Name: "name", Value: "value", Inline: true}},
+			Footer: &discordgo.MessageEmbedFooter{Text: "footer"},
+		}
+		if !reflect.DeepEqual(discord.lastEmbed, want) {
+			t.Errorf("want embed %+v, got %+v", want, discord.lastEmbed)
+		}
+		if discord.lastMessage != "<@456>" {
+			t.Errorf("want mention with the embed, got %q", discord.lastMessage)
+		}
+	})
+
+	t.Run("should reply with files", func(t *testing.T) {
+		b.replyToMessage(m, command.Files("raids exported", prototype.File{Name: "raids.csv", ContentType: "text/csv", Data: []byte("id,name\n")}))
+
+		if !assertSpySuccess(t, discord, "ChannelMessageSendComplex()") {
+			return
+		}
+
+		if discord.lastEmbed != nil {
+			t.Errorf("want no embed, got %+v", discord.lastEmbed)
+		}
+		if len(discord.lastFiles) != 1 || discord.lastFiles[0].Name != "raids.csv" || discord.lastFiles[0].ContentType != "text/csv" {
+			t.Fatalf("want raids.csv file, got %+v", discord.lastFiles)
+		}
+		data, _ := ioutil.ReadAll(discord.lastFiles[0].Reader)
+		if string(data) != "id,name\n" {
+			t.Errorf("want file data %q, got %q", "id,name\n", data)
+		}
+		if discord.lastMessage != "<@456> raids exported" {
+			t.Errorf("want mention with the files, got %q", discord.lastMessage)
+		}
+	})
+
+	t.Run("should send several messages in order", func(t *testing.T) {
+		discord.sentMessages = nil
+		b.replyToMessage(m, &prototype.Response{
+			Messages: []prototype.Message{{Text: "first"}, {Text: "second"}},
+		})
+
+		want := []string{"<@456> first", "second"}
+		if !reflect.DeepEqual(discord.sentMessages, want) {
+			t.Errorf("want messages %v, got %v", want, discord.sentMessages)
+		}
+	})
+
+	t.Run("should add reactions", func(t *testing.T) {
+		m.ID = "m1"
+		b.replyToMessage(m, &prototype.Response{Reactions: []string{"👍"}})
+
+		if assertSpySuccess(t, discord, "MessageReactionAdd()") && discord.lastReaction != "m1:👍" {
+			t.Errorf("want reaction %q, got %q", "m1:👍", discord.lastReaction)
+		}
+	})
+
+	t.Run("should add reactions to sent messages and tell where they were sent", func(t *testing.T) {
+		discord.reactions = nil
+		var sentTo string
+		b.replyToMessage(m, &prototype.Response{Messages: []prototype.Message{{
+			Embed:     &prototype.Embed{Title: "announcement"},
+			Reactions: []string{"💚", "🏹"},
+			Sent: func(channel string, messageId string) {
+				sentTo = channel + ":" + messageId
+			},
+		}}})
+
+		sent := "sent" + strconv.Itoa(len(discord.sentMessages))
+		if want := []string{sent + ":💚", sent + ":🏹"}; !reflect.DeepEqual(discord.reactions, want) {
+			t.Errorf("want reactions %v, got %v", want, discord.reactions)
+		}
+		if want := "chanel1:" + sent; sentTo != want {
+			t.Errorf("want message sent to %q, got %q", want, sentTo)
+		}
+	})
+
+	t.Run("should edit messages", func(t *testing.T) {
+		resp := command.Text("")
+		resp.Edits = []prototype.Edit{{Channel: "chanel2", MessageId: "m2", Message: prototype.Message{Embed: &prototype.Embed{Title: "roster"}}}}
+		b.replyToMessage(m, resp)
+
+		if !assertSpySuccess(t, discord, "ChannelMessageEditComplex()") {
+			return
+		}
+		got := discord.lastEdit
+		if got.Channel != "chanel2" || got.ID != "m2" || *got.Content != "" || got.Embed.Title != "roster" {
+			t.Errorf("want message m2 edited, got %+v", got)
+		}
+	})
+
+	t.Run("should not fail if a message could not be edited", func(t *testing.T) {
+		discord.failOnChannelMessageEditComplex = true
+		resp := command.Text("hello")
+		resp.Edits = []prototype.Edit{{Channel: "chanel2", MessageId: "m2"}}
+		b.replyToMessage(m, resp)
+
+		if !assertSpySuccess(t, discord, "ChannelMessageSend()") || discord.lastMessage != "<@456> hello" {
+			t.Errorf("want message sent after the failure, got %q", discord.lastMessage)
+		}
+		discord.failOnChannelMessageEditComplex = false
+	})
+
+	t.Run("should reply privately", func(t *testing.T) {
+		resp := command.Text("secret")
+		resp.Private = true
+		b.replyToMessage(m, resp)
+
+		if discord.lastChannelTo != "dm-456" {
+			t.Errorf("want message reply to %q, got %q", "dm-456", discord.lastChannelTo)
+		}
+		if discord.lastMessage != "secret" {
+			t.Errorf("want message %q, got %q", "secret", discord.lastMessage)
+		}
+	})
+
+	t.Run("should send the notices to the direct messages of their members", func(t *testing.T) {
+		discord.sentMessages = nil
+		resp := command.Text("benched")
+		resp.Notices = []prototype.Notice{{Member: "789", Message: prototype.Message{Text: "you have been benched"}}}
+		b.replyToMessage(m, resp)
+
+		if want := []string{"you have been benched", "<@456> benched"}; !reflect.DeepEqual(discord.sentMessages, want) {
+			t.Errorf("want messages %v, got %v", want, discord.sentMessages)
+		}
+		if discord.lastChannelTo != "chanel1" {
+			t.Errorf("want the reply to %q, got %q", "chanel1", discord.lastChannelTo)
+		}
+	})
+
+	t.Run("should not mention the author in direct messages", func(t *testing.T) {
+		dm := &discordgo.MessageCreate{
+			Message: &discordgo.Message{
+				ChannelID: "dm-456",
+				Author:    &discordgo.User{ID: "456"},
+			},
+		}
+		b.replyToMessage(dm, command.Text("hello"))
+
+		if discord.lastMessage != "hello" {
+			t.Errorf("want message %q, got %q", "hello", discord.lastMessage)
+		}
+	})
+
+	t.Run("should not reply privately if the channel could not be created", func(t *testing.T) {
+		discord.failOnUserChannelCreate = true
+		resp := command.Text("secret")
+		resp.Private = true
+		b.replyToMessage(m, resp)
+
+		assertSpyFailure(t, discord, "UserChannelCreate()", fakeError)
+		discord.failOnUserChannelCreate = false
+	})
 }
 
 func Test_getResponseToMessage(t *testing.T) {
@@ -562,14 +895,78 @@ func Test_getResponseToMessage(t *testing.T) {
 		prc:     prc,
 	}
 
-	got := b.getResponseToMessage("hello", "user1")
-	want := "user1 told me : hello"
+	got := b.getResponseToMessage(&prototype.Request{Text: "hello", Author: "user1", Guild: "guild1"}).String()
+	want := "user1 told me : hello in guild1"
 
 	if got != want {
 		t.Errorf("want message %q, got %q", want, got)
 	}
 }
 
+func Test_bot_newRequest(t *testing.T) {
+	b := &bot{cfg: fakeCfg{}, discord: &FakeDiscordClientSpy{}, prc: &fakeProcessor{}}
+	log := zap.NewNop()
+
+	t.Run("should get a request from a guild message", func(t *testing.T) {
+		m := &discordgo.MessageCreate{
+			Message: &discordgo.Message{
+				ID:          "m1",
+				ChannelID:   "chanel1",
+				GuildID:     "guild1",
+				Content:     "<@123> hello <@789>",
+				Timestamp:   "2019-11-20T20:00:00+00:00",
+				Author:      &discordgo.User{ID: "456", Username: "thrall"},
+				Member:      &discordgo.Member{Nick: "Warchief", Roles: []string{"r1", "r2"}},
+				Mentions:    []*discordgo.User{{ID: "123"}, {ID: "789"}},
+				Attachments: []*discordgo.MessageAttachment{{URL: "http://file"}},
+			},
+		}
+
+		got := b.newRequest(m, "hello <@789>", log)
+		want := &prototype.Request{
+			Text:        "hello <@789>",
+			Author:      "456",
+			AuthorName:  "Warchief",
+			Guild:       "guild1",
+			Channel:     "chanel1",
+			MessageId:   "m1",
+			DM:          false,
+			Mentions:    []string{"123", "789"},
+			Roles:       []string{"r1", "r2"},
+			Attachments: []string{"http://file"},
+			Timestamp:   time.Date(2019, 11, 20, 20, 0, 0, 0, time.UTC),
+		}
+
+		if got.Log == nil {
+			t.Errorf("want a logger, got nil")
+		}
+		got.Log = nil
+		if !got.Timestamp.Equal(want.Timestamp) {
+			t.Errorf("want timestamp %v, got %v", want.Timestamp, got.Timestamp)
+		}
+		got.Timestamp = want.Timestamp
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("want request %+v, got %+v", want, got)
+		}
+	})
+
+	t.Run("should get a request from a direct message", func(t *testing.T) {
+		m := &discordgo.MessageCreate{
+			Message: &discordgo.Message{
+				ID:        "m2",
+				ChannelID: "dm1",
+				Content:   "hello",
+				Author:    &discordgo.User{ID: "456", Username: "thrall"},
+			},
+		}
+
+		got := b.newRequest(m, "hello", log)
+		if !got.DM || got.Guild != "" || got.AuthorName != "thrall" {
+			t.Errorf("want direct message request from thrall, got %+v", got)
+		}
+	})
+}
+
 func Test_bot_onChannelMessage(t *testing.T) {
 
 	cfg := fakeCfg{}
@@ -591,6 +988,7 @@ func Test_bot_onChannelMessage(t *testing.T) {
 	m := &discordgo.MessageCreate{
 		Message: &discordgo.Message{
 			ChannelID: "chanel1",
+			GuildID:   "guild1",
 			Author:    &discordgo.User{ID: "456"},
 			Content:   "<@123> this is a message",
 			Mentions:  []*discordgo.User{botUser},
@@ -605,9 +1003,142 @@ func Test_bot_onChannelMessage(t *testing.T) {
 		t.Errorf("want message reply to %q, got %q", wantChannel, gotChannel)
 	}
 
-	wantMessage := "<@456> 456 told me : this is a message"
+	wantMessage := "<@456> 456 told me : this is a message in guild1"
 	gotMessage := discord.lastMessage
 	if wantMessage != gotMessage {
 		t.Errorf("want message %q, got %q", wantMessage, gotMessage)
 	}
 }
+
+func Test_bot_onChannelMessage_directMessage(t *testing.T) {
+	prc := &fakeProcessor{}
+	// the state does not have the members that have not been active, discord is asked for them
+	discord := &FakeDiscordClientSpy{members: map[string]bool{"g2/456": true}}
+	b := &bot{
+		cfg:     fakeCfg{},
+		discord: discord,
+		prc:     prc,
+	}
+
+	botUser := &discordgo.User{ID: "123"}
+	sta := discordgo.NewState()
+	sta.User = botUser
+	for _, guild := range []*discordgo.Guild{{ID: "g2", Name: "Second"}, {ID: "g1", Name: "First"}, {ID: "g3", Name: "Other"}} {
+		_ = sta.GuildAdd(guild)
+	}
+	_ = sta.MemberAdd(&discordgo.Member{GuildID: "g1", User: &discordgo.User{ID: "456"}})
+	_ = sta.MemberAdd(&discordgo.Member{GuildID: "g3", User: &discordgo.User{ID: "789"}})
+	ses := &discordgo.Session{State: sta}
+
+	m := &discordgo.MessageCreate{
+		Message: &discordgo.Message{
+			ChannelID: "dm1",
+			Author:    &discordgo.User{ID: "456"},
+			Content:   "raid list",
+		},
+	}
+
+	b.onChannelMessage(ses, m)
+
+	if prc.lastRequest == nil || !prc.lastRequest.DM {
+		t.Fatalf("want a direct message request, got %+v", prc.lastRequest)
+	}
+
+	wantGuilds := []prototype.GuildRef{{Id: "g1", Name: "First"}, {Id: "g2", Name: "Second"}}
+	if !reflect.DeepEqual(prc.lastRequest.Guilds, wantGuilds) {
+		t.Errorf("want guilds %v, got %v", wantGuilds, prc.lastRequest.Guilds)
+	}
+
+	if _, err := sta.Member("g2", "456"); err != nil {
+		t.Errorf("want the member found by discord kept in the state, got %v", err)
+	}
+	lookups := discord.memberLookups
+	b.sharedGuilds(sta, "456")
+	if discord.memberLookups != lookups+1 {
+		t.Errorf("want discord asked only for the unknown member, got %d lookups", discord.memberLookups-lookups)
+	}
+
+	wantMessage := "456 told me : raid list in "
+	if discord.lastMessage != wantMessage || discord.lastChannelTo != "dm1" {
+		t.Errorf("want message %q to %q, got %q to %q", wantMessage, "dm1", discord.lastMessage, discord.lastChannelTo)
+	}
+}
+
+func Test_bot_onReaction(t *testing.T) {
+	prc := &fakeProcessor{}
+	discord := &FakeDiscordClientSpy{}
+	b := &bot{
+		cfg:     fakeCfg{},
+		discord: discord,
+		prc:     prc,
+	}
+
+	sta := discordgo.NewState()
+	sta.User = &discordgo.User{ID: "123"}
+	_ = sta.GuildAdd(&discordgo.Guild{ID: "guild1"})
+	_ = sta.MemberAdd(&discordgo.Member{GuildID: "guild1", Nick: "Thrall", User: &discordgo.User{ID: "456", Username: "thrall"}})
+	ses := &discordgo.Session{State: sta}
+
+	reaction := func(user string) *discordgo.MessageReaction {
+		return &discordgo.MessageReaction{
+			UserID:    user,
+			MessageID: "m1",
+			Emoji:     discordgo.Emoji{Name: "🛡️"},
+			ChannelID: "chanel1",
+			GuildID:   "guild1",
+		}
+	}
+
+	t.Run("should ignore the reactions of the bot", func(t *testing.T) {
+		b.onReactionAdd(ses, &discordgo.MessageReactionAdd{MessageReaction: reaction("123")})
+
+		if prc.lastReaction != nil {
+			t.Errorf("want reaction ignored, got %+v", prc.lastReaction)
+		}
+	})
+
+	t.Run("should process added reactions", func(t *testing.T) {
+		b.onReactionAdd(ses, &discordgo.MessageReactionAdd{MessageReaction: reaction("456")})
+
+		got := prc.lastReaction
+		if got == nil || !got.Added || got.Member != "456" || got.MemberName != "Thrall" || got.MessageId != "m1" || got.Guild != "guild1" {
+			t.Fatalf("want reaction from the event, got %+v", got)
+		}
+
+		want := "Thrall reacted with 🛡️"
+		if discord.lastMessage != want || discord.lastChannelTo != "dm-456" {
+			t.Errorf("want message %q to %q, got %q to %q", want, "dm-456", discord.lastMessage, discord.lastChannelTo)
+		}
+	})
+
+	t.Run("should process removed reactions", func(t *testing.T) {
+		discord.sentMessages = nil
+		b.onReactionRemove(ses, &discordgo.MessageReactionRemove{MessageReaction: reaction("456")})
+
+		if prc.lastReaction.Added {
+			t.Errorf("want a removed reaction, got %+v", prc.lastReaction)
+		}
+		if len(discord.sentMessages) != 0 {
+			t.Errorf("want no reply, got %v", discord.sentMessages)
+		}
+	})
+}
+
+func Test_bot_Send(t *testing.T) {
+	discord := &FakeDiscordClientSpy{}
+	b := &bot{
+		cfg:     fakeCfg{},
+		discord: discord,
+		prc:     &fakeProcessor{},
+	}
+
+	b.Send("chanel1", prototype.Message{Text: "reminder"})
+	if discord.lastMessage != "reminder" || discord.lastChannelTo != "chanel1" {
+		t.Errorf("want message %q to %q, got %q to %q", "reminder", "chanel1", discord.lastMessage, discord.lastChannelTo)
+	}
+
+	b.SendDirect("456", prototype.Message{Text: "direct reminder"})
+	if discord.lastMessage != "direct reminder" || discord.lastChannelTo != "dm-456" {
+		t.Errorf("want message %q to %q, got %q to %q", "direct reminder", "dm-456", discord.lastMessage, discord.lastChannelTo)
+	}
+}
@@ -0,0 +1,106 @@
+package bot
+
+import (
+	"github.com/bwmarrin/discordgo"
+	"regexp"
+	"strings"
+)
+
+type mentionKind int
+
+const (
+	userMention mentionKind = iota
+	nicknameMention
+	roleMention
+)
+
+// mention is a mention token found in a message, start and end are its byte offsets
+type mention struct {
+	kind  mentionKind
+	id    string
+	start int
+	end   int
+}
+
+var mentionPattern = regexp.MustCompile(`<@([!&]?)(\d+)>`)
+
+func findMentions(text string) []mention {
+	result := make([]mention, 0)
+	for _, match := range mentionPattern.FindAllStringSubmatchIndex(text, -1) {
+		kind := userMention
+		switch text[match[2]:match[3]] {
+		case "!":
+			kind = nicknameMention
+		case "&":
+			kind = roleMention
+		}
+		result = append(result, mention{
+			kind:  kind,
+			id:    text[match[4]:match[5]],
+			start: match[0],
+			end:   match[1],
+		})
+	}
+	return result
+}
+
+// botMentions knows the ways the bot could be mentioned: its user, or a role managed for it in the guild
+type botMentions struct {
+	user  string
+	roles map[string]bool
+}
+
+func newBotMentions(state *discordgo.State, guildID string) botMentions {
+	result := botMentions{user: state.User.ID, roles: make(map[string]bool)}
+	if guildID == "" {
+		return result
+	}
+
+	member, err := state.Member(guildID, state.User.ID)
+	if err != nil {
+		return result
+	}
+
+	for _, roleID := range member.Roles {
+		if role, err := state.Role(guildID, roleID); err == nil && role.Managed {
+			result.roles[roleID] = true
+		}
+	}
+	return result
+}
+
+func (b botMentions) isBot(m mention) bool {
+	if m.kind == roleMention {
+		return b.roles[m.id]
+	}
+	return m.id == b.user
+}
+
+// strip removes the mentions to the bot from a text, with the spaces around them, returning if there was any
+func (b botMentions) strip(text string) (string, bool) {
+	found := false
+	result := ""
+	last := 0
+	for _, m := range findMentions(text) {
+		if !b.isBot(m) {
+			continue
+		}
+		found = true
+
+		result = joinText(result, strings.TrimRight(text[last:m.start], " \t"))
+		last = m.end
+		for last < len(text) && (text[last] == ' ' || text[last] == '\t') {
+			last++
+		}
+	}
+
+	return strings.TrimSpace(joinText(result, text[last:])), found
+}
+
+// joinText joins two pieces of text with a space, unless there is already a line break between them
+func joinText(first string, second string) string {
+	if first == "" || second == "" || strings.HasSuffix(first, "\n") || strings.HasPrefix(second, "\n") {
+		return first + second
+	}
+	return first + " " + second
+}
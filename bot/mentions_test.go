@@ -0,0 +1,122 @@
+package bot
+
+import (
+	"github.com/bwmarrin/discordgo"
+	"reflect"
+	"testing"
+)
+
+func Test_findMentions(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []mention
+	}{
+		{
+			name: "text without mentions",
+			text: "raid list",
+			want: []mention{},
+		},
+		{
+			name: "user mention",
+			text: "<@123> raid list",
+			want: []mention{{kind: userMention, id: "123", start: 0, end: 6}},
+		},
+		{
+			name: "nickname mention",
+			text: "<@!123> raid list",
+			want: []mention{{kind: nicknameMention, id: "123", start: 0, end: 7}},
+		},
+		{
+			name: "role mention",
+			text: "<@&123> raid list",
+			want: []mention{{kind: roleMention, id: "123", start: 0, end: 7}},
+		},
+		{
+			name: "several mentions anywhere",
+			text: "hi <@1>, add <@!2>",
+			want: []mention{
+				{kind: userMention, id: "1", start: 3, end: 7},
+				{kind: nicknameMention, id: "2", start: 13, end: 18},
+			},
+		},
+		{
+			name: "channels and broken mentions are not user mentions",
+			text: "<#123> <@abc> <@123",
+			want: []mention{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := findMentions(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("want %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func Test_botMentions_strip(t *testing.T) {
+	mentions := botMentions{user: "123", roles: map[string]bool{"999": true}}
+
+	tests := []struct {
+		name      string
+		text      string
+		want      string
+		wantFound bool
+	}{
+		{"no mentions", "raid list", "raid list", false},
+		{"user mention", "<@123> raid list", "raid list", true},
+		{"user mention without space", "<@123>raid list", "raid list", true},
+		{"nickname mention", "<@!123> raid list", "raid list", true},
+		{"bot role mention", "<@&999> raid list", "raid list", true},
+		{"other role mention", "<@&888> raid list", "<@&888> raid list", false},
+		{"mention at the end", "raid list <@123>", "raid list", true},
+		{"mention in the middle", "raid <@!123>   list", "raid list", true},
+		{"several mentions", "<@123> <@&999> raid list", "raid list", true},
+		{"other users are kept", "<@123> raid officer add <@!456>", "raid officer add <@!456>", true},
+		{"only the mention", "<@123>", "", true},
+		{"lines are kept", "<@123> raid create\n<@123>  'Molten Core'", "raid create\n'Molten Core'", true},
+		{"other user with the bot id as a nickname is kept", "<@1234> raid list", "<@1234> raid list", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, found := mentions.strip(tt.text)
+			if got != tt.want {
+				t.Errorf("want %q, got %q", tt.want, got)
+			}
+			if found != tt.wantFound {
+				t.Errorf("want found %v, got %v", tt.wantFound, found)
+			}
+		})
+	}
+}
+
+func Test_newBotMentions(t *testing.T) {
+	sta := discordgo.NewState()
+	sta.User = &discordgo.User{ID: "123"}
+	_ = sta.GuildAdd(&discordgo.Guild{
+		ID: "guild1",
+		Roles: []*discordgo.Role{
+			{ID: "999", Name: "cecibot", Managed: true},
+			{ID: "888", Name: "officers"},
+			{ID: "777", Name: "other bot", Managed: true},
+		},
+	})
+	_ = sta.MemberAdd(&discordgo.Member{GuildID: "guild1", User: sta.User, Roles: []string{"999", "888"}})
+
+	t.Run("we should get the managed roles of the bot", func(t *testing.T) {
+		got := newBotMentions(sta, "guild1")
+		want := botMentions{user: "123", roles: map[string]bool{"999": true}}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("want %v, got %v", want, got)
+		}
+	})
+
+	t.Run("we should get only the user in direct messages", func(t *testing.T) {
+		got := newBotMentions(sta, "")
+		want := botMentions{user: "123", roles: map[string]bool{}}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("want %v, got %v", want, got)
+		}
+	})
+}
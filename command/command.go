@@ -10,3 +10,32 @@ func New(key string, desc string, help string, fun prototype.CommandFunction) *p
 		Help: help,
 	}
 }
+
+// Text creates a response with the text, without messages if it is empty
+func Text(text string) *prototype.Response {
+	if text == "" {
+		return &prototype.Response{}
+	}
+	return &prototype.Response{Messages: []prototype.Message{{Text: text}}}
+}
+
+func Private(text string) *prototype.Response {
+	response := Text(text)
+	response.Private = true
+	return response
+}
+
+func Embed(embed *prototype.Embed) *prototype.Response {
+	return &prototype.Response{Messages: []prototype.Message{{Embed: embed}}}
+}
+
+func Files(text string, files ...prototype.File) *prototype.Response {
+	return &prototype.Response{Messages: []prototype.Message{{Text: text, Files: files}}}
+}
+
+// Adapt allows to use a prototype.SimpleCommandFunction as a prototype.CommandFunction
+func Adapt(fun prototype.SimpleCommandFunction) prototype.CommandFunction {
+	return func(req *prototype.Request) *prototype.Response {
+		return Text(fun(req.Args, req.Author))
+	}
+}
@@ -18,6 +18,9 @@ func (b *BaseProvider) GetProcessor() prototype.Processor {
 	return b.prc
 }
 
+func (b *BaseProvider) End() {
+}
+
 func New(prc prototype.Processor) *BaseProvider {
 	var prov = BaseProvider{
 		commands: make(prototype.CommandsMap),
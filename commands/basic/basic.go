@@ -32,12 +32,12 @@ func New(p prototype.Processor) prototype.Provider {
 	prov.AddCommand(command.New("ping",
 		"Asks for a ping to the *bot*.",
 		"This is a test command for the *bot* that will reply with a pong message",
-		prov.ping),
+		command.Adapt(prov.ping)),
 	)
 	prov.AddCommand(command.New("hello",
 		"Greets the *user*.",
 		"This command will greet *you* back.",
-		prov.hello),
+		command.Adapt(prov.hello)),
 	)
 
 	log.Info("Basic commands created", zap.Int("number of commands", len(*prov.GetCommands())))
@@ -2,6 +2,7 @@ package commands
 
 import (
 	"github.com/juan-medina/cecibot/commands/basic"
+	"github.com/juan-medina/cecibot/commands/loot"
 	"github.com/juan-medina/cecibot/commands/raid"
 	"github.com/juan-medina/cecibot/commands/system"
 	"github.com/juan-medina/cecibot/prototype"
@@ -17,6 +18,7 @@ func New(processor prototype.Processor) []prototype.Provider {
 		basic.New(processor),
 		system.New(processor),
 		raid.New(processor),
+		loot.New(processor),
 	}
 
 	log.Info("Commands providers created.", zap.Int("number of providers", len(providers)))
@@ -0,0 +1,470 @@
+package loot
+
+import (
+	"fmt"
+	"github.com/juan-medina/cecibot/command"
+	"github.com/juan-medina/cecibot/command/provider"
+	"github.com/juan-medina/cecibot/commands/raid/data/entities"
+	"github.com/juan-medina/cecibot/commands/raid/shared"
+	"github.com/juan-medina/cecibot/prototype"
+	"go.uber.org/zap"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const noRoll = "there is no roll open in this channel"
+const onlyServers = "loot rolls are only in the channels of a server"
+
+const defaultTimeout = time.Minute
+const maxTimeout = 10 * time.Minute
+
+const maxRoll = 100
+
+const raidOption = "raid="
+const timeOption = "time="
+
+// the choices of the members in a roll, need wins over greed
+const (
+	need  = "need"
+	greed = "greed"
+	pass  = "pass"
+)
+
+// Random gives the rolls, tests use a fake one to get known results
+type Random interface {
+	Intn(n int) int
+}
+
+// afterFunc runs a function once the duration has passed, the returned function stops it
+type afterFunc func(d time.Duration, fun func()) func()
+
+func systemAfter(d time.Duration, fun func()) func() {
+	timer := time.AfterFunc(d, fun)
+	return func() {
+		timer.Stop()
+	}
+}
+
+type subCommandFunction func(data prototype.RaidDataProvider, req *prototype.Request, args []string) string
+
+type subCommand struct {
+	officersOnly bool
+	fun          subCommandFunction
+}
+
+// entry is the choice of a member in a roll, the member rolls once even if the choice changes
+type entry struct {
+	member string
+	choice string
+	roll   int
+}
+
+type roll struct {
+	guild   string
+	channel string
+	item    string
+	raid    string
+	author  string
+	entries map[string]*entry
+	stop    func()
+}
+
+type lootCommands struct {
+	*provider.BaseProvider
+	store       prototype.RaidDataStore
+	now         func() time.Time
+	random      Random
+	after       afterFunc
+	subCommands map[string]subCommand
+	mu          sync.Mutex
+	rolls       map[string]*roll // open roll of each channel
+}
+
+// findRaid gets the raid with the given id, or without it the last one that has started, an empty raid if there is none
+func (d *lootCommands) findRaid(data prototype.RaidDataProvider, id string) (entities.Raid, error) {
+	if id != "" {
+		return data.GetRaid(id)
+	}
+
+	raids, err := data.GetRaids()
+	if err != nil {
+		return entities.Raid{}, err
+	}
+	for i := len(raids) - 1; i >= 0; i-- {
+		if !raids[i].Cancelled && !raids[i].Date.After(d.now()) {
+			return raids[i], nil
+		}
+	}
+	return entities.Raid{}, nil
+}
+
+func parseOpen(args []string) (string, string, time.Duration, error) {
+	words := make([]string, 0, len(args))
+	raidId := ""
+	timeout := defaultTimeout
+	for _, arg := range args {
+		switch {
+		case strings.HasPrefix(arg, raidOption):
+			raidId = strings.TrimPrefix(arg, raidOption)
+		case strings.HasPrefix(arg, timeOption):
+			value, err := time.ParseDuration(strings.TrimPrefix(arg, timeOption))
+			if err != nil || value <= 0 || value > maxTimeout {
+				return "", "", 0, fmt.Errorf("invalid time %q, use a duration up to %s as *time=90s* or *time=2m*", arg, maxTimeout)
+			}
+			timeout = value
+		default:
+			words = append(words, arg)
+		}
+	}
+	return strings.Join(words, " "), raidId, timeout, nil
+}
+
+func (d *lootCommands) open(data prototype.RaidDataProvider, req *prototype.Request, args []string) string {
+	item, raidId, timeout, err := parseOpen(args)
+	if err != nil {
+		return err.Error()
+	}
+	if item == "" {
+		return ""
+	}
+
+	raid, err := d.findRaid(data, raidId)
+	if err != nil {
+		return shared.Failure(err, raidId)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if current, found := d.rolls[req.Channel]; found {
+		return fmt.Sprintf("there is already a roll open for *%s* in this channel", current.item)
+	}
+
+	r := &roll{
+		guild:   req.Guild,
+		channel: req.Channel,
+		item:    item,
+		raid:    raid.Id,
+		author:  req.Author,
+		entries: make(map[string]*entry),
+	}
+	d.rolls[req.Channel] = r
+	r.stop = d.after(timeout, func() {
+		d.expire(r)
+	})
+
+	what := fmt.Sprintf("*%s*", item)
+	if raid.Id != "" {
+		what += " of raid " + shared.RaidTitle(raid)
+	}
+	return fmt.Sprintf("roll open for %s during **%d** seconds, reply **loot need**, **loot greed** or **loot pass**",
+		what, int(timeout.Seconds()))
+}
+
+func (d *lootCommands) choose(req *prototype.Request, choice string) string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	r, found := d.rolls[req.Channel]
+	if !found {
+		return noRoll
+	}
+
+	e, found := r.entries[req.Author]
+	if !found {
+		e = &entry{member: req.Author, roll: d.random.Intn(maxRoll) + 1}
+		r.entries[req.Author] = e
+	}
+	e.choice = choice
+
+	if choice == pass {
+		return fmt.Sprintf("you pass on *%s*", r.item)
+	}
+	return fmt.Sprintf("**%s** roll of **%d** for *%s*", choice, e.roll, r.item)
+}
+
+func (d *lootCommands) need(data prototype.RaidDataProvider, req *prototype.Request, args []string) string {
+	return d.choose(req, need)
+}
+
+func (d *lootCommands) greed(data prototype.RaidDataProvider, req *prototype.Request, args []string) string {
+	return d.choose(req, greed)
+}
+
+func (d *lootCommands) pass(data prototype.RaidDataProvider, req *prototype.Request, args []string) string {
+	return d.choose(req, pass)
+}
+
+// rollDice rolls from 1 to 100 without an item, as the loot council may ask for
+func (d *lootCommands) rollDice(data prototype.RaidDataProvider, req *prototype.Request, args []string) string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return fmt.Sprintf("you roll **%d** (1-%d)", d.random.Intn(maxRoll)+1, maxRoll)
+}
+
+func (d *lootCommands) end(data prototype.RaidDataProvider, req *prototype.Request, args []string) string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	r, found := d.rolls[req.Channel]
+	if !found {
+		return noRoll
+	}
+	r.stop()
+	return d.finish(r)
+}
+
+func (d *lootCommands) cancel(data prototype.RaidDataProvider, req *prototype.Request, args []string) string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	r, found := d.rolls[req.Channel]
+	if !found {
+		return noRoll
+	}
+	r.stop()
+	delete(d.rolls, r.channel)
+	return fmt.Sprintf("roll for *%s* cancelled", r.item)
+}
+
+func (d *lootCommands) expire(r *roll) {
+	d.mu.Lock()
+	if d.rolls[r.channel] != r {
+		d.mu.Unlock()
+		return
+	}
+	result := d.finish(r)
+	d.mu.Unlock()
+
+	d.GetProcessor().Send(r.channel, prototype.Message{Text: result})
+}
+
+// finish closes a roll and records the winner, need wins over greed, then the highest roll and the members that tie
+// roll again until one of them is the highest. Must be called holding the lock
+func (d *lootCommands) finish(r *roll) string {
+	delete(d.rolls, r.channel)
+
+	entries := make([]*entry, 0, len(r.entries))
+	for _, e := range r.entries {
+		if e.choice != pass {
+			entries = append(entries, e)
+		}
+	}
+	if len(entries) == 0 {
+		return fmt.Sprintf("nobody rolled for *%s*, the loot council decides who gets it", r.item)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].choice != entries[j].choice {
+			return entries[i].choice == need
+		}
+		if entries[i].roll != entries[j].roll {
+			return entries[i].roll > entries[j].roll
+		}
+		return entries[i].member < entries[j].member
+	})
+
+	best := entries[0]
+	tied := make([]string, 0)
+	rolls := ""
+	for _, e := range entries {
+		rolls += fmt.Sprintf("\n\t<@%s> **%s** **%d**", e.member, e.choice, e.roll)
+		if e.choice == best.choice && e.roll == best.roll {
+			tied = append(tied, e.member)
+		}
+	}
+
+	ties := ""
+	top := best.roll
+	for len(tied) > 1 {
+		ties += fmt.Sprintf("\n\ttie on **%d**, rolling again:", top)
+		again := make(map[string]int, len(tied))
+		top = 0
+		for _, member := range tied {
+			again[member] = d.random.Intn(maxRoll) + 1
+			ties += fmt.Sprintf(" <@%s> **%d**", member, again[member])
+			if again[member] > top {
+				top = again[member]
+			}
+		}
+		highest := make([]string, 0, len(tied))
+		for _, member := range tied {
+			if again[member] == top {
+				highest = append(highest, member)
+			}
+		}
+		tied = highest
+	}
+	winner := tied[0]
+
+	result := fmt.Sprintf("*%s* goes to <@%s> with a **%s** roll of **%d**", r.item, winner, best.choice, best.roll) + rolls + ties
+	if err := d.record(r, winner); err != nil {
+		result += "\n" + shared.Failure(err, r.raid)
+	}
+	return result
+}
+
+// record adds the item won to the loot points ledger, without cost, for the main character of the winner
+func (d *lootCommands) record(r *roll, winner string) error {
+	data := d.store.Guild(r.guild)
+
+	characters, err := data.GetCharacters(winner)
+	if err != nil {
+		return err
+	}
+	char := ""
+	for _, character := range characters {
+		if character.Main {
+			char = character.Name
+		}
+	}
+
+	_, err = data.AddTransaction(entities.Transaction{
+		Member: winner,
+		Kind:   entities.TransactionLoot,
+		Raid:   r.raid,
+		Char:   char,
+		Item:   r.item,
+		Author: r.author,
+		Date:   d.now(),
+	})
+	return err
+}
+
+func (d *lootCommands) history(data prototype.RaidDataProvider, req *prototype.Request, args []string) string {
+	raidId := ""
+	if len(args) > 0 {
+		raidId = args[0]
+	}
+
+	raid, err := d.findRaid(data, raidId)
+	if err != nil {
+		return shared.Failure(err, raidId)
+	}
+	if raid.Id == "" {
+		return "there are no raids that have started"
+	}
+
+	transactions, err := data.GetTransactions("")
+	if err != nil {
+		return shared.Failure(err, raid.Id)
+	}
+
+	result := fmt.Sprintf("loot of raid %s:", shared.RaidTitle(raid))
+	found := false
+	for _, transaction := range transactions {
+		if transaction.Kind != entities.TransactionLoot || transaction.Raid != raid.Id {
+			continue
+		}
+		found = true
+		result += fmt.Sprintf("\n\t*%s* : <@%s>", transaction.Item, transaction.Member)
+		if transaction.Char != "" {
+			result += fmt.Sprintf(" **%s**", transaction.Char)
+		}
+		if transaction.Points != 0 {
+			result += fmt.Sprintf(" for **%d** loot points", -transaction.Points)
+		}
+	}
+	if !found {
+		return fmt.Sprintf("there is no loot recorded for raid %s", shared.RaidTitle(raid))
+	}
+	return result
+}
+
+func (d *lootCommands) loot(req *prototype.Request) *prototype.Response {
+	argc := len(req.Args)
+	if argc > 0 {
+		sub, found := d.subCommands[req.Args[0]]
+		if found {
+			if req.Guild == "" {
+				return command.Text(onlyServers)
+			}
+			data := d.store.Guild(req.Guild)
+			if sub.officersOnly && !shared.IsOfficer(d.GetProcessor(), data, req.Author) {
+				return command.Text(shared.OfficersOnly)
+			}
+			return command.Text(sub.fun(data, req, req.Args[1:]))
+		}
+	}
+
+	return command.Text("")
+}
+
+func (d *lootCommands) End() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for channel, r := range d.rolls {
+		r.stop()
+		delete(d.rolls, channel)
+	}
+}
+
+func (d *lootCommands) addSubCommand(key string, officersOnly bool, fun subCommandFunction) {
+	d.subCommands[key] = subCommand{officersOnly: officersOnly, fun: fun}
+}
+
+func newLootCommands(p prototype.Processor, store prototype.RaidDataStore, now func() time.Time, random Random, after afterFunc) *lootCommands {
+	var prov = &lootCommands{
+		BaseProvider: provider.New(p),
+		store:        store,
+		now:          now,
+		random:       random,
+		after:        after,
+		subCommands:  make(map[string]subCommand),
+		rolls:        make(map[string]*roll),
+	}
+
+	prov.addSubCommand("need", false, prov.need)
+	prov.addSubCommand("greed", false, prov.greed)
+	prov.addSubCommand("pass", false, prov.pass)
+	prov.addSubCommand("roll", false, prov.rollDice)
+	prov.addSubCommand("history", false, prov.history)
+	prov.addSubCommand("open", true, prov.open)
+	prov.addSubCommand("end", true, prov.end)
+	prov.addSubCommand("cancel", true, prov.cancel)
+
+	return prov
+}
+
+func New(p prototype.Processor) prototype.Provider {
+	log, _ := zap.NewProduction()
+	defer log.Sync()
+
+	log.Info("Creating loot commands")
+	random := rand.New(rand.NewSource(time.Now().UnixNano()))
+	var prov = newLootCommands(p, p.GetStore(), time.Now, random, systemAfter)
+
+	prov.AddCommand(command.New("loot",
+		"Roll for *loot*.",
+		`With this command you could roll for the loot of a raid
+Usage:
+	**loot** *option* *parameters*
+*Options* for *members* and *officer* are:
+	**need**
+		rolls from 1 to 100 for the item open in the channel, need rolls win over greed rolls
+	**greed**
+		rolls from 1 to 100 for the item open in the channel
+	**pass**
+		passes on the item open in the channel
+	**roll**
+		rolls from 1 to 100, without an item
+	**history** *raid-id*
+		list the loot given in the raid, or in the last raid that has started without *raid-id*
+*Options* for *officers* only are:
+	**open** *item* *raid=raid-id* *time=duration*
+		opens a roll for the *item* in the channel during a minute, or the *duration* as *90s* or *2m*. The winner is announced when the time is over, and recorded in the *raid-id*, or the last raid that has started. The highest need roll wins, otherwise the highest greed roll, and the members that tie roll again
+	**end**
+		ends the roll open in the channel now, announcing the winner
+	**cancel**
+		cancels the roll open in the channel, without a winner
+`,
+		prov.loot))
+
+	log.Info("Loot commands created", zap.Int("number of commands", len(*prov.GetCommands())))
+	return prov
+}
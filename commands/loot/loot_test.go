@@ -0,0 +1,307 @@
+package loot
+
+import (
+	"github.com/juan-medina/cecibot/commands/raid/data/entities"
+	"github.com/juan-medina/cecibot/commands/raid/data/memory"
+	"github.com/juan-medina/cecibot/commands/raid/shared"
+	"github.com/juan-medina/cecibot/config"
+	"github.com/juan-medina/cecibot/prototype"
+	"testing"
+	"time"
+)
+
+const fakeGuild = "guild1"
+const fakeChannel = "channel1"
+
+type fakeProcessor struct {
+	sent []prototype.Message
+}
+
+func (f *fakeProcessor) ProcessMessage(req *prototype.Request) *prototype.Response {
+	return nil
+}
+
+func (f *fakeProcessor) Init(bot prototype.Bot) error {
+	return nil
+}
+
+func (f *fakeProcessor) End() {
+}
+
+func (f *fakeProcessor) IsOwner(userId string) bool {
+	return userId == "123"
+}
+
+func (f *fakeProcessor) GetCommandHelp(key string) string {
+	return ""
+}
+
+func (f *fakeProcessor) GetHelp() string {
+	return ""
+}
+
+func (f *fakeProcessor) GetHelpEmbed() *prototype.Embed {
+	return nil
+}
+
+func (f *fakeProcessor) GetConfig() config.Config {
+	return nil
+}
+
+func (f *fakeProcessor) GetStore() prototype.RaidDataStore {
+	return nil
+}
+
+func (f *fakeProcessor) GetPrefix(guild string) string {
+	return ""
+}
+
+func (f *fakeProcessor) SetPrefix(guild string, prefix string) error {
+	return nil
+}
+
+func (f *fakeProcessor) GetCommands() []*prototype.Command {
+	return nil
+}
+
+func (f *fakeProcessor) Complete(req *prototype.Request) []prototype.Choice {
+	return nil
+}
+
+func (f *fakeProcessor) ProcessReaction(reaction *prototype.Reaction) *prototype.Response {
+	return nil
+}
+
+func (f *fakeProcessor) Send(channel string, message prototype.Message) {
+	f.sent = append(f.sent, message)
+}
+
+// fakeRandom returns the given rolls in order
+type fakeRandom struct {
+	rolls []int
+}
+
+func (f *fakeRandom) Intn(n int) int {
+	roll := f.rolls[0]
+	f.rolls = f.rolls[1:]
+	return roll - 1
+}
+
+// fakeTimer keeps the function of the last roll opened, to end it when the test wants
+type fakeTimer struct {
+	fun     func()
+	stopped bool
+}
+
+func (f *fakeTimer) after(d time.Duration, fun func()) func() {
+	f.fun = fun
+	f.stopped = false
+	return func() {
+		f.stopped = true
+	}
+}
+
+func fakeNow() time.Time {
+	return time.Date(2019, 11, 1, 23, 0, 0, 0, time.Local)
+}
+
+func newRequest(args []string, author string) *prototype.Request {
+	return &prototype.Request{Args: args, Author: author, Guild: fakeGuild, Channel: fakeChannel}
+}
+
+func Test_lootCommands_loot(t *testing.T) {
+	prc := &fakeProcessor{}
+	store := memory.New()
+	data := store.Guild(fakeGuild)
+	random := &fakeRandom{}
+	timer := &fakeTimer{}
+	lc := newLootCommands(prc, store, fakeNow, random, timer.after)
+
+	_, _ = data.AddRaid(entities.Raid{Name: "Molten Core", Date: time.Date(2019, 11, 1, 20, 0, 0, 0, time.Local)})
+	_, _ = data.AddRaid(entities.Raid{Name: "Onyxia's Lair", Date: time.Date(2019, 11, 8, 20, 0, 0, 0, time.Local)})
+	_ = data.AddCharacter(entities.Character{Member: "2", Name: "Jaina", Class: "Mage", Spec: "Arcane", Main: true})
+
+	cases := []struct {
+		name   string
+		args   []string
+		author string
+		rolls  []int
+		want   string
+	}{
+		{
+			name:   "should be only for officers",
+			args:   []string{"open", "Onslaught", "Girdle"},
+			author: "1",
+			want:   shared.OfficersOnly,
+		},
+		{
+			name:   "there is no roll open",
+			args:   []string{"need"},
+			author: "1",
+			want:   noRoll,
+		},
+		{
+			name:   "should fail with an invalid time",
+			args:   []string{"open", "Onslaught", "Girdle", "time=1h"},
+			author: "123",
+			want:   `invalid time "time=1h", use a duration up to 10m0s as *time=90s* or *time=2m*`,
+		},
+		{
+			name:   "should open a roll in the last raid",
+			args:   []string{"open", "Onslaught", "Girdle", "time=90s"},
+			author: "123",
+			want:   "roll open for *Onslaught Girdle* of raid **Molten Core** (**1**) during **90** seconds, reply **loot need**, **loot greed** or **loot pass**",
+		},
+		{
+			name:   "should not open two rolls in a channel",
+			args:   []string{"open", "Eye", "of", "Sulfuras"},
+			author: "123",
+			want:   "there is already a roll open for *Onslaught Girdle* in this channel",
+		},
+		{
+			name:   "should roll need",
+			args:   []string{"need"},
+			author: "1",
+			rolls:  []int{87},
+			want:   "**need** roll of **87** for *Onslaught Girdle*",
+		},
+		{
+			name:   "should roll greed",
+			args:   []string{"greed"},
+			author: "3",
+			rolls:  []int{99},
+			want:   "**greed** roll of **99** for *Onslaught Girdle*",
+		},
+		{
+			name:   "should roll once when changing the choice",
+			args:   []string{"greed"},
+			author: "2",
+			rolls:  []int{87},
+			want:   "**greed** roll of **87** for *Onslaught Girdle*",
+		},
+		{
+			name:   "should keep the roll when changing the choice",
+			args:   []string{"need"},
+			author: "2",
+			want:   "**need** roll of **87** for *Onslaught Girdle*",
+		},
+		{
+			name:   "should pass",
+			args:   []string{"pass"},
+			author: "4",
+			rolls:  []int{100},
+			want:   "you pass on *Onslaught Girdle*",
+		},
+		{
+			name:   "should roll without an item",
+			args:   []string{"roll"},
+			author: "5",
+			rolls:  []int{42},
+			want:   "you roll **42** (1-100)",
+		},
+		{
+			name:   "should break the ties rolling again",
+			args:   []string{"end"},
+			author: "123",
+			rolls:  []int{50, 50, 20, 70},
+			want: "*Onslaught Girdle* goes to <@2> with a **need** roll of **87**\n" +
+				"\t<@1> **need** **87**\n" +
+				"\t<@2> **need** **87**\n" +
+				"\t<@3> **greed** **99**\n" +
+				"\ttie on **87**, rolling again: <@1> **50** <@2> **50**\n" +
+				"\ttie on **50**, rolling again: <@1> **20** <@2> **70**",
+		},
+		{
+			name:   "should show the loot of the last raid",
+			args:   []string{"history"},
+			author: "1",
+			want:   "loot of raid **Molten Core** (**1**):\n\t*Onslaught Girdle* : <@2> **Jaina**",
+		},
+		{
+			name:   "there is no loot in a raid",
+			args:   []string{"history", "2"},
+			author: "1",
+			want:   "there is no loot recorded for raid **Onyxia's Lair** (**2**)",
+		},
+		{
+			name:   "should fail with a raid not found",
+			args:   []string{"open", "Eye", "of", "Sulfuras", "raid=9"},
+			author: "123",
+			want:   "raid **9** not found",
+		},
+		{
+			name:   "should open a roll in a raid",
+			args:   []string{"open", "Eye", "of", "Sulfuras", "raid=2"},
+			author: "123",
+			want:   "roll open for *Eye of Sulfuras* of raid **Onyxia's Lair** (**2**) during **60** seconds, reply **loot need**, **loot greed** or **loot pass**",
+		},
+		{
+			name:   "should cancel a roll",
+			args:   []string{"cancel"},
+			author: "123",
+			want:   "roll for *Eye of Sulfuras* cancelled",
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			random.rolls = tt.rolls
+			got := lc.loot(newRequest(tt.args, tt.author)).String()
+			if got != tt.want {
+				t.Errorf("want %q, got %q", tt.want, got)
+			}
+			if len(random.rolls) != 0 {
+				t.Errorf("want every roll used, got %v left", random.rolls)
+			}
+		})
+	}
+
+	t.Run("should announce the winner when the time is over", func(t *testing.T) {
+		lc.loot(newRequest([]string{"open", "Eye", "of", "Sulfuras", "raid=2"}, "123"))
+		random.rolls = []int{30, 60}
+		lc.loot(newRequest([]string{"greed"}, "1"))
+		lc.loot(newRequest([]string{"greed"}, "3"))
+
+		timer.fun()
+		want := "*Eye of Sulfuras* goes to <@3> with a **greed** roll of **60**\n\t<@3> **greed** **60**\n\t<@1> **greed** **30**"
+		if len(prc.sent) != 1 || prc.sent[0].Text != want {
+			t.Fatalf("want %q sent, got %v", want, prc.sent)
+		}
+
+		transactions, _ := data.GetTransactions("3")
+		if len(transactions) != 1 || transactions[0].Raid != "2" || transactions[0].Item != "Eye of Sulfuras" || transactions[0].Points != 0 {
+			t.Errorf("want the loot recorded in the raid, got %v", transactions)
+		}
+
+		if got := lc.loot(newRequest([]string{"need"}, "1")).String(); got != noRoll {
+			t.Errorf("want %q, got %q", noRoll, got)
+		}
+	})
+
+	t.Run("should not announce a roll ended before", func(t *testing.T) {
+		prc.sent = nil
+		lc.loot(newRequest([]string{"open", "Eye", "of", "Sulfuras"}, "123"))
+		fun := timer.fun
+
+		want := "nobody rolled for *Eye of Sulfuras*, the loot council decides who gets it"
+		if got := lc.loot(newRequest([]string{"end"}, "123")).String(); got != want {
+			t.Errorf("want %q, got %q", want, got)
+		}
+		if !timer.stopped {
+			t.Errorf("want the timer stopped, got running")
+		}
+
+		fun()
+		if len(prc.sent) != 0 {
+			t.Errorf("want nothing sent, got %v", prc.sent)
+		}
+	})
+
+	t.Run("should be only in servers", func(t *testing.T) {
+		req := newRequest([]string{"need"}, "1")
+		req.Guild = ""
+		if got := lc.loot(req).String(); got != onlyServers {
+			t.Errorf("want %q, got %q", onlyServers, got)
+		}
+	})
+}
@@ -0,0 +1,182 @@
+package raid
+
+import (
+	"fmt"
+	"github.com/juan-medina/cecibot/command"
+	"github.com/juan-medina/cecibot/commands/raid/classes"
+	"github.com/juan-medina/cecibot/commands/raid/data/entities"
+	"github.com/juan-medina/cecibot/commands/raid/shared"
+	"github.com/juan-medina/cecibot/prototype"
+	"go.uber.org/zap"
+	"strings"
+)
+
+// variationSelector may be sent, or not, by discord after some emojis
+const variationSelector = "\ufe0f"
+
+type roleEmoji struct {
+	emoji string
+	role  classes.Role
+}
+
+// roleEmojis are added to the announcements in this order
+var roleEmojis = []roleEmoji{
+	{emoji: "🛡️", role: classes.Tank},
+	{emoji: "💚", role: classes.Healer},
+	{emoji: "⚔️", role: classes.Melee},
+	{emoji: "🏹", role: classes.Ranged},
+}
+
+func emojiRole(emoji string) (classes.Role, bool) {
+	key := strings.Replace(emoji, variationSelector, "", -1)
+	for _, re := range roleEmojis {
+		if strings.Replace(re.emoji, variationSelector, "", -1) == key {
+			return re.role, true
+		}
+	}
+	return "", false
+}
+
+func announcementEmbed(raid entities.Raid, signups []entities.Signup) *prototype.Embed {
+	embed := rosterEmbed(raid, signups)
+	if raid.Cancelled {
+		return embed
+	}
+
+	options := make([]string, 0, len(roleEmojis))
+	for _, re := range roleEmojis {
+		options = append(options, fmt.Sprintf("%s %s", re.emoji, re.role))
+	}
+	embed.Description += fmt.Sprintf("\nreact with %s to sign up, remove your reaction to sign down", strings.Join(options, ", "))
+	return embed
+}
+
+// announced keeps where a raid has been announced, the last announcement is the one that gets updated
+func (d *raidCommands) announced(data prototype.RaidDataProvider, raidId string, channel string, messageId string) error {
+	if err := data.AddAnnouncement(raidId, messageId); err != nil {
+		return err
+	}
+
+	raid, err := data.GetRaid(raidId)
+	if err != nil {
+		return err
+	}
+	raid.Channel = channel
+	raid.Message = messageId
+	return data.UpdateRaid(raid)
+}
+
+func (d *raidCommands) announce(data prototype.RaidDataProvider, req *prototype.Request, args []string) *prototype.Response {
+	argc := len(args)
+	if argc > 0 {
+		raid, msg := d.getOpenRaid(data, args[0])
+		if msg != "" {
+			return command.Text(msg)
+		}
+
+		signups, err := data.GetSignups(raid.Id)
+		if err != nil {
+			return command.Text(shared.Failure(err, raid.Id))
+		}
+
+		response := command.Embed(announcementEmbed(raid, signups))
+		for _, re := range roleEmojis {
+			response.Messages[0].Reactions = append(response.Messages[0].Reactions, re.emoji)
+		}
+		response.Messages[0].Sent = func(channel string, messageId string) {
+			if err := d.announced(data, raid.Id, channel, messageId); err != nil {
+				log, _ := zap.NewProduction()
+				defer log.Sync()
+
+				log.Error("Error storing raid announcement.", zap.Error(err))
+			}
+		}
+		return response
+	}
+
+	return command.Text("")
+}
+
+// withRole changes the spec of a signup to one with the role, if the class has none the role is kept on its own
+func withRole(signup entities.Signup, role classes.Role) entities.Signup {
+	signup.Role = ""
+	if class, err := classes.FindClass(signup.Class); err == nil {
+		if spec, found := class.SpecFor(role); found {
+			signup.Spec = spec.Name
+			return signup
+		}
+	}
+	signup.Role = string(role)
+	return signup
+}
+
+func (d *raidCommands) reactionSignUp(data prototype.RaidDataProvider, raid entities.Raid, reaction *prototype.Reaction, role classes.Role) *prototype.Response {
+	if _, msg := d.getOpenRaid(data, raid.Id); msg != "" {
+		return command.Private(msg)
+	}
+
+	signup, updated := d.findSignup(data, raid.Id, reaction.Member)
+	if updated {
+		if roleOf(signup) == role {
+			return nil
+		}
+		signup = withRole(signup, role)
+	} else if main, found := d.findCharacter(data, reaction.Member, ""); found {
+		signup = withRole(entities.Signup{Member: reaction.Member, Char: main.Name, Class: main.Class, Spec: main.Spec}, role)
+	} else {
+		signup = entities.Signup{Member: reaction.Member, Char: reaction.MemberName, Role: string(role)}
+	}
+
+	place, notices, err := d.saveSignup(data, raid, signup)
+	if err != nil {
+		return command.Private(shared.Failure(err, raid.Id))
+	}
+
+	msg := fmt.Sprintf("signed up as **%s** for raid %s", role, shared.RaidTitle(raid))
+	if updated {
+		msg = fmt.Sprintf("signup for raid %s changed to **%s**", shared.RaidTitle(raid), role)
+	}
+	response := command.Private(msg + place)
+	response.Notices = notices
+	return d.withRosterUpdate(data, raid.Id, response)
+}
+
+// reactionSignDown only signs down when the reaction is the role of the signup, members may have reacted with several
+func (d *raidCommands) reactionSignDown(data prototype.RaidDataProvider, raid entities.Raid, reaction *prototype.Reaction, role classes.Role) *prototype.Response {
+	signup, found := d.findSignup(data, raid.Id, reaction.Member)
+	if !found || roleOf(signup) != role {
+		return nil
+	}
+
+	if _, msg := d.getOpenRaid(data, raid.Id); msg != "" {
+		return command.Private(msg)
+	}
+
+	notices, err := d.removeSignup(data, raid, reaction.Member)
+	if err != nil {
+		return command.Private(shared.Failure(err, raid.Id))
+	}
+	response := command.Private(fmt.Sprintf("signed down from raid %s", shared.RaidTitle(raid)))
+	response.Notices = notices
+	return d.withRosterUpdate(data, raid.Id, response)
+}
+
+func (d *raidCommands) OnReaction(reaction *prototype.Reaction) *prototype.Response {
+	role, found := emojiRole(reaction.Emoji)
+	if !found || reaction.Guild == "" {
+		return nil
+	}
+
+	data := d.store.Guild(reaction.Guild)
+	raid, err := data.GetAnnouncedRaid(reaction.MessageId)
+	if err == entities.ErrRaidNotFound {
+		return nil
+	} else if err != nil {
+		return command.Private(shared.Failure(err, ""))
+	}
+
+	if reaction.Added {
+		return d.reactionSignUp(data, raid, reaction, role)
+	}
+	return d.reactionSignDown(data, raid, reaction, role)
+}
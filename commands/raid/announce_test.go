@@ -0,0 +1,243 @@
+package raid
+
+import (
+	"github.com/juan-medina/cecibot/commands/raid/data/entities"
+	"github.com/juan-medina/cecibot/commands/raid/data/memory"
+	"github.com/juan-medina/cecibot/commands/raid/shared"
+	"github.com/juan-medina/cecibot/message"
+	"github.com/juan-medina/cecibot/prototype"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_raidCommands_announce(t *testing.T) {
+	prc := fakeProcessor{}
+	store := memory.New()
+	data := store.Guild(fakeGuild)
+	rc := newRaidCommands(prc, store, fakeNow)
+
+	raid := addRaid(t, data, "Molten Core", time.Date(2019, 11, 20, 20, 0, 0, 0, time.Local))
+
+	t.Run("should be only for officers", func(t *testing.T) {
+		got := rc.raid(newRequest([]string{"announce", raid.Id}, "456", fakeGuild)).String()
+		if got != shared.OfficersOnly {
+			t.Errorf("want %q, got %q", shared.OfficersOnly, got)
+		}
+	})
+
+	t.Run("should fail with an unknown raid", func(t *testing.T) {
+		got := rc.raid(newRequest([]string{"announce", "99"}, "123", fakeGuild)).String()
+		want := "raid **99** not found"
+		if got != want {
+			t.Errorf("want %q, got %q", want, got)
+		}
+	})
+
+	_ = data.SignUp(raid.Id, entities.Signup{Member: "1", Char: "Thrall", Class: "Shaman", Spec: "Restoration"})
+
+	t.Run("should announce the roster of the raid with the role reactions", func(t *testing.T) {
+		got := rc.raid(newRequest([]string{"announce", raid.Id}, "123", fakeGuild))
+
+		want := &prototype.Embed{
+			Title:       "Molten Core (1)",
+			Description: message.Timestamp(time.Date(2019, 11, 20, 20, 0, 0, 0, time.Local)) + "\nreact with 🛡️ Tank, 💚 Healer, ⚔️ Melee, 🏹 Ranged to sign up, remove your reaction to sign down",
+			Colour:      rosterColour,
+			Fields: []prototype.EmbedField{
+				{Name: "Tank (0)", Value: "-", Inline: true},
+				{Name: "Healer (1)", Value: "**Thrall** *Shaman* *Restoration* <@1>", Inline: true},
+				{Name: "Melee (0)", Value: "-", Inline: true},
+				{Name: "Ranged (0)", Value: "-", Inline: true},
+				{Name: "Classes", Value: "Shaman 1"},
+			},
+			Footer: "Total : 1",
+		}
+		assertEmbed(t, got, want)
+
+		msg := got.Messages[0]
+		if wantReactions := []string{"🛡️", "💚", "⚔️", "🏹"}; !reflect.DeepEqual(msg.Reactions, wantReactions) {
+			t.Errorf("want reactions %v, got %v", wantReactions, msg.Reactions)
+		}
+
+		msg.Sent("channel1", "m1")
+		announced, err := data.GetAnnouncedRaid("m1")
+		if err != nil || announced.Id != raid.Id {
+			t.Errorf("want announcement of raid %q, got %v, %v", raid.Id, announced, err)
+		}
+		if announced.Channel != "channel1" || announced.Message != "m1" {
+			t.Errorf("want the announcement stored in the raid, got %+v", announced)
+		}
+	})
+
+	assertRosterUpdate := func(t *testing.T, got *prototype.Response, wantTotal string) {
+		t.Helper()
+		if len(got.Edits) != 1 {
+			t.Fatalf("want the roster updated, got %+v", got)
+		}
+		edit := got.Edits[0]
+		if edit.Channel != "channel1" || edit.MessageId != "m1" || edit.Message.Embed == nil || edit.Message.Embed.Footer != wantTotal {
+			t.Errorf("want announcement edited with %q, got %+v", wantTotal, edit)
+		}
+	}
+
+	t.Run("should update the roster signing up", func(t *testing.T) {
+		got := rc.raid(newRequest([]string{"sign", "up", raid.Id, "Brox", "warrior", "fury"}, "2", fakeGuild))
+		assertRosterUpdate(t, got, "Total : 2")
+	})
+
+	t.Run("should update the roster signing down", func(t *testing.T) {
+		got := rc.raid(newRequest([]string{"sign", "down", raid.Id}, "2", fakeGuild))
+		assertRosterUpdate(t, got, "Total : 1")
+	})
+
+	t.Run("should update the roster reacting", func(t *testing.T) {
+		got := rc.OnReaction(&prototype.Reaction{Emoji: "🏹", Member: "3", Guild: fakeGuild, MessageId: "m1", Added: true})
+		assertRosterUpdate(t, got, "Total : 2")
+	})
+
+	t.Run("should not update the roster on failures", func(t *testing.T) {
+		got := rc.raid(newRequest([]string{"sign", "up", raid.Id, "Brox", "monk", "fury"}, "2", fakeGuild))
+		if len(got.Edits) != 0 {
+			t.Errorf("want no edits, got %+v", got.Edits)
+		}
+	})
+
+	t.Run("should update the roster cancelling", func(t *testing.T) {
+		got := rc.raid(newRequest([]string{"cancel", raid.Id}, "123", fakeGuild))
+		assertRosterUpdate(t, got, "Total : 2")
+		if embed := got.Edits[0].Message.Embed; embed.Colour != cancelledColour || strings.Contains(embed.Description, "react") {
+			t.Errorf("want a cancelled roster without sign up options, got %+v", embed)
+		}
+	})
+}
+
+func Test_raidCommands_OnReaction(t *testing.T) {
+	prc := fakeProcessor{}
+	store := memory.New()
+	data := store.Guild(fakeGuild)
+	rc := newRaidCommands(prc, store, fakeNow)
+
+	raid := addRaid(t, data, "Molten Core", time.Date(2019, 11, 20, 20, 0, 0, 0, time.Local))
+	past := addRaid(t, data, "Onyxia's Lair", time.Date(2019, 10, 20, 20, 0, 0, 0, time.Local))
+	_ = data.AddAnnouncement(raid.Id, "m1")
+	_ = data.AddAnnouncement(past.Id, "m2")
+
+	react := func(emoji string, member string, messageId string, added bool) string {
+		return rc.OnReaction(&prototype.Reaction{
+			Emoji:      emoji,
+			Member:     member,
+			MemberName: "Name" + member,
+			Guild:      fakeGuild,
+			MessageId:  messageId,
+			Added:      added,
+		}).String()
+	}
+
+	assertSignups := func(t *testing.T, want []entities.Signup) {
+		t.Helper()
+		got, _ := data.GetSignups(raid.Id)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("want signups %v, got %v", want, got)
+		}
+	}
+
+	t.Run("should ignore other messages and emojis", func(t *testing.T) {
+		for _, got := range []string{react("🛡️", "1", "m99", true), react("👍", "1", "m1", true)} {
+			if got != "" {
+				t.Errorf("want no response, got %q", got)
+			}
+		}
+		assertSignups(t, []entities.Signup{})
+	})
+
+	t.Run("should reply privately", func(t *testing.T) {
+		resp := rc.OnReaction(&prototype.Reaction{Emoji: "💚", Member: "1", Guild: fakeGuild, MessageId: "m2", Added: true})
+		if !resp.Private {
+			t.Errorf("want a private response, got %+v", resp)
+		}
+	})
+
+	cases := []struct {
+		name    string
+		emoji   string
+		member  string
+		message string
+		added   bool
+		want    string
+		signups []entities.Signup
+	}{
+		{
+			name:    "should not sign up to a started raid",
+			emoji:   "💚",
+			member:  "1",
+			message: "m2",
+			added:   true,
+			want:    "raid **Onyxia's Lair** (**2**) has already started",
+			signups: []entities.Signup{},
+		},
+		{
+			name:    "should sign up with the role",
+			emoji:   "💚",
+			member:  "1",
+			message: "m1",
+			added:   true,
+			want:    "signed up as **Healer** for raid **Molten Core** (**1**)",
+			signups: []entities.Signup{{Member: "1", Char: "Name1", Role: "Healer"}},
+		},
+		{
+			name:    "should accept emojis without variation selector",
+			emoji:   "\U0001F6E1",
+			member:  "1",
+			message: "m1",
+			added:   true,
+			want:    "signup for raid **Molten Core** (**1**) changed to **Tank**",
+			signups: []entities.Signup{{Member: "1", Char: "Name1", Role: "Tank"}},
+		},
+		{
+			name:    "should not sign down removing other role",
+			emoji:   "💚",
+			member:  "1",
+			message: "m1",
+			added:   false,
+			want:    "",
+			signups: []entities.Signup{{Member: "1", Char: "Name1", Role: "Tank"}},
+		},
+		{
+			name:    "should sign down removing the role",
+			emoji:   "🛡️",
+			member:  "1",
+			message: "m1",
+			added:   false,
+			want:    "signed down from raid **Molten Core** (**1**)",
+			signups: []entities.Signup{},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := react(tt.emoji, tt.member, tt.message, tt.added); got != tt.want {
+				t.Errorf("want %q, got %q", tt.want, got)
+			}
+			assertSignups(t, tt.signups)
+		})
+	}
+
+	t.Run("should change the spec of a character signup", func(t *testing.T) {
+		_ = data.SignUp(raid.Id, entities.Signup{Member: "2", Char: "Brox", Class: "Warrior", Spec: "Fury"})
+
+		want := "signup for raid **Molten Core** (**1**) changed to **Tank**"
+		if got := react("🛡️", "2", "m1", true); got != want {
+			t.Errorf("want %q, got %q", want, got)
+		}
+		if got := react("🛡️", "2", "m1", true); got != "" {
+			t.Errorf("want no response for the same role, got %q", got)
+		}
+
+		want = "signup for raid **Molten Core** (**1**) changed to **Ranged**"
+		if got := react("🏹", "2", "m1", true); got != want {
+			t.Errorf("want %q, got %q", want, got)
+		}
+		assertSignups(t, []entities.Signup{{Member: "2", Char: "Brox", Class: "Warrior", Spec: "Protection", Role: "Ranged"}})
+	})
+}
@@ -0,0 +1,283 @@
+package raid
+
+import (
+	"fmt"
+	"github.com/juan-medina/cecibot/commands/raid/data/entities"
+	"github.com/juan-medina/cecibot/commands/raid/shared"
+	"github.com/juan-medina/cecibot/prototype"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const defaultStatsRaids = 10
+
+const raidsOption = "raids="
+
+var closeAttendance = map[string]string{
+	"late":   entities.AttendanceLate,
+	"noshow": entities.AttendanceNoShow,
+}
+
+type memberStats struct {
+	member   string
+	raids    int
+	attended int
+	late     int
+	noShow   int
+	benched  int
+	streak   int
+}
+
+// percentage of the raids that the member was present, benched members were available so they count as present
+func (s memberStats) percentage() int {
+	if s.raids == 0 {
+		return 0
+	}
+	return (s.attended + s.late + s.benched) * 100 / s.raids
+}
+
+func countAttendance(attendance []entities.Attendance) string {
+	counts := make(map[string]int)
+	for _, record := range attendance {
+		counts[record.Status]++
+	}
+	return fmt.Sprintf("attended **%d**, late **%d**, no-show **%d**, benched **%d**",
+		counts[entities.AttendanceAttended], counts[entities.AttendanceLate], counts[entities.AttendanceNoShow], counts[entities.AttendanceBenched])
+}
+
+// closeRaid counts the signed up members as attended unless they are given as late or noshow, and the ones in the
+// bench or the waitlist as benched
+func (d *raidCommands) closeRaid(data prototype.RaidDataProvider, req *prototype.Request, args []string) string {
+	argc := len(args)
+	if argc > 0 {
+		raid, err := data.GetRaid(args[0])
+		if err != nil {
+			return shared.Failure(err, args[0])
+		}
+		if raid.Cancelled {
+			return fmt.Sprintf("raid %s has been cancelled", shared.RaidTitle(raid))
+		}
+		if raid.Date.After(d.now()) {
+			return fmt.Sprintf("raid %s has not started yet", shared.RaidTitle(raid))
+		}
+
+		given := make(map[string]string)
+		status := ""
+		for _, arg := range args[1:] {
+			if value, found := closeAttendance[strings.ToLower(arg)]; found {
+				status = value
+				continue
+			}
+			if status == "" {
+				return fmt.Sprintf("invalid member %q, give the members after *late* or *noshow*", arg)
+			}
+			member, err := mentionedMember(req, arg)
+			if err != nil {
+				return err.Error()
+			}
+			given[member] = status
+		}
+
+		signups, err := data.GetSignups(raid.Id)
+		if err != nil {
+			return shared.Failure(err, raid.Id)
+		}
+
+		attendance := make([]entities.Attendance, 0, len(signups))
+		for _, signup := range signups {
+			record := entities.Attendance{Member: signup.Member, Char: signup.Char, Status: entities.AttendanceAttended}
+			if signup.Status != entities.SignupConfirmed {
+				record.Status = entities.AttendanceBenched
+			}
+			if value, found := given[signup.Member]; found {
+				record.Status = value
+				delete(given, signup.Member)
+			}
+			attendance = append(attendance, record)
+		}
+
+		// members that came without signing up
+		members := make([]string, 0, len(given))
+		for member := range given {
+			members = append(members, member)
+		}
+		sort.Strings(members)
+		for _, member := range members {
+			attendance = append(attendance, entities.Attendance{Member: member, Status: given[member]})
+		}
+
+		if err := data.CloseRaid(raid.Id, attendance); err != nil {
+			return shared.Failure(err, raid.Id)
+		}
+
+		result := fmt.Sprintf("raid %s closed, %s", shared.RaidTitle(raid), countAttendance(attendance))
+		if raid.Closed {
+			result = fmt.Sprintf("attendance of raid %s updated, %s", shared.RaidTitle(raid), countAttendance(attendance))
+		}
+
+		changed, err := d.awardAttendance(data, raid, attendance, req.Author)
+		if err != nil {
+			return result + "\n" + shared.Failure(err, raid.Id)
+		}
+		if changed > 0 {
+			result += fmt.Sprintf("\nloot points updated for **%d** members", changed)
+		}
+		return result
+	}
+
+	return ""
+}
+
+func parseStatsRaids(args []string) ([]string, int, error) {
+	rest := make([]string, 0, len(args))
+	count := defaultStatsRaids
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, raidsOption) {
+			rest = append(rest, arg)
+			continue
+		}
+		value, err := strconv.Atoi(strings.TrimPrefix(arg, raidsOption))
+		if err != nil || value < 1 {
+			return nil, 0, fmt.Errorf("invalid number of raids %q, use *raids=N*", arg)
+		}
+		count = value
+	}
+	return rest, count, nil
+}
+
+func closedRaids(data prototype.RaidDataProvider, count int) ([]entities.Raid, error) {
+	raids, err := data.GetRaids()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]entities.Raid, 0, count)
+	for i := len(raids) - 1; i >= 0 && len(result) < count; i-- {
+		if raids[i].Closed && !raids[i].Cancelled {
+			result = append(result, raids[i])
+		}
+	}
+	return result, nil
+}
+
+// attendanceStats calculates the statistics of every member that has attended the raids, given the newest first
+func attendanceStats(data prototype.RaidDataProvider, raids []entities.Raid) (map[string]*memberStats, error) {
+	records := make([]map[string]string, 0, len(raids))
+	stats := make(map[string]*memberStats)
+	for _, raid := range raids {
+		attendance, err := data.GetAttendance(raid.Id)
+		if err != nil {
+			return nil, err
+		}
+		statuses := make(map[string]string, len(attendance))
+		for _, record := range attendance {
+			statuses[record.Member] = record.Status
+			if _, found := stats[record.Member]; !found {
+				stats[record.Member] = &memberStats{member: record.Member, raids: len(raids)}
+			}
+		}
+		records = append(records, statuses)
+	}
+
+	for member, s := range stats {
+		streak := true
+		for _, statuses := range records {
+			switch statuses[member] {
+			case entities.AttendanceAttended:
+				s.attended++
+			case entities.AttendanceLate:
+				s.late++
+			case entities.AttendanceBenched:
+				s.benched++
+			case entities.AttendanceNoShow:
+				s.noShow++
+				streak = false
+				continue
+			default:
+				streak = false
+				continue
+			}
+			if streak {
+				s.streak++
+			}
+		}
+	}
+	return stats, nil
+}
+
+func (d *raidCommands) stats(data prototype.RaidDataProvider, req *prototype.Request, args []string) string {
+	args, count, err := parseStatsRaids(args)
+	if err != nil {
+		return err.Error()
+	}
+
+	member := req.Author
+	if len(args) > 0 {
+		if member, err = mentionedMember(req, args[0]); err != nil {
+			return err.Error()
+		}
+	}
+
+	raids, err := closedRaids(data, count)
+	if err != nil {
+		return shared.Failure(err, "")
+	}
+	if len(raids) == 0 {
+		return "there are no closed raids"
+	}
+
+	stats, err := attendanceStats(data, raids)
+	if err != nil {
+		return shared.Failure(err, "")
+	}
+	s, found := stats[member]
+	if !found {
+		s = &memberStats{member: member, raids: len(raids)}
+	}
+
+	return fmt.Sprintf("attendance of <@%s> in the last **%d** raids: **%d%%**\n"+
+		"\tattended **%d**, late **%d**, no-show **%d**, benched **%d**\n"+
+		"\tcurrent streak of **%d** raids",
+		member, s.raids, s.percentage(), s.attended, s.late, s.noShow, s.benched, s.streak)
+}
+
+func (d *raidCommands) attendance(data prototype.RaidDataProvider, req *prototype.Request, args []string) string {
+	_, count, err := parseStatsRaids(args)
+	if err != nil {
+		return err.Error()
+	}
+
+	raids, err := closedRaids(data, count)
+	if err != nil {
+		return shared.Failure(err, "")
+	}
+	if len(raids) == 0 {
+		return "there are no closed raids"
+	}
+
+	stats, err := attendanceStats(data, raids)
+	if err != nil {
+		return shared.Failure(err, "")
+	}
+
+	sorted := make([]*memberStats, 0, len(stats))
+	for _, s := range stats {
+		sorted = append(sorted, s)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].percentage() != sorted[j].percentage() {
+			return sorted[i].percentage() > sorted[j].percentage()
+		}
+		if sorted[i].noShow != sorted[j].noShow {
+			return sorted[i].noShow < sorted[j].noShow
+		}
+		return sorted[i].member < sorted[j].member
+	})
+
+	result := fmt.Sprintf("attendance in the last **%d** raids:\n", len(raids))
+	for _, s := range sorted {
+		result += fmt.Sprintf("\t<@%s> : **%d%%**, no-show **%d**, streak **%d**\n", s.member, s.percentage(), s.noShow, s.streak)
+	}
+	return result
+}
@@ -0,0 +1,132 @@
+package raid
+
+import (
+	"github.com/juan-medina/cecibot/commands/raid/data/entities"
+	"github.com/juan-medina/cecibot/commands/raid/data/memory"
+	"github.com/juan-medina/cecibot/commands/raid/shared"
+	"testing"
+	"time"
+)
+
+func Test_raidCommands_attendance(t *testing.T) {
+	prc := fakeProcessor{}
+	store := memory.New()
+	data := store.Guild(fakeGuild)
+	rc := newRaidCommands(prc, store, fakeNow)
+
+	first := addRaid(t, data, "Molten Core", time.Date(2019, 10, 20, 20, 0, 0, 0, time.Local))
+	second := addRaid(t, data, "Onyxia's Lair", time.Date(2019, 10, 27, 20, 0, 0, 0, time.Local))
+	third := addRaid(t, data, "Molten Core", time.Date(2019, 10, 30, 20, 0, 0, 0, time.Local))
+	next := addRaid(t, data, "Blackwing Lair", time.Date(2019, 11, 20, 20, 0, 0, 0, time.Local))
+
+	signUp := func(raid entities.Raid, member string, status string) {
+		_ = data.SignUp(raid.Id, entities.Signup{Member: member, Char: "Char" + member, Class: "Shaman", Spec: "Restoration", Status: status})
+	}
+	signUp(first, "1", entities.SignupConfirmed)
+	signUp(first, "2", entities.SignupConfirmed)
+	signUp(first, "3", entities.SignupConfirmed)
+	signUp(second, "1", entities.SignupConfirmed)
+	signUp(second, "2", entities.SignupBenched)
+	signUp(third, "1", entities.SignupConfirmed)
+	signUp(third, "2", entities.SignupConfirmed)
+	signUp(third, "3", entities.SignupWaitlisted)
+
+	cases := []struct {
+		name   string
+		args   []string
+		author string
+		want   string
+	}{
+		{
+			name:   "there are no closed raids",
+			args:   []string{"stats"},
+			author: "1",
+			want:   "there are no closed raids",
+		},
+		{
+			name:   "should be only for officers",
+			args:   []string{"close", first.Id},
+			author: "1",
+			want:   shared.OfficersOnly,
+		},
+		{
+			name:   "should fail closing a raid not started",
+			args:   []string{"close", next.Id},
+			author: "123",
+			want:   "raid **Blackwing Lair** (**4**) has not started yet",
+		},
+		{
+			name:   "should fail with members without attendance",
+			args:   []string{"close", first.Id, "<@3>"},
+			author: "123",
+			want:   `invalid member "<@3>", give the members after *late* or *noshow*`,
+		},
+		{
+			name:   "should close a raid",
+			args:   []string{"close", first.Id, "noshow", "<@3>"},
+			author: "123",
+			want:   "raid **Molten Core** (**1**) closed, attended **2**, late **0**, no-show **1**, benched **0**",
+		},
+		{
+			name:   "should close a raid with benched members and members not signed up",
+			args:   []string{"close", second.Id, "late", "<@!4>"},
+			author: "123",
+			want:   "raid **Onyxia's Lair** (**2**) closed, attended **1**, late **1**, no-show **0**, benched **1**",
+		},
+		{
+			name:   "should close a raid with late members and no-shows",
+			args:   []string{"close", third.Id, "noshow", "<@3>", "late", "<@2>"},
+			author: "123",
+			want:   "raid **Molten Core** (**3**) closed, attended **1**, late **1**, no-show **1**, benched **0**",
+		},
+		{
+			name:   "should correct the attendance closing again",
+			args:   []string{"close", third.Id, "late", "<@2>", "noshow", "<@3>"},
+			author: "123",
+			want:   "attendance of raid **Molten Core** (**3**) updated, attended **1**, late **1**, no-show **1**, benched **0**",
+		},
+		{
+			name:   "should show the stats of the member",
+			args:   []string{"stats"},
+			author: "1",
+			want:   "attendance of <@1> in the last **3** raids: **100%**\n\tattended **3**, late **0**, no-show **0**, benched **0**\n\tcurrent streak of **3** raids",
+		},
+		{
+			name:   "should count benched members as present",
+			args:   []string{"stats", "<@2>"},
+			author: "1",
+			want:   "attendance of <@2> in the last **3** raids: **100%**\n\tattended **1**, late **1**, no-show **0**, benched **1**\n\tcurrent streak of **3** raids",
+		},
+		{
+			name:   "should show the stats in the last raids",
+			args:   []string{"stats", "3", "raids=2"},
+			author: "1",
+			want:   "attendance of <@3> in the last **2** raids: **0%**\n\tattended **0**, late **0**, no-show **1**, benched **0**\n\tcurrent streak of **0** raids",
+		},
+		{
+			name:   "should fail with an invalid number of raids",
+			args:   []string{"attendance", "raids=all"},
+			author: "1",
+			want:   `invalid number of raids "raids=all", use *raids=N*`,
+		},
+		{
+			name:   "should show the attendance of every member",
+			args:   []string{"attendance"},
+			author: "1",
+			want: "attendance in the last **3** raids:\n" +
+				"\t<@1> : **100%**, no-show **0**, streak **3**\n" +
+				"\t<@2> : **100%**, no-show **0**, streak **3**\n" +
+				"\t<@4> : **33%**, no-show **0**, streak **0**\n" +
+				"\t<@3> : **0%**, no-show **2**, streak **0**\n",
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			got := rc.raid(newRequest(tt.args, tt.author, fakeGuild)).String()
+			if got != tt.want {
+				t.Errorf("want %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package raid
+
+import (
+	"fmt"
+	"github.com/juan-medina/cecibot/commands/raid/classes"
+	"github.com/juan-medina/cecibot/commands/raid/data/entities"
+	"github.com/juan-medina/cecibot/commands/raid/shared"
+	"github.com/juan-medina/cecibot/prototype"
+	"strings"
+)
+
+const mainCharacter = "main"
+const altCharacter = "alt"
+
+func describeCharacter(character entities.Character) string {
+	kind := altCharacter
+	if character.Main {
+		kind = mainCharacter
+	}
+	return fmt.Sprintf("**%s** *%s* *%s* (%s)", character.Name, character.Class, character.Spec, kind)
+}
+
+// findCharacter gets a character of a member by its name, ignoring the case, or its main if the name is empty
+func (d *raidCommands) findCharacter(data prototype.RaidDataProvider, member string, name string) (entities.Character, bool) {
+	characters, err := data.GetCharacters(member)
+	if err != nil {
+		return entities.Character{}, false
+	}
+	for _, character := range characters {
+		if (name == "" && character.Main) || (name != "" && strings.EqualFold(character.Name, name)) {
+			return character, true
+		}
+	}
+	return entities.Character{}, false
+}
+
+// without class and spec the signup uses a character of the member, its main if no char is given
+func (d *raidCommands) characterSignup(data prototype.RaidDataProvider, req *prototype.Request, args []string) (entities.Signup, string) {
+	if len(args) > 2 {
+		class, spec, err := classes.Find(args[1], strings.Join(args[2:], " "))
+		if err != nil {
+			return entities.Signup{}, err.Error()
+		}
+		return entities.Signup{Member: req.Author, Char: args[0], Class: class.Name, Spec: spec.Name}, ""
+	}
+
+	name := ""
+	if len(args) > 0 {
+		name = args[0]
+	}
+	character, found := d.findCharacter(data, req.Author, name)
+	if !found && name == "" {
+		return entities.Signup{}, "you have no main character, add one with *raid char add* or sign up with a *char* *class* *spec*"
+	} else if !found {
+		return entities.Signup{}, fmt.Sprintf("you have no character **%s**, add it with *raid char add* or sign up with a *char* *class* *spec*", name)
+	}
+	return entities.Signup{Member: req.Author, Char: character.Name, Class: character.Class, Spec: character.Spec}, ""
+}
+
+func (d *raidCommands) addCharacter(data prototype.RaidDataProvider, req *prototype.Request, args []string) string {
+	kind := ""
+	if argc := len(args); argc > 3 && (args[argc-1] == mainCharacter || args[argc-1] == altCharacter) {
+		kind = args[argc-1]
+		args = args[:argc-1]
+	}
+
+	argc := len(args)
+	if argc > 2 {
+		class, spec, err := classes.Find(args[1], strings.Join(args[2:], " "))
+		if err != nil {
+			return err.Error()
+		}
+
+		character := entities.Character{Member: req.Author, Name: args[0], Class: class.Name, Spec: spec.Name}
+		current, updated := d.findCharacter(data, req.Author, character.Name)
+		if updated {
+			character.Name = current.Name
+		}
+		_, hasMain := d.findCharacter(data, req.Author, "")
+		switch {
+		case kind != "":
+			character.Main = kind == mainCharacter
+		case updated:
+			character.Main = current.Main
+		default:
+			// the first character of a member is its main
+			character.Main = !hasMain
+		}
+
+		if err := data.AddCharacter(character); err != nil {
+			return shared.Failure(err, "")
+		}
+		if updated {
+			return fmt.Sprintf("character %s updated", describeCharacter(character))
+		}
+		return fmt.Sprintf("character %s added", describeCharacter(character))
+	}
+
+	return ""
+}
+
+func (d *raidCommands) listCharacters(data prototype.RaidDataProvider, req *prototype.Request, args []string) string {
+	member, empty, header := req.Author, "you have no characters", "your characters:\n"
+	if len(args) > 0 {
+		if !shared.IsOfficer(d.GetProcessor(), data, req.Author) {
+			return shared.OfficersOnly
+		}
+		var err error
+		if member, err = mentionedMember(req, args[0]); err != nil {
+			return err.Error()
+		}
+		empty = fmt.Sprintf("<@%s> has no characters", member)
+		header = fmt.Sprintf("characters of <@%s>:\n", member)
+	}
+
+	characters, err := data.GetCharacters(member)
+	if err != nil {
+		return shared.Failure(err, "")
+	}
+	if len(characters) == 0 {
+		return empty
+	}
+
+	result := header
+	for _, character := range characters {
+		result += fmt.Sprintf("\t%s\n", describeCharacter(character))
+	}
+	return result
+}
+
+// deleteCharacter deletes a character of a member, if it was its main the next character becomes the main
+func (d *raidCommands) deleteCharacter(data prototype.RaidDataProvider, req *prototype.Request, args []string) string {
+	argc := len(args)
+	if argc > 0 {
+		character, found := d.findCharacter(data, req.Author, args[0])
+		if !found {
+			return fmt.Sprintf("you have no character **%s**", args[0])
+		}
+		if err := data.DeleteCharacter(req.Author, character.Name); err != nil {
+			return shared.Failure(err, "")
+		}
+
+		result := fmt.Sprintf("character **%s** deleted", character.Name)
+		if !character.Main {
+			return result
+		}
+		characters, err := data.GetCharacters(req.Author)
+		if err != nil {
+			return shared.Failure(err, "")
+		}
+		if len(characters) > 0 {
+			next := characters[0]
+			next.Main = true
+			if err := data.AddCharacter(next); err != nil {
+				return shared.Failure(err, "")
+			}
+			result += fmt.Sprintf(", **%s** is now your main", next.Name)
+		}
+		return result
+	}
+
+	return ""
+}
+
+func (d *raidCommands) character(data prototype.RaidDataProvider, req *prototype.Request, args []string) string {
+	argc := len(args)
+	if argc > 0 {
+		switch args[0] {
+		case "add":
+			return d.addCharacter(data, req, args[1:])
+		case "list":
+			return d.listCharacters(data, req, args[1:])
+		case "delete":
+			return d.deleteCharacter(data, req, args[1:])
+		}
+	}
+	return ""
+}
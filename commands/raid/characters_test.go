@@ -0,0 +1,146 @@
+package raid
+
+import (
+	"github.com/juan-medina/cecibot/commands/raid/data/entities"
+	"github.com/juan-medina/cecibot/commands/raid/data/memory"
+	"github.com/juan-medina/cecibot/commands/raid/shared"
+	"github.com/juan-medina/cecibot/prototype"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_raidCommands_character(t *testing.T) {
+	prc := fakeProcessor{}
+	store := memory.New()
+	data := store.Guild(fakeGuild)
+	rc := newRaidCommands(prc, store, fakeNow)
+
+	raid := addRaid(t, data, "Molten Core", time.Date(2019, 11, 20, 20, 0, 0, 0, time.Local))
+
+	cases := []struct {
+		name   string
+		args   []string
+		author string
+		want   string
+	}{
+		{
+			name:   "should have no characters",
+			args:   []string{"char", "list"},
+			author: "1",
+			want:   "you have no characters",
+		},
+		{
+			name:   "should fail signing up without a main",
+			args:   []string{"sign", "up", raid.Id},
+			author: "1",
+			want:   "you have no main character, add one with *raid char add* or sign up with a *char* *class* *spec*",
+		},
+		{
+			name:   "should fail adding a character with an invalid class",
+			args:   []string{"char", "add", "Thrall", "monk", "mistweaver"},
+			author: "1",
+			want:   "invalid class \"monk\", valid classes are: Druid, Hunter, Mage, Paladin, Priest, Rogue, Shaman, Warlock, Warrior",
+		},
+		{
+			name:   "should add the first character as main",
+			args:   []string{"char", "add", "Thrall", "shaman", "restoration"},
+			author: "1",
+			want:   "character **Thrall** *Shaman* *Restoration* (main) added",
+		},
+		{
+			name:   "should add the next characters as alts",
+			args:   []string{"char", "add", "Rexxar", "hunter", "beast", "mastery"},
+			author: "1",
+			want:   "character **Rexxar** *Hunter* *Beast Mastery* (alt) added",
+		},
+		{
+			name:   "should update a character keeping if it is the main",
+			args:   []string{"char", "add", "thrall", "shaman", "elemental"},
+			author: "1",
+			want:   "character **Thrall** *Shaman* *Elemental* (main) updated",
+		},
+		{
+			name:   "should list the characters",
+			args:   []string{"char", "list"},
+			author: "1",
+			want:   "your characters:\n\t**Thrall** *Shaman* *Elemental* (main)\n\t**Rexxar** *Hunter* *Beast Mastery* (alt)\n",
+		},
+		{
+			name:   "should sign up with the main",
+			args:   []string{"sign", "up", raid.Id},
+			author: "1",
+			want:   "**Thrall** signed up as *Shaman* *Elemental* for raid **Molten Core** (**1**)",
+		},
+		{
+			name:   "should sign up with a character by its name",
+			args:   []string{"sign", "up", raid.Id, "rexxar"},
+			author: "1",
+			want:   "signup for raid **Molten Core** (**1**) updated to **Rexxar** as *Hunter* *Beast Mastery*",
+		},
+		{
+			name:   "should fail signing up with an unknown character",
+			args:   []string{"sign", "up", raid.Id, "Jaina"},
+			author: "1",
+			want:   "you have no character **Jaina**, add it with *raid char add* or sign up with a *char* *class* *spec*",
+		},
+		{
+			name:   "should change the main",
+			args:   []string{"char", "add", "Rexxar", "hunter", "survival", "main"},
+			author: "1",
+			want:   "character **Rexxar** *Hunter* *Survival* (main) updated",
+		},
+		{
+			name:   "should not let members list the characters of others",
+			args:   []string{"char", "list", "<@1>"},
+			author: "2",
+			want:   shared.OfficersOnly,
+		},
+		{
+			name:   "should let officers list the characters of a member",
+			args:   []string{"char", "list", "<@!1>"},
+			author: "123",
+			want:   "characters of <@1>:\n\t**Thrall** *Shaman* *Elemental* (alt)\n\t**Rexxar** *Hunter* *Survival* (main)\n",
+		},
+		{
+			name:   "should fail deleting an unknown character",
+			args:   []string{"char", "delete", "Jaina"},
+			author: "1",
+			want:   "you have no character **Jaina**",
+		},
+		{
+			name:   "should make other character the main when the main is deleted",
+			args:   []string{"char", "delete", "rexxar"},
+			author: "1",
+			want:   "character **Rexxar** deleted, **Thrall** is now your main",
+		},
+		{
+			name:   "should delete the last character",
+			args:   []string{"char", "delete", "Thrall"},
+			author: "1",
+			want:   "character **Thrall** deleted",
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			got := rc.raid(newRequest(tt.args, tt.author, fakeGuild)).String()
+			if got != tt.want {
+				t.Errorf("want %q, got %q", tt.want, got)
+			}
+		})
+	}
+
+	t.Run("should sign up with the main reacting to an announcement", func(t *testing.T) {
+		_ = data.AddCharacter(entities.Character{Member: "2", Name: "Jaina", Class: "Mage", Spec: "Arcane", Main: true})
+		_ = data.AddAnnouncement(raid.Id, "m1")
+
+		_ = rc.OnReaction(&prototype.Reaction{Emoji: "🏹", Member: "2", MemberName: "jaina_proudmoore", Guild: fakeGuild, MessageId: "m1", Added: true})
+
+		signup, _ := rc.findSignup(data, raid.Id, "2")
+		want := entities.Signup{Member: "2", Char: "Jaina", Class: "Mage", Spec: "Arcane"}
+		if !reflect.DeepEqual(signup, want) {
+			t.Errorf("want signup %v, got %v", want, signup)
+		}
+	})
+}
@@ -0,0 +1,114 @@
+package classes
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Role string
+
+const (
+	Tank   Role = "Tank"
+	Healer Role = "Healer"
+	Melee  Role = "Melee"
+	Ranged Role = "Ranged"
+)
+
+var Roles = []Role{Tank, Healer, Melee, Ranged}
+
+type Spec struct {
+	Name string
+	Role Role
+}
+
+type Class struct {
+	Name  string
+	Specs []Spec
+}
+
+var classes = []Class{
+	{Name: "Druid", Specs: []Spec{{"Balance", Ranged}, {"Feral", Tank}, {"Restoration", Healer}}},
+	{Name: "Hunter", Specs: []Spec{{"Beast Mastery", Ranged}, {"Marksmanship", Ranged}, {"Survival", Ranged}}},
+	{Name: "Mage", Specs: []Spec{{"Arcane", Ranged}, {"Fire", Ranged}, {"Frost", Ranged}}},
+	{Name: "Paladin", Specs: []Spec{{"Holy", Healer}, {"Protection", Tank}, {"Retribution", Melee}}},
+	{Name: "Priest", Specs: []Spec{{"Discipline", Healer}, {"Holy", Healer}, {"Shadow", Ranged}}},
+	{Name: "Rogue", Specs: []Spec{{"Assassination", Melee}, {"Combat", Melee}, {"Subtlety", Melee}}},
+	{Name: "Shaman", Specs: []Spec{{"Elemental", Ranged}, {"Enhancement", Melee}, {"Restoration", Healer}}},
+	{Name: "Warlock", Specs: []Spec{{"Affliction", Ranged}, {"Demonology", Ranged}, {"Destruction", Ranged}}},
+	{Name: "Warrior", Specs: []Spec{{"Arms", Melee}, {"Fury", Melee}, {"Protection", Tank}}},
+}
+
+func normalize(text string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '_', '\'':
+			return -1
+		}
+		return r
+	}, strings.ToLower(text))
+}
+
+func (c Class) specNames() []string {
+	names := make([]string, 0, len(c.Specs))
+	for _, spec := range c.Specs {
+		names = append(names, spec.Name)
+	}
+	return names
+}
+
+func (c Class) FindSpec(spec string) (Spec, error) {
+	key := normalize(spec)
+	for _, s := range c.Specs {
+		if normalize(s.Name) == key {
+			return s, nil
+		}
+	}
+	return Spec{}, fmt.Errorf("invalid spec %q for **%s**, valid specs are: %s", spec, c.Name, strings.Join(c.specNames(), ", "))
+}
+
+func (c Class) SpecFor(role Role) (Spec, bool) {
+	for _, spec := range c.Specs {
+		if spec.Role == role {
+			return spec, true
+		}
+	}
+	return Spec{}, false
+}
+
+func Names() []string {
+	names := make([]string, 0, len(classes))
+	for _, class := range classes {
+		names = append(names, class.Name)
+	}
+	return names
+}
+
+func FindClass(class string) (Class, error) {
+	key := normalize(class)
+	for _, c := range classes {
+		if normalize(c.Name) == key {
+			return c, nil
+		}
+	}
+	return Class{}, fmt.Errorf("invalid class %q, valid classes are: %s", class, strings.Join(Names(), ", "))
+}
+
+func Find(class string, spec string) (Class, Spec, error) {
+	c, err := FindClass(class)
+	if err != nil {
+		return Class{}, Spec{}, err
+	}
+	s, err := c.FindSpec(spec)
+	if err != nil {
+		return Class{}, Spec{}, err
+	}
+	return c, s, nil
+}
+
+func RoleOf(class string, spec string) Role {
+	_, s, err := Find(class, spec)
+	if err != nil {
+		return ""
+	}
+	return s.Role
+}
@@ -0,0 +1,105 @@
+package classes
+
+import "testing"
+
+func TestFind(t *testing.T) {
+	type testCase struct {
+		name      string
+		class     string
+		spec      string
+		wantClass string
+		wantSpec  string
+		wantErr   string
+	}
+
+	cases := []testCase{
+		{
+			name:      "exact names",
+			class:     "Shaman",
+			spec:      "Restoration",
+			wantClass: "Shaman",
+			wantSpec:  "Restoration",
+		},
+		{
+			name:      "any case",
+			class:     "wARRIOR",
+			spec:      "protection",
+			wantClass: "Warrior",
+			wantSpec:  "Protection",
+		},
+		{
+			name:      "spec without spaces",
+			class:     "hunter",
+			spec:      "beastmastery",
+			wantClass: "Hunter",
+			wantSpec:  "Beast Mastery",
+		},
+		{
+			name:      "spec with hyphens",
+			class:     "hunter",
+			spec:      "beast-mastery",
+			wantClass: "Hunter",
+			wantSpec:  "Beast Mastery",
+		},
+		{
+			name:    "invalid class",
+			class:   "necromancer",
+			spec:    "unholy",
+			wantErr: "invalid class \"necromancer\", valid classes are: Druid, Hunter, Mage, Paladin, Priest, Rogue, Shaman, Warlock, Warrior",
+		},
+		{
+			name:    "invalid spec for class",
+			class:   "mage",
+			spec:    "holy",
+			wantErr: "invalid spec \"holy\" for **Mage**, valid specs are: Arcane, Fire, Frost",
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			class, spec, err := Find(tt.class, tt.spec)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("want error %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("want not error, got %v", err)
+				return
+			}
+			if class.Name != tt.wantClass {
+				t.Errorf("want class %q, got %q", tt.wantClass, class.Name)
+			}
+			if spec.Name != tt.wantSpec {
+				t.Errorf("want spec %q, got %q", tt.wantSpec, spec.Name)
+			}
+		})
+	}
+}
+
+func TestRoleOf(t *testing.T) {
+	type testCase struct {
+		class string
+		spec  string
+		want  Role
+	}
+
+	cases := []testCase{
+		{"warrior", "protection", Tank},
+		{"warrior", "fury", Melee},
+		{"druid", "feral", Tank},
+		{"priest", "holy", Healer},
+		{"priest", "shadow", Ranged},
+		{"shaman", "enhancement", Melee},
+		{"necromancer", "unholy", ""},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.class+" "+tt.spec, func(t *testing.T) {
+			if got := RoleOf(tt.class, tt.spec); got != tt.want {
+				t.Errorf("want %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
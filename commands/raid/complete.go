@@ -0,0 +1,193 @@
+package raid
+
+import (
+	"fmt"
+	"github.com/juan-medina/cecibot/commands/raid/classes"
+	"github.com/juan-medina/cecibot/commands/raid/data/entities"
+	"github.com/juan-medina/cecibot/prototype"
+	"sort"
+	"strings"
+	"time"
+)
+
+// the options that take a raid-id, with the position of the raid-id in the arguments
+var raidIdPositions = map[string]int{
+	"roster":   1,
+	"rooster":  1,
+	"cancel":   1,
+	"cancels":  1,
+	"bench":    1,
+	"unbench":  1,
+	"announce": 1,
+	"close":    1,
+}
+
+// completable tells if a raid is offered to complete the raid-id of an option, the upcoming raids but for closing them
+// that is done once they have started
+func completable(option string, raid entities.Raid, now time.Time) bool {
+	if raid.Cancelled {
+		return false
+	}
+	if option == "close" {
+		return !raid.Closed && !raid.Date.After(now)
+	}
+	return !raid.Date.Before(now)
+}
+
+func quote(value string) string {
+	if strings.Contains(value, " ") {
+		return fmt.Sprintf("%q", value)
+	}
+	return value
+}
+
+func choices(values []string, partial string) []prototype.Choice {
+	result := make([]prototype.Choice, 0)
+	prefix := strings.ToLower(partial)
+	for _, value := range values {
+		if strings.HasPrefix(strings.ToLower(value), prefix) {
+			result = append(result, prototype.Choice{Name: value, Value: quote(value)})
+		}
+	}
+	return result
+}
+
+// requestData is the raid data of the server of a request, nil if in direct messages no server is selected
+func (d *raidCommands) requestData(req *prototype.Request) prototype.RaidDataProvider {
+	guild := req.Guild
+	if guild == "" {
+		if guild, _ = d.directMessageGuild(req); guild == "" {
+			return nil
+		}
+	}
+	return d.store.Guild(guild)
+}
+
+func (d *raidCommands) completeRaidId(req *prototype.Request, option string, partial string) []prototype.Choice {
+	data := d.requestData(req)
+	if data == nil {
+		return nil
+	}
+
+	raids, err := data.GetRaids()
+	if err != nil {
+		return nil
+	}
+
+	now := d.now()
+	loc := d.memberLocation(req.Author)
+	result := make([]prototype.Choice, 0)
+	for _, raid := range raids {
+		if !completable(option, raid, now) || !strings.HasPrefix(raid.Id, partial) {
+			continue
+		}
+		result = append(result, prototype.Choice{
+			Name:  fmt.Sprintf("%s : %s, %s", raid.Id, raid.Name, formatDate(raid.Date, loc)),
+			Value: raid.Id,
+		})
+	}
+	return result
+}
+
+func (d *raidCommands) completeCharacter(req *prototype.Request, partial string) []prototype.Choice {
+	data := d.requestData(req)
+	if data == nil {
+		return nil
+	}
+
+	characters, err := data.GetCharacters(req.Author)
+	if err != nil {
+		return nil
+	}
+
+	names := make([]string, 0, len(characters))
+	for _, character := range characters {
+		names = append(names, character.Name)
+	}
+	return choices(names, partial)
+}
+
+func completeSpec(class string, partial string) []prototype.Choice {
+	c, err := classes.FindClass(class)
+	if err != nil {
+		return nil
+	}
+
+	specs := make([]string, 0, len(c.Specs))
+	for _, spec := range c.Specs {
+		specs = append(specs, spec.Name)
+	}
+	return choices(specs, partial)
+}
+
+func (d *raidCommands) complete(req *prototype.Request) []prototype.Choice {
+	args := req.Args
+	argc := len(args)
+	if argc == 0 {
+		return nil
+	}
+
+	partial := args[argc-1]
+	if argc == 1 {
+		options := []string{"server"}
+		for key := range d.subCommands {
+			options = append(options, key)
+		}
+		sort.Strings(options)
+		return choices(options, partial)
+	}
+
+	if position, found := raidIdPositions[args[0]]; found && argc-1 == position {
+		return d.completeRaidId(req, args[0], partial)
+	}
+
+	if args[0] == "schedule" && argc == 2 {
+		return choices([]string{"add", "list", "delete"}, partial)
+	}
+
+	if args[0] == "export" {
+		switch {
+		case argc == 2:
+			return choices(exportOptions, partial)
+		case args[1] == "roster" && argc == 3:
+			return d.completeRaidId(req, args[0], partial)
+		case args[1] == "roster" && argc == 4, args[1] != "roster" && args[1] != "calendar" && argc == 3:
+			return choices(exportFormats, partial)
+		}
+		return nil
+	}
+
+	if args[0] == "char" {
+		switch {
+		case argc == 2:
+			return choices([]string{"add", "list", "delete"}, partial)
+		case args[1] == "delete" && argc == 3:
+			return d.completeCharacter(req, partial)
+		case args[1] == "add" && argc == 4:
+			return choices(classes.Names(), partial)
+		case args[1] == "add" && argc == 5:
+			return completeSpec(args[3], partial)
+		}
+		return nil
+	}
+
+	if args[0] != "sign" {
+		return nil
+	}
+
+	if argc == 2 {
+		return choices([]string{"up", "down"}, partial)
+	}
+
+	switch {
+	case argc == 3:
+		return d.completeRaidId(req, args[0], partial)
+	case args[1] == "up" && argc == 4:
+		return d.completeCharacter(req, partial)
+	case args[1] == "up" && argc == 5:
+		return choices(classes.Names(), partial)
+	case args[1] == "up" && argc == 6:
+		return completeSpec(args[4], partial)
+	}
+	return nil
+}
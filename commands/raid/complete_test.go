@@ -0,0 +1,126 @@
+package raid
+
+import (
+	"github.com/juan-medina/cecibot/commands/raid/data/entities"
+	"github.com/juan-medina/cecibot/commands/raid/data/memory"
+	"github.com/juan-medina/cecibot/prototype"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_raidCommands_complete(t *testing.T) {
+	prc := fakeProcessor{}
+	store := memory.New()
+	data := store.Guild(fakeGuild)
+	rc := newRaidCommands(prc, store, fakeNow)
+
+	mc := addRaid(t, data, "Molten Core", fakeNow().Add(24*time.Hour))
+	addRaid(t, data, "Onyxia's Lair", fakeNow().Add(-24*time.Hour))
+	bwl := addRaid(t, data, "Blackwing Lair", fakeNow().Add(48*time.Hour))
+	bwl.Cancelled = true
+	_ = data.AddCharacter(entities.Character{Member: "456", Name: "Thrall", Class: "Shaman", Spec: "Restoration", Main: true})
+	_ = data.AddCharacter(entities.Character{Member: "456", Name: "Rexxar", Class: "Hunter", Spec: "Survival"})
+	_ = data.UpdateRaid(bwl)
+
+	mcChoice := prototype.Choice{Name: "1 : Molten Core, Sat 02 Nov 2019 12:00", Value: "1"}
+
+	tests := []struct {
+		name string
+		req  *prototype.Request
+		want []prototype.Choice
+	}{
+		{
+			name: "should complete options",
+			req:  newRequest([]string{"c"}, "456", fakeGuild),
+			want: []prototype.Choice{{Name: "cancel", Value: "cancel"}, {Name: "cancels", Value: "cancels"}, {Name: "char", Value: "char"}, {Name: "close", Value: "close"}, {Name: "create", Value: "create"}},
+		},
+		{
+			name: "should complete export options",
+			req:  newRequest([]string{"export", ""}, "456", fakeGuild),
+			want: []prototype.Choice{{Name: "raids", Value: "raids"}, {Name: "roster", Value: "roster"}, {Name: "attendance", Value: "attendance"}, {Name: "calendar", Value: "calendar"}},
+		},
+		{
+			name: "should complete export formats",
+			req:  newRequest([]string{"export", "roster", "1", "j"}, "456", fakeGuild),
+			want: []prototype.Choice{{Name: "json", Value: "json"}},
+		},
+		{
+			name: "should complete sign options",
+			req:  newRequest([]string{"sign", ""}, "456", fakeGuild),
+			want: []prototype.Choice{{Name: "up", Value: "up"}, {Name: "down", Value: "down"}},
+		},
+		{
+			name: "should complete open raids for sign up",
+			req:  newRequest([]string{"sign", "up", ""}, "456", fakeGuild),
+			want: []prototype.Choice{mcChoice},
+		},
+		{
+			name: "should complete raids by id",
+			req:  newRequest([]string{"roster", "2"}, "456", fakeGuild),
+			want: []prototype.Choice{},
+		},
+		{
+			name: "should complete raids for cancel",
+			req:  newRequest([]string{"cancel", "1"}, "456", fakeGuild),
+			want: []prototype.Choice{mcChoice},
+		},
+		{
+			name: "should complete raids for announce",
+			req:  newRequest([]string{"announce", ""}, "456", fakeGuild),
+			want: []prototype.Choice{mcChoice},
+		},
+		{
+			name: "should complete started raids for close",
+			req:  newRequest([]string{"close", ""}, "456", fakeGuild),
+			want: []prototype.Choice{{Name: "2 : Onyxia's Lair, Thu 31 Oct 2019 12:00", Value: "2"}},
+		},
+		{
+			name: "should complete classes",
+			req:  newRequest([]string{"sign", "up", mc.Id, "Thrall", "sh"}, "456", fakeGuild),
+			want: []prototype.Choice{{Name: "Shaman", Value: "Shaman"}},
+		},
+		{
+			name: "should complete and quote specs",
+			req:  newRequest([]string{"sign", "up", mc.Id, "Rexxar", "hunter", "b"}, "456", fakeGuild),
+			want: []prototype.Choice{{Name: "Beast Mastery", Value: `"Beast Mastery"`}},
+		},
+		{
+			name: "should not complete specs of invalid classes",
+			req:  newRequest([]string{"sign", "up", mc.Id, "Rexxar", "zzz", ""}, "456", fakeGuild),
+			want: nil,
+		},
+		{
+			name: "should complete the characters of the member",
+			req:  newRequest([]string{"sign", "up", mc.Id, "t"}, "456", fakeGuild),
+			want: []prototype.Choice{{Name: "Thrall", Value: "Thrall"}},
+		},
+		{
+			name: "should complete the characters to delete",
+			req:  newRequest([]string{"char", "delete", ""}, "456", fakeGuild),
+			want: []prototype.Choice{{Name: "Thrall", Value: "Thrall"}, {Name: "Rexxar", Value: "Rexxar"}},
+		},
+		{
+			name: "should not complete the characters of other members",
+			req:  newRequest([]string{"sign", "up", mc.Id, ""}, "789", fakeGuild),
+			want: []prototype.Choice{},
+		},
+		{
+			name: "should complete raids of the server in direct messages",
+			req:  newDirectMessage([]string{"roster", ""}, "456", prototype.GuildRef{Id: fakeGuild, Name: "First"}),
+			want: []prototype.Choice{mcChoice},
+		},
+		{
+			name: "should not complete raids without a server",
+			req:  newDirectMessage([]string{"roster", ""}, "456"),
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rc.complete(tt.req); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("want %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,704 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/juan-medina/cecibot/commands/raid/data/entities"
+	"github.com/juan-medina/cecibot/prototype"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const sqliteDriver = "sqlite3"
+
+type sqlStore struct {
+	db *sql.DB
+}
+
+type guildStore struct {
+	db    *sql.DB
+	guild string
+}
+
+func parseRaidId(id string) (int64, error) {
+	value, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return 0, entities.ErrRaidNotFound
+	}
+	return value, nil
+}
+
+func parseScheduleId(id string) (int64, error) {
+	value, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return 0, entities.ErrScheduleNotFound
+	}
+	return value, nil
+}
+
+// formatDays stores the week days of a schedule as their numbers separated by commas
+func formatDays(days []time.Weekday) string {
+	values := make([]string, 0, len(days))
+	for _, day := range days {
+		values = append(values, strconv.Itoa(int(day)))
+	}
+	return strings.Join(values, ",")
+}
+
+func parseDays(text string) ([]time.Weekday, error) {
+	result := make([]time.Weekday, 0)
+	if text == "" {
+		return result, nil
+	}
+	for _, value := range strings.Split(text, ",") {
+		day, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, time.Weekday(day))
+	}
+	return result, nil
+}
+
+// formatLimits stores the limits of a raid by role as role:limit separated by commas
+func formatLimits(limits map[string]int) string {
+	values := make([]string, 0, len(limits))
+	for role, limit := range limits {
+		values = append(values, fmt.Sprintf("%s:%d", role, limit))
+	}
+	sort.Strings(values)
+	return strings.Join(values, ",")
+}
+
+func parseLimits(text string) (map[string]int, error) {
+	if text == "" {
+		return nil, nil
+	}
+	result := make(map[string]int)
+	for _, value := range strings.Split(text, ",") {
+		parts := strings.SplitN(value, ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid raid limit %q", value)
+		}
+		limit, err := strconv.Atoi(parts[1])
+		if err != nil {
+			return nil, err
+		}
+		result[parts[0]] = limit
+	}
+	return result, nil
+}
+
+func (d *guildStore) AddOfficer(id string) error {
+	_, err := d.db.Exec(`INSERT INTO officers (guild, id) SELECT ?, ? WHERE NOT EXISTS (SELECT 1 FROM officers WHERE guild = ? AND id = ?)`,
+		d.guild, id, d.guild, id)
+	return err
+}
+
+func (d *guildStore) DeleteOfficer(id string) error {
+	_, err := d.db.Exec(`DELETE FROM officers WHERE guild = ? AND id = ?`, d.guild, id)
+	return err
+}
+
+func (d *guildStore) GetOfficers() ([]entities.Officer, error) {
+	rows, err := d.db.Query(`SELECT id FROM officers WHERE guild = ? ORDER BY id`, d.guild)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]entities.Officer, 0)
+	for rows.Next() {
+		officer := entities.Officer{}
+		if err := rows.Scan(&officer.Id); err != nil {
+			return nil, err
+		}
+		result = append(result, officer)
+	}
+	return result, rows.Err()
+}
+
+func (d *guildStore) AddRaid(raid entities.Raid) (entities.Raid, error) {
+	res, err := d.db.Exec(`INSERT INTO raids (guild, name, date, cancelled, channel, message, size, limits, closed) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		d.guild, raid.Name, raid.Date.UTC(), raid.Cancelled, raid.Channel, raid.Message, raid.Size, formatLimits(raid.Limits), raid.Closed)
+	if err != nil {
+		return entities.Raid{}, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return entities.Raid{}, err
+	}
+
+	raid.Id = strconv.FormatInt(id, 10)
+	return raid, nil
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanRaid(row scanner) (entities.Raid, error) {
+	var id int64
+	var limits string
+	raid := entities.Raid{}
+	if err := row.Scan(&id, &raid.Name, &raid.Date, &raid.Cancelled, &raid.Channel, &raid.Message, &raid.Size, &limits, &raid.Closed); err != nil {
+		return entities.Raid{}, err
+	}
+	var err error
+	if raid.Limits, err = parseLimits(limits); err != nil {
+		return entities.Raid{}, err
+	}
+	raid.Id = strconv.FormatInt(id, 10)
+	raid.Date = raid.Date.Local()
+	return raid, nil
+}
+
+func (d *guildStore) GetRaid(id string) (entities.Raid, error) {
+	raidId, err := parseRaidId(id)
+	if err != nil {
+		return entities.Raid{}, err
+	}
+
+	raid, err := scanRaid(d.db.QueryRow(`SELECT id, name, date, cancelled, channel, message, size, limits, closed FROM raids WHERE guild = ? AND id = ?`, d.guild, raidId))
+	if err == sql.ErrNoRows {
+		return entities.Raid{}, entities.ErrRaidNotFound
+	}
+	return raid, err
+}
+
+func (d *guildStore) GetRaids() ([]entities.Raid, error) {
+	rows, err := d.db.Query(`SELECT id, name, date, cancelled, channel, message, size, limits, closed FROM raids WHERE guild = ? ORDER BY date, id`, d.guild)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]entities.Raid, 0)
+	for rows.Next() {
+		raid, err := scanRaid(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, raid)
+	}
+	return result, rows.Err()
+}
+
+func checkAffected(res sql.Result, err error, notFound error) error {
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return notFound
+	}
+	return nil
+}
+
+func (d *guildStore) UpdateRaid(raid entities.Raid) error {
+	raidId, err := parseRaidId(raid.Id)
+	if err != nil {
+		return err
+	}
+
+	res, err := d.db.Exec(`UPDATE raids SET name = ?, date = ?, cancelled = ?, channel = ?, message = ?, size = ?, limits = ?, closed = ? WHERE guild = ? AND id = ?`,
+		raid.Name, raid.Date.UTC(), raid.Cancelled, raid.Channel, raid.Message, raid.Size, formatLimits(raid.Limits), raid.Closed, d.guild, raidId)
+	return checkAffected(res, err, entities.ErrRaidNotFound)
+}
+
+func (d *guildStore) inTransaction(fn func(tx *sql.Tx) error) error {
+	return inTransaction(d.db, fn)
+}
+
+func inTransaction(db *sql.DB, fn func(tx *sql.Tx) error) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (d *guildStore) raidExists(tx *sql.Tx, raidId int64) error {
+	var count int
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM raids WHERE guild = ? AND id = ?`, d.guild, raidId).Scan(&count); err != nil {
+		return err
+	}
+	if count == 0 {
+		return entities.ErrRaidNotFound
+	}
+	return nil
+}
+
+func (d *guildStore) DeleteRaid(id string) error {
+	raidId, err := parseRaidId(id)
+	if err != nil {
+		return err
+	}
+
+	return d.inTransaction(func(tx *sql.Tx) error {
+		if err := d.raidExists(tx, raidId); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`DELETE FROM signups WHERE raid_id = ?`, raidId); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`DELETE FROM announcements WHERE raid_id = ?`, raidId); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`DELETE FROM attendance WHERE raid_id = ?`, raidId); err != nil {
+			return err
+		}
+		_, err := tx.Exec(`DELETE FROM raids WHERE id = ?`, raidId)
+		return err
+	})
+}
+
+func (d *guildStore) SignUp(raidId string, signup entities.Signup) error {
+	id, err := parseRaidId(raidId)
+	if err != nil {
+		return err
+	}
+
+	return d.inTransaction(func(tx *sql.Tx) error {
+		if err := d.raidExists(tx, id); err != nil {
+			return err
+		}
+
+		res, err := tx.Exec(`UPDATE signups SET char = ?, class = ?, spec = ?, role = ?, status = ? WHERE raid_id = ? AND member = ?`,
+			signup.Char, signup.Class, signup.Spec, signup.Role, signup.Status, id, signup.Member)
+		if err := checkAffected(res, err, entities.ErrSignupNotFound); err != entities.ErrSignupNotFound {
+			return err
+		}
+
+		_, err = tx.Exec(`INSERT INTO signups (raid_id, member, char, class, spec, role, status) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			id, signup.Member, signup.Char, signup.Class, signup.Spec, signup.Role, signup.Status)
+		return err
+	})
+}
+
+func (d *guildStore) SignDown(raidId string, member string) error {
+	id, err := parseRaidId(raidId)
+	if err != nil {
+		return err
+	}
+
+	return d.inTransaction(func(tx *sql.Tx) error {
+		if err := d.raidExists(tx, id); err != nil {
+			return err
+		}
+		res, err := tx.Exec(`DELETE FROM signups WHERE raid_id = ? AND member = ?`, id, member)
+		return checkAffected(res, err, entities.ErrSignupNotFound)
+	})
+}
+
+func (d *guildStore) GetSignups(raidId string) ([]entities.Signup, error) {
+	id, err := parseRaidId(raidId)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []entities.Signup
+	err = d.inTransaction(func(tx *sql.Tx) error {
+		if err := d.raidExists(tx, id); err != nil {
+			return err
+		}
+
+		rows, err := tx.Query(`SELECT member, char, class, spec, role, status FROM signups WHERE raid_id = ? ORDER BY id`, id)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		result = make([]entities.Signup, 0)
+		for rows.Next() {
+			signup := entities.Signup{}
+			if err := rows.Scan(&signup.Member, &signup.Char, &signup.Class, &signup.Spec, &signup.Role, &signup.Status); err != nil {
+				return err
+			}
+			result = append(result, signup)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (d *guildStore) SetSignupStatus(raidId string, member string, status string) error {
+	id, err := parseRaidId(raidId)
+	if err != nil {
+		return err
+	}
+
+	return d.inTransaction(func(tx *sql.Tx) error {
+		if err := d.raidExists(tx, id); err != nil {
+			return err
+		}
+		res, err := tx.Exec(`UPDATE signups SET status = ? WHERE raid_id = ? AND member = ?`, status, id, member)
+		return checkAffected(res, err, entities.ErrSignupNotFound)
+	})
+}
+
+// CloseRaid records the attendance of a raid, replacing the previous one if it was already closed
+func (d *guildStore) CloseRaid(raidId string, attendance []entities.Attendance) error {
+	id, err := parseRaidId(raidId)
+	if err != nil {
+		return err
+	}
+
+	return d.inTransaction(func(tx *sql.Tx) error {
+		if err := d.raidExists(tx, id); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`DELETE FROM attendance WHERE raid_id = ?`, id); err != nil {
+			return err
+		}
+		for _, record := range attendance {
+			if _, err := tx.Exec(`INSERT INTO attendance (raid_id, member, char, status) VALUES (?, ?, ?, ?)`,
+				id, record.Member, record.Char, record.Status); err != nil {
+				return err
+			}
+		}
+		_, err := tx.Exec(`UPDATE raids SET closed = ? WHERE id = ?`, true, id)
+		return err
+	})
+}
+
+func (d *guildStore) GetAttendance(raidId string) ([]entities.Attendance, error) {
+	id, err := parseRaidId(raidId)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []entities.Attendance
+	err = d.inTransaction(func(tx *sql.Tx) error {
+		if err := d.raidExists(tx, id); err != nil {
+			return err
+		}
+
+		rows, err := tx.Query(`SELECT member, char, status FROM attendance WHERE raid_id = ? ORDER BY id`, id)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		result = make([]entities.Attendance, 0)
+		for rows.Next() {
+			record := entities.Attendance{}
+			if err := rows.Scan(&record.Member, &record.Char, &record.Status); err != nil {
+				return err
+			}
+			result = append(result, record)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (d *guildStore) GetSetting(key string) (string, error) {
+	var value string
+	err := d.db.QueryRow(`SELECT value FROM settings WHERE guild = ? AND key = ?`, d.guild, key).Scan(&value)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return value, err
+}
+
+func (d *guildStore) SetSetting(key string, value string) error {
+	return d.inTransaction(func(tx *sql.Tx) error {
+		if _, err := tx.Exec(`DELETE FROM settings WHERE guild = ? AND key = ?`, d.guild, key); err != nil {
+			return err
+		}
+		if value == "" {
+			return nil
+		}
+		_, err := tx.Exec(`INSERT INTO settings (guild, key, value) VALUES (?, ?, ?)`, d.guild, key, value)
+		return err
+	})
+}
+
+func (d *guildStore) AddAnnouncement(raidId string, messageId string) error {
+	id, err := parseRaidId(raidId)
+	if err != nil {
+		return err
+	}
+
+	return d.inTransaction(func(tx *sql.Tx) error {
+		if err := d.raidExists(tx, id); err != nil {
+			return err
+		}
+		_, err := tx.Exec(`INSERT OR REPLACE INTO announcements (message_id, raid_id) VALUES (?, ?)`, messageId, id)
+		return err
+	})
+}
+
+func (d *guildStore) GetAnnouncedRaid(messageId string) (entities.Raid, error) {
+	raid, err := scanRaid(d.db.QueryRow(`SELECT r.id, r.name, r.date, r.cancelled, r.channel, r.message, r.size, r.limits, r.closed FROM raids r
+		JOIN announcements a ON a.raid_id = r.id WHERE r.guild = ? AND a.message_id = ?`, d.guild, messageId))
+	if err == sql.ErrNoRows {
+		return entities.Raid{}, entities.ErrRaidNotFound
+	}
+	return raid, err
+}
+
+func (d *guildStore) AddSchedule(schedule entities.Schedule) (entities.Schedule, error) {
+	res, err := d.db.Exec(`INSERT INTO schedules (guild, name, days, hour, minute, location, until) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		d.guild, schedule.Name, formatDays(schedule.Days), schedule.Hour, schedule.Minute, schedule.Location, schedule.Until.UTC())
+	if err != nil {
+		return entities.Schedule{}, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return entities.Schedule{}, err
+	}
+
+	schedule.Id = strconv.FormatInt(id, 10)
+	return schedule, nil
+}
+
+func (d *guildStore) GetSchedules() ([]entities.Schedule, error) {
+	rows, err := d.db.Query(`SELECT id, name, days, hour, minute, location, until FROM schedules WHERE guild = ? ORDER BY id`, d.guild)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]entities.Schedule, 0)
+	for rows.Next() {
+		var id int64
+		var days string
+		schedule := entities.Schedule{}
+		if err := rows.Scan(&id, &schedule.Name, &days, &schedule.Hour, &schedule.Minute, &schedule.Location, &schedule.Until); err != nil {
+			return nil, err
+		}
+		if schedule.Days, err = parseDays(days); err != nil {
+			return nil, err
+		}
+		schedule.Id = strconv.FormatInt(id, 10)
+		schedule.Until = schedule.Until.Local()
+		result = append(result, schedule)
+	}
+	return result, rows.Err()
+}
+
+func (d *guildStore) UpdateSchedule(schedule entities.Schedule) error {
+	scheduleId, err := parseScheduleId(schedule.Id)
+	if err != nil {
+		return err
+	}
+
+	res, err := d.db.Exec(`UPDATE schedules SET name = ?, days = ?, hour = ?, minute = ?, location = ?, until = ? WHERE guild = ? AND id = ?`,
+		schedule.Name, formatDays(schedule.Days), schedule.Hour, schedule.Minute, schedule.Location, schedule.Until.UTC(), d.guild, scheduleId)
+	return checkAffected(res, err, entities.ErrScheduleNotFound)
+}
+
+func (d *guildStore) DeleteSchedule(id string) error {
+	scheduleId, err := parseScheduleId(id)
+	if err != nil {
+		return err
+	}
+
+	res, err := d.db.Exec(`DELETE FROM schedules WHERE guild = ? AND id = ?`, d.guild, scheduleId)
+	return checkAffected(res, err, entities.ErrScheduleNotFound)
+}
+
+func (d *guildStore) AddCharacter(character entities.Character) error {
+	return d.inTransaction(func(tx *sql.Tx) error {
+		if character.Main {
+			if _, err := tx.Exec(`UPDATE characters SET main = ? WHERE guild = ? AND member = ?`, false, d.guild, character.Member); err != nil {
+				return err
+			}
+		}
+
+		res, err := tx.Exec(`UPDATE characters SET class = ?, spec = ?, main = ? WHERE guild = ? AND member = ? AND name = ?`,
+			character.Class, character.Spec, character.Main, d.guild, character.Member, character.Name)
+		if err := checkAffected(res, err, entities.ErrCharacterNotFound); err != entities.ErrCharacterNotFound {
+			return err
+		}
+
+		_, err = tx.Exec(`INSERT INTO characters (guild, member, name, class, spec, main) VALUES (?, ?, ?, ?, ?, ?)`,
+			d.guild, character.Member, character.Name, character.Class, character.Spec, character.Main)
+		return err
+	})
+}
+
+func (d *guildStore) GetCharacters(member string) ([]entities.Character, error) {
+	rows, err := d.db.Query(`SELECT member, name, class, spec, main FROM characters WHERE guild = ? AND member = ? ORDER BY id`, d.guild, member)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]entities.Character, 0)
+	for rows.Next() {
+		character := entities.Character{}
+		if err := rows.Scan(&character.Member, &character.Name, &character.Class, &character.Spec, &character.Main); err != nil {
+			return nil, err
+		}
+		result = append(result, character)
+	}
+	return result, rows.Err()
+}
+
+func (d *guildStore) DeleteCharacter(member string, name string) error {
+	res, err := d.db.Exec(`DELETE FROM characters WHERE guild = ? AND member = ? AND name = ?`, d.guild, member, name)
+	return checkAffected(res, err, entities.ErrCharacterNotFound)
+}
+
+func (d *guildStore) AddTransaction(transaction entities.Transaction) (entities.Transaction, error) {
+	res, err := d.db.Exec(`INSERT INTO transactions (guild, member, points, kind, reason, raid, char, item, author, date)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`, d.guild, transaction.Member, transaction.Points, transaction.Kind, transaction.Reason,
+		transaction.Raid, transaction.Char, transaction.Item, transaction.Author, transaction.Date.UTC())
+	if err != nil {
+		return entities.Transaction{}, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return entities.Transaction{}, err
+	}
+
+	transaction.Id = strconv.FormatInt(id, 10)
+	return transaction, nil
+}
+
+// GetTransactions returns the transactions of a member, or of every member if it is empty, in the order they were made
+func (d *guildStore) GetTransactions(member string) ([]entities.Transaction, error) {
+	rows, err := d.db.Query(`SELECT id, member, points, kind, reason, raid, char, item, author, date FROM transactions
+		WHERE guild = ? AND (? = '' OR member = ?) ORDER BY id`, d.guild, member, member)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]entities.Transaction, 0)
+	for rows.Next() {
+		var id int64
+		transaction := entities.Transaction{}
+		if err := rows.Scan(&id, &transaction.Member, &transaction.Points, &transaction.Kind, &transaction.Reason, &transaction.Raid,
+			&transaction.Char, &transaction.Item, &transaction.Author, &transaction.Date); err != nil {
+			return nil, err
+		}
+		transaction.Id = strconv.FormatInt(id, 10)
+		transaction.Date = transaction.Date.Local()
+		result = append(result, transaction)
+	}
+	return result, rows.Err()
+}
+
+func (d *sqlStore) Guild(id string) prototype.RaidDataProvider {
+	return &guildStore{db: d.db, guild: id}
+}
+
+func (d *sqlStore) Guilds() ([]string, error) {
+	rows, err := d.db.Query(`SELECT guild FROM officers UNION SELECT guild FROM raids UNION SELECT guild FROM settings UNION SELECT guild FROM schedules
+		UNION SELECT guild FROM characters UNION SELECT guild FROM transactions ORDER BY guild`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]string, 0)
+	for rows.Next() {
+		var guild string
+		if err := rows.Scan(&guild); err != nil {
+			return nil, err
+		}
+		result = append(result, guild)
+	}
+	return result, rows.Err()
+}
+
+func (d *sqlStore) GetUserSetting(user string, key string) (string, error) {
+	var value string
+	err := d.db.QueryRow(`SELECT value FROM user_settings WHERE user = ? AND key = ?`, user, key).Scan(&value)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return value, err
+}
+
+func (d *sqlStore) SetUserSetting(user string, key string, value string) error {
+	return inTransaction(d.db, func(tx *sql.Tx) error {
+		if _, err := tx.Exec(`DELETE FROM user_settings WHERE user = ? AND key = ?`, user, key); err != nil {
+			return err
+		}
+		if value == "" {
+			return nil
+		}
+		_, err := tx.Exec(`INSERT INTO user_settings (user, key, value) VALUES (?, ?, ?)`, user, key, value)
+		return err
+	})
+}
+
+func (d *sqlStore) Close() error {
+	return d.db.Close()
+}
+
+// adoptLegacy moves the officers and raids stored before guilds were supported, that the migrations left without a
+// guild, to the configured legacy guild, no command could reach them otherwise.
+func adoptLegacy(db *sql.DB, legacyGuild string) error {
+	return inTransaction(db, func(tx *sql.Tx) error {
+		var count int
+		err := tx.QueryRow(`SELECT (SELECT COUNT(*) FROM officers WHERE guild = '') +
+			(SELECT COUNT(*) FROM raids WHERE guild = '')`).Scan(&count)
+		if err != nil || count == 0 {
+			return err
+		}
+		if legacyGuild == "" {
+			return errors.New("the database has data stored before guilds were supported, " +
+				"configure the legacy guild that keeps it")
+		}
+
+		if _, err := tx.Exec(`UPDATE OR IGNORE officers SET guild = ? WHERE guild = ''`, legacyGuild); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`DELETE FROM officers WHERE guild = ''`); err != nil {
+			return err
+		}
+		_, err = tx.Exec(`UPDATE raids SET guild = ? WHERE guild = ''`, legacyGuild)
+		return err
+	})
+}
+
+// New opens the database with the given driver and data source, migrating it to the last schema version. The data
+// stored before guilds were supported is moved to legacyGuild.
+func New(driver string, dataSource string, legacyGuild string) (prototype.RaidDataStore, error) {
+	db, err := sql.Open(driver, dataSource)
+	if err != nil {
+		return nil, err
+	}
+
+	if driver == sqliteDriver {
+		// sqlite allows a single writer, sharing one connection avoids locking errors
+		db.SetMaxOpenConns(1)
+	}
+
+	if _, err := Migrate(db); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	if err := adoptLegacy(db, legacyGuild); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	return &sqlStore{db: db}, nil
+}
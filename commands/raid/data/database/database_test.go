@@ -0,0 +1,160 @@
+package database
+
+import (
+	"database/sql"
+	"github.com/juan-medina/cecibot/commands/raid/data/datatest"
+	"github.com/juan-medina/cecibot/prototype"
+	_ "github.com/mattn/go-sqlite3"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func tempDir(t *testing.T) (string, func()) {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "cecibot")
+	if err != nil {
+		t.Fatalf("want not error creating temp dir, got %v", err)
+	}
+	return dir, func() { _ = os.RemoveAll(dir) }
+}
+
+func open(t *testing.T, path string) prototype.RaidDataStore {
+	t.Helper()
+	data, err := New(sqliteDriver, path, "")
+	if err != nil {
+		t.Fatalf("want not error opening %q, got %v", path, err)
+	}
+	return data
+}
+
+func TestSqlStore(t *testing.T) {
+	dir, clean := tempDir(t)
+	defer clean()
+
+	files := 0
+	datatest.TestProvider(t, func(t *testing.T) prototype.RaidDataStore {
+		files++
+		return open(t, filepath.Join(dir, strconv.Itoa(files)+".db"))
+	})
+}
+
+func TestSqlStore_persistence(t *testing.T) {
+	dir, clean := tempDir(t)
+	defer clean()
+
+	path := filepath.Join(dir, "raids.db")
+	var last prototype.RaidDataStore
+	datatest.TestPersistence(t, func(t *testing.T) prototype.RaidDataStore {
+		if last != nil {
+			_ = last.Close()
+		}
+		last = open(t, path)
+		return last
+	})
+	_ = last.Close()
+}
+
+func TestSqlStore_legacyGuild(t *testing.T) {
+	dir, clean := tempDir(t)
+	defer clean()
+
+	path := filepath.Join(dir, "raids.db")
+	db, err := sql.Open(sqliteDriver, path)
+	if err != nil {
+		t.Fatalf("want not error, got %v", err)
+	}
+	defer db.Close()
+	if _, err := Migrate(db); err != nil {
+		t.Fatalf("want not error, got %v", err)
+	}
+
+	// this is where the migrations leave the data stored before guilds were supported
+	_, err = db.Exec(`INSERT INTO officers (guild, id) VALUES ('', '123')`)
+	if err == nil {
+		_, err = db.Exec(`INSERT INTO raids (name, date) VALUES ('Molten Core', CURRENT_TIMESTAMP)`)
+	}
+	if err != nil {
+		t.Fatalf("want not error, got %v", err)
+	}
+
+	t.Run("should fail without a legacy guild", func(t *testing.T) {
+		if _, err := New(sqliteDriver, path, ""); err == nil {
+			t.Errorf("want error, got nil")
+		}
+	})
+
+	t.Run("should move the data to the legacy guild", func(t *testing.T) {
+		store, err := New(sqliteDriver, path, "guild1")
+		if err != nil {
+			t.Fatalf("want not error, got %v", err)
+		}
+		defer store.Close()
+
+		data := store.Guild("guild1")
+		if got, _ := data.GetOfficers(); len(got) != 1 {
+			t.Errorf("want legacy officers, got %v", got)
+		}
+		if got, _ := data.GetRaids(); len(got) != 1 {
+			t.Errorf("want legacy raids, got %v", got)
+		}
+	})
+
+	t.Run("should open once the data has been moved", func(t *testing.T) {
+		store, err := New(sqliteDriver, path, "")
+		if err != nil {
+			t.Fatalf("want not error, got %v", err)
+		}
+		_ = store.Close()
+	})
+}
+
+func TestMigrate(t *testing.T) {
+	dir, clean := tempDir(t)
+	defer clean()
+
+	db, err := sql.Open(sqliteDriver, filepath.Join(dir, "raids.db"))
+	if err != nil {
+		t.Fatalf("want not error, got %v", err)
+	}
+	defer db.Close()
+
+	t.Run("should apply all migrations to a new database", func(t *testing.T) {
+		got, err := Migrate(db)
+		if err != nil {
+			t.Errorf("want not error, got %v", err)
+			return
+		}
+		want := make([]int, 0)
+		for _, m := range migrations {
+			want = append(want, m.version)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("want %v, got %v", want, got)
+		}
+	})
+
+	t.Run("should not apply migrations twice", func(t *testing.T) {
+		got, err := Migrate(db)
+		if err != nil {
+			t.Errorf("want not error, got %v", err)
+			return
+		}
+		if len(got) != 0 {
+			t.Errorf("want no migrations, got %v", got)
+		}
+	})
+
+	t.Run("should fail with a newer schema", func(t *testing.T) {
+		_, err := db.Exec(`INSERT INTO schema_migrations (version, applied_at) VALUES (?, CURRENT_TIMESTAMP)`, 9999)
+		if err != nil {
+			t.Fatalf("want not error, got %v", err)
+		}
+		if _, err := Migrate(db); err == nil {
+			t.Errorf("want error, got nil")
+		}
+	})
+}
@@ -0,0 +1,226 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+type migration struct {
+	version    int
+	statements []string
+}
+
+// migrations are applied in order and never changed once released, any schema change is a new migration.
+var migrations = []migration{
+	{
+		version: 1,
+		statements: []string{
+			`CREATE TABLE officers (
+				id TEXT PRIMARY KEY
+			)`,
+			`CREATE TABLE raids (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				name TEXT NOT NULL,
+				date TIMESTAMP NOT NULL,
+				cancelled BOOLEAN NOT NULL DEFAULT FALSE
+			)`,
+			`CREATE TABLE signups (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				raid_id INTEGER NOT NULL REFERENCES raids (id),
+				member TEXT NOT NULL,
+				char TEXT NOT NULL,
+				class TEXT NOT NULL,
+				spec TEXT NOT NULL,
+				UNIQUE (raid_id, member)
+			)`,
+		},
+	},
+	{
+		version: 2,
+		statements: []string{
+			`CREATE TABLE guild_officers (
+				guild TEXT NOT NULL,
+				id TEXT NOT NULL,
+				PRIMARY KEY (guild, id)
+			)`,
+			`INSERT INTO guild_officers (guild, id) SELECT '', id FROM officers`,
+			`DROP TABLE officers`,
+			`ALTER TABLE guild_officers RENAME TO officers`,
+			`ALTER TABLE raids ADD COLUMN guild TEXT NOT NULL DEFAULT ''`,
+			`CREATE INDEX raids_guild_date ON raids (guild, date)`,
+		},
+	},
+	{
+		version: 3,
+		statements: []string{
+			`CREATE TABLE settings (
+				guild TEXT NOT NULL,
+				key TEXT NOT NULL,
+				value TEXT NOT NULL,
+				PRIMARY KEY (guild, key)
+			)`,
+		},
+	},
+	{
+		version: 4,
+		statements: []string{
+			`ALTER TABLE signups ADD COLUMN role TEXT NOT NULL DEFAULT ''`,
+			`CREATE TABLE announcements (
+				message_id TEXT PRIMARY KEY,
+				raid_id INTEGER NOT NULL REFERENCES raids (id)
+			)`,
+		},
+	},
+	{
+		version: 5,
+		statements: []string{
+			`ALTER TABLE raids ADD COLUMN channel TEXT NOT NULL DEFAULT ''`,
+			`ALTER TABLE raids ADD COLUMN message TEXT NOT NULL DEFAULT ''`,
+		},
+	},
+	{
+		version: 6,
+		statements: []string{
+			`CREATE TABLE schedules (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				guild TEXT NOT NULL,
+				name TEXT NOT NULL,
+				days TEXT NOT NULL,
+				hour INTEGER NOT NULL,
+				minute INTEGER NOT NULL,
+				location TEXT NOT NULL,
+				until TIMESTAMP NOT NULL
+			)`,
+		},
+	},
+	{
+		version: 7,
+		statements: []string{
+			`ALTER TABLE raids ADD COLUMN size INTEGER NOT NULL DEFAULT 0`,
+			`ALTER TABLE raids ADD COLUMN limits TEXT NOT NULL DEFAULT ''`,
+			`ALTER TABLE signups ADD COLUMN status TEXT NOT NULL DEFAULT ''`,
+		},
+	},
+	{
+		version: 8,
+		statements: []string{
+			`CREATE TABLE characters (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				guild TEXT NOT NULL,
+				member TEXT NOT NULL,
+				name TEXT NOT NULL,
+				class TEXT NOT NULL,
+				spec TEXT NOT NULL,
+				main BOOLEAN NOT NULL DEFAULT FALSE,
+				UNIQUE (guild, member, name)
+			)`,
+		},
+	},
+	{
+		version: 9,
+		statements: []string{
+			`ALTER TABLE raids ADD COLUMN closed BOOLEAN NOT NULL DEFAULT FALSE`,
+			`CREATE TABLE attendance (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				raid_id INTEGER NOT NULL REFERENCES raids (id),
+				member TEXT NOT NULL,
+				char TEXT NOT NULL,
+				status TEXT NOT NULL,
+				UNIQUE (raid_id, member)
+			)`,
+		},
+	},
+	{
+		version: 10,
+		statements: []string{
+			`CREATE TABLE transactions (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				guild TEXT NOT NULL,
+				member TEXT NOT NULL,
+				points INTEGER NOT NULL,
+				kind TEXT NOT NULL,
+				reason TEXT NOT NULL,
+				raid TEXT NOT NULL,
+				char TEXT NOT NULL,
+				item TEXT NOT NULL,
+				author TEXT NOT NULL,
+				date TIMESTAMP NOT NULL
+			)`,
+			`CREATE INDEX transactions_guild_member ON transactions (guild, member)`,
+		},
+	},
+	{
+		version: 11,
+		statements: []string{
+			`CREATE TABLE user_settings (
+				user TEXT NOT NULL,
+				key TEXT NOT NULL,
+				value TEXT NOT NULL,
+				PRIMARY KEY (user, key)
+			)`,
+		},
+	},
+}
+
+func schemaVersion(db *sql.DB) (int, error) {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		applied_at TIMESTAMP NOT NULL
+	)`)
+	if err != nil {
+		return 0, err
+	}
+
+	var version int
+	err = db.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version)
+	return version, err
+}
+
+func apply(db *sql.DB, m migration) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	for _, statement := range m.statements {
+		if _, err := tx.Exec(statement); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("migration %d failed: %v", m.version, err)
+		}
+	}
+
+	_, err = tx.Exec(`INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)`, m.version, time.Now().UTC())
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Migrate updates the database schema to the last version, returning the versions that has been applied.
+func Migrate(db *sql.DB) ([]int, error) {
+	current, err := schemaVersion(db)
+	if err != nil {
+		return nil, err
+	}
+
+	last := migrations[len(migrations)-1].version
+	if current > last {
+		return nil, fmt.Errorf("database schema version %d is newer than the supported version %d", current, last)
+	}
+
+	applied := make([]int, 0)
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+		if err := apply(db, m); err != nil {
+			return applied, err
+		}
+		applied = append(applied, m.version)
+	}
+
+	return applied, nil
+}
@@ -0,0 +1,717 @@
+// Package datatest provides a conformance suite that any prototype.RaidDataProvider should pass.
+package datatest
+
+import (
+	"github.com/juan-medina/cecibot/commands/raid/data/entities"
+	"github.com/juan-medina/cecibot/prototype"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// Factory returns a new empty store.
+type Factory func(t *testing.T) prototype.RaidDataStore
+
+// Opener returns a store over the same storage every time that is called.
+type Opener func(t *testing.T) prototype.RaidDataStore
+
+const guild = "guild1"
+const otherGuild = "guild2"
+
+var raidDate = time.Date(2019, 11, 20, 20, 0, 0, 0, time.UTC)
+
+func assertRaid(t *testing.T, got entities.Raid, want entities.Raid) {
+	t.Helper()
+	if got.Id != want.Id || got.Name != want.Name || !got.Date.Equal(want.Date) || got.Cancelled != want.Cancelled ||
+		got.Channel != want.Channel || got.Message != want.Message || got.Size != want.Size || !reflect.DeepEqual(got.Limits, want.Limits) ||
+		got.Closed != want.Closed {
+		t.Errorf("want raid %v, got %v", want, got)
+	}
+}
+
+func assertSchedule(t *testing.T, got entities.Schedule, want entities.Schedule) {
+	t.Helper()
+	if got.Id != want.Id || got.Name != want.Name || !reflect.DeepEqual(got.Days, want.Days) || got.Hour != want.Hour ||
+		got.Minute != want.Minute || got.Location != want.Location || !got.Until.Equal(want.Until) {
+		t.Errorf("want schedule %v, got %v", want, got)
+	}
+}
+
+func assertTransactions(t *testing.T, got []entities.Transaction, want []entities.Transaction) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("want transactions %v, got %v", want, got)
+	}
+	for i := range want {
+		g, w := got[i], want[i]
+		if g.Id != w.Id || g.Member != w.Member || g.Points != w.Points || g.Kind != w.Kind || g.Reason != w.Reason ||
+			g.Raid != w.Raid || g.Char != w.Char || g.Item != w.Item || g.Author != w.Author || !g.Date.Equal(w.Date) {
+			t.Errorf("want transaction %v, got %v", w, g)
+		}
+	}
+}
+
+func assertNoError(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("want not error, got %v", err)
+	}
+}
+
+func testOfficers(t *testing.T, data prototype.RaidDataProvider) {
+	if got, _ := data.GetOfficers(); len(got) != 0 {
+		t.Errorf("want no officers, got %v", got)
+	}
+
+	assertNoError(t, data.AddOfficer("456"))
+	assertNoError(t, data.AddOfficer("123"))
+	assertNoError(t, data.AddOfficer("123"))
+
+	want := []entities.Officer{{Id: "123"}, {Id: "456"}}
+	if got, _ := data.GetOfficers(); !reflect.DeepEqual(got, want) {
+		t.Errorf("want officers %v, got %v", want, got)
+	}
+
+	assertNoError(t, data.DeleteOfficer("123"))
+	assertNoError(t, data.DeleteOfficer("789"))
+
+	want = []entities.Officer{{Id: "456"}}
+	if got, _ := data.GetOfficers(); !reflect.DeepEqual(got, want) {
+		t.Errorf("want officers %v, got %v", want, got)
+	}
+}
+
+func testRaids(t *testing.T, data prototype.RaidDataProvider) {
+	if got, _ := data.GetRaids(); len(got) != 0 {
+		t.Errorf("want no raids, got %v", got)
+	}
+
+	mc, err := data.AddRaid(entities.Raid{Name: "Molten Core", Date: raidDate})
+	assertNoError(t, err)
+	ony, err := data.AddRaid(entities.Raid{Name: "Onyxia's Lair", Date: raidDate.Add(-24 * time.Hour)})
+	assertNoError(t, err)
+
+	if mc.Id == "" || mc.Id == ony.Id {
+		t.Errorf("want different raid ids, got %q and %q", mc.Id, ony.Id)
+	}
+
+	got, err := data.GetRaid(mc.Id)
+	assertNoError(t, err)
+	assertRaid(t, got, entities.Raid{Id: mc.Id, Name: "Molten Core", Date: raidDate})
+
+	if _, err := data.GetRaid("99"); err != entities.ErrRaidNotFound {
+		t.Errorf("want raid not found, got %v", err)
+	}
+
+	bwl, err := data.AddRaid(entities.Raid{Name: "Blackwing Lair", Date: raidDate, Size: 40, Limits: map[string]int{"Tank": 4}})
+	assertNoError(t, err)
+	got, err = data.GetRaid(bwl.Id)
+	assertNoError(t, err)
+	assertRaid(t, got, bwl)
+	assertNoError(t, data.DeleteRaid(bwl.Id))
+
+	raids, err := data.GetRaids()
+	assertNoError(t, err)
+	if len(raids) != 2 {
+		t.Fatalf("want 2 raids, got %v", raids)
+	}
+	assertRaid(t, raids[0], ony)
+	assertRaid(t, raids[1], mc)
+
+	mc.Cancelled = true
+	mc.Channel = "channel1"
+	mc.Message = "m1"
+	mc.Size = 40
+	mc.Limits = map[string]int{"Tank": 4, "Healer": 8}
+	assertNoError(t, data.UpdateRaid(mc))
+	got, err = data.GetRaid(mc.Id)
+	assertNoError(t, err)
+	assertRaid(t, got, mc)
+
+	// the limits given and returned are not the ones stored
+	mc.Limits["Tank"] = 10
+	got.Limits["Healer"] = 10
+	raids, err = data.GetRaids()
+	assertNoError(t, err)
+	for _, raid := range raids {
+		if raid.Id == mc.Id {
+			raid.Limits["Healer"] = 10
+		}
+	}
+	got, err = data.GetRaid(mc.Id)
+	assertNoError(t, err)
+	if want := map[string]int{"Tank": 4, "Healer": 8}; !reflect.DeepEqual(got.Limits, want) {
+		t.Errorf("want limits %v, got %v", want, got.Limits)
+	}
+
+	if err := data.UpdateRaid(entities.Raid{Id: "99"}); err != entities.ErrRaidNotFound {
+		t.Errorf("want raid not found updating, got %v", err)
+	}
+
+	assertNoError(t, data.DeleteRaid(ony.Id))
+	if _, err := data.GetRaid(ony.Id); err != entities.ErrRaidNotFound {
+		t.Errorf("want raid not found after delete, got %v", err)
+	}
+	if err := data.DeleteRaid(ony.Id); err != entities.ErrRaidNotFound {
+		t.Errorf("want raid not found deleting twice, got %v", err)
+	}
+
+	next, err := data.AddRaid(entities.Raid{Name: "Blackwing Lair", Date: raidDate})
+	assertNoError(t, err)
+	if next.Id == mc.Id || next.Id == ony.Id {
+		t.Errorf("want raid ids not to be reused, got %q", next.Id)
+	}
+}
+
+func testSignups(t *testing.T, data prototype.RaidDataProvider) {
+	raid, err := data.AddRaid(entities.Raid{Name: "Molten Core", Date: raidDate})
+	assertNoError(t, err)
+
+	thrall := entities.Signup{Member: "1", Char: "Thrall", Class: "Shaman", Spec: "Restoration"}
+	brox := entities.Signup{Member: "2", Char: "Brox", Class: "Warrior", Spec: "Protection"}
+	jaina := entities.Signup{Member: "3", Char: "Jaina", Role: "Ranged"}
+
+	assertNoError(t, data.SignUp(raid.Id, thrall))
+	assertNoError(t, data.SignUp(raid.Id, brox))
+	assertNoError(t, data.SignUp(raid.Id, jaina))
+
+	thrall.Spec = "Elemental"
+	assertNoError(t, data.SignUp(raid.Id, thrall))
+	jaina.Role = "Healer"
+	assertNoError(t, data.SignUp(raid.Id, jaina))
+	assertNoError(t, data.SignDown(raid.Id, jaina.Member))
+
+	got, err := data.GetSignups(raid.Id)
+	assertNoError(t, err)
+	want := []entities.Signup{thrall, brox}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want signups %v, got %v", want, got)
+	}
+
+	assertNoError(t, data.SignDown(raid.Id, thrall.Member))
+	if err := data.SignDown(raid.Id, thrall.Member); err != entities.ErrSignupNotFound {
+		t.Errorf("want signup not found, got %v", err)
+	}
+
+	got, err = data.GetSignups(raid.Id)
+	assertNoError(t, err)
+	want = []entities.Signup{brox}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want signups %v, got %v", want, got)
+	}
+
+	jaina.Status = entities.SignupWaitlisted
+	assertNoError(t, data.SignUp(raid.Id, jaina))
+	assertNoError(t, data.SetSignupStatus(raid.Id, brox.Member, entities.SignupBenched))
+	got, err = data.GetSignups(raid.Id)
+	assertNoError(t, err)
+	brox.Status = entities.SignupBenched
+	want = []entities.Signup{brox, jaina}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want signups with status %v, got %v", want, got)
+	}
+
+	if err := data.SetSignupStatus(raid.Id, thrall.Member, entities.SignupBenched); err != entities.ErrSignupNotFound {
+		t.Errorf("want signup not found setting status, got %v", err)
+	}
+	if err := data.SetSignupStatus("99", brox.Member, entities.SignupBenched); err != entities.ErrRaidNotFound {
+		t.Errorf("want raid not found setting status, got %v", err)
+	}
+
+	if err := data.SignUp("99", thrall); err != entities.ErrRaidNotFound {
+		t.Errorf("want raid not found signing up, got %v", err)
+	}
+	if err := data.SignDown("99", thrall.Member); err != entities.ErrRaidNotFound {
+		t.Errorf("want raid not found signing down, got %v", err)
+	}
+	if _, err := data.GetSignups("99"); err != entities.ErrRaidNotFound {
+		t.Errorf("want raid not found getting signups, got %v", err)
+	}
+
+	assertNoError(t, data.DeleteRaid(raid.Id))
+	if _, err := data.GetSignups(raid.Id); err != entities.ErrRaidNotFound {
+		t.Errorf("want raid not found after delete, got %v", err)
+	}
+}
+
+func testAttendance(t *testing.T, data prototype.RaidDataProvider) {
+	raid, err := data.AddRaid(entities.Raid{Name: "Molten Core", Date: raidDate})
+	assertNoError(t, err)
+
+	if got, err := data.GetAttendance(raid.Id); err != nil || len(got) != 0 {
+		t.Errorf("want no attendance, got %v, %v", got, err)
+	}
+
+	attendance := []entities.Attendance{
+		{Member: "1", Char: "Thrall", Status: entities.AttendanceAttended},
+		{Member: "2", Char: "Jaina", Status: entities.AttendanceNoShow},
+	}
+	assertNoError(t, data.CloseRaid(raid.Id, attendance))
+
+	got, err := data.GetRaid(raid.Id)
+	assertNoError(t, err)
+	raid.Closed = true
+	assertRaid(t, got, raid)
+	if got, _ := data.GetAttendance(raid.Id); !reflect.DeepEqual(got, attendance) {
+		t.Errorf("want attendance %v, got %v", attendance, got)
+	}
+
+	attendance = []entities.Attendance{{Member: "2", Char: "Jaina", Status: entities.AttendanceLate}}
+	assertNoError(t, data.CloseRaid(raid.Id, attendance))
+	if got, _ := data.GetAttendance(raid.Id); !reflect.DeepEqual(got, attendance) {
+		t.Errorf("want attendance replaced %v, got %v", attendance, got)
+	}
+
+	if err := data.CloseRaid("99", attendance); err != entities.ErrRaidNotFound {
+		t.Errorf("want raid not found closing, got %v", err)
+	}
+	if _, err := data.GetAttendance("99"); err != entities.ErrRaidNotFound {
+		t.Errorf("want raid not found getting attendance, got %v", err)
+	}
+
+	assertNoError(t, data.DeleteRaid(raid.Id))
+	next, err := data.AddRaid(entities.Raid{Name: "Onyxia's Lair", Date: raidDate})
+	assertNoError(t, err)
+	if got, _ := data.GetAttendance(next.Id); len(got) != 0 {
+		t.Errorf("want no attendance in a new raid, got %v", got)
+	}
+}
+
+func testSettings(t *testing.T, data prototype.RaidDataProvider) {
+	if got, err := data.GetSetting("prefix"); err != nil || got != "" {
+		t.Errorf("want empty setting, got %q, %v", got, err)
+	}
+
+	assertNoError(t, data.SetSetting("prefix", "!"))
+	assertNoError(t, data.SetSetting("prefix", "?"))
+	if got, _ := data.GetSetting("prefix"); got != "?" {
+		t.Errorf("want setting %q, got %q", "?", got)
+	}
+
+	assertNoError(t, data.SetSetting("prefix", ""))
+	if got, _ := data.GetSetting("prefix"); got != "" {
+		t.Errorf("want setting removed, got %q", got)
+	}
+}
+
+func testUserSettings(t *testing.T, store prototype.RaidDataStore) {
+	if got, err := store.GetUserSetting("1", "timezone"); err != nil || got != "" {
+		t.Errorf("want empty user setting, got %q, %v", got, err)
+	}
+
+	assertNoError(t, store.SetUserSetting("1", "timezone", "Europe/Madrid"))
+	assertNoError(t, store.SetUserSetting("1", "timezone", "Asia/Tokyo"))
+	if got, _ := store.GetUserSetting("1", "timezone"); got != "Asia/Tokyo" {
+		t.Errorf("want user setting %q, got %q", "Asia/Tokyo", got)
+	}
+	if got, _ := store.GetUserSetting("2", "timezone"); got != "" {
+		t.Errorf("want no setting for other user, got %q", got)
+	}
+	if got, _ := store.Guild(guild).GetSetting("timezone"); got != "" {
+		t.Errorf("want user settings not in the guild, got %q", got)
+	}
+	if got, _ := store.Guilds(); len(got) != 0 {
+		t.Errorf("want no guilds with user settings, got %v", got)
+	}
+
+	assertNoError(t, store.SetUserSetting("1", "timezone", ""))
+	if got, _ := store.GetUserSetting("1", "timezone"); got != "" {
+		t.Errorf("want user setting removed, got %q", got)
+	}
+}
+
+func testAnnouncements(t *testing.T, data prototype.RaidDataProvider) {
+	mc, err := data.AddRaid(entities.Raid{Name: "Molten Core", Date: raidDate})
+	assertNoError(t, err)
+	ony, err := data.AddRaid(entities.Raid{Name: "Onyxia's Lair", Date: raidDate})
+	assertNoError(t, err)
+
+	if _, err := data.GetAnnouncedRaid("m1"); err != entities.ErrRaidNotFound {
+		t.Errorf("want raid not found without announcement, got %v", err)
+	}
+
+	assertNoError(t, data.AddAnnouncement(mc.Id, "m1"))
+	assertNoError(t, data.AddAnnouncement(mc.Id, "m2"))
+	assertNoError(t, data.AddAnnouncement(ony.Id, "m3"))
+
+	for _, messageId := range []string{"m1", "m2"} {
+		got, err := data.GetAnnouncedRaid(messageId)
+		assertNoError(t, err)
+		assertRaid(t, got, mc)
+	}
+
+	if err := data.AddAnnouncement("99", "m4"); err != entities.ErrRaidNotFound {
+		t.Errorf("want raid not found announcing, got %v", err)
+	}
+
+	assertNoError(t, data.DeleteRaid(mc.Id))
+	if _, err := data.GetAnnouncedRaid("m1"); err != entities.ErrRaidNotFound {
+		t.Errorf("want raid not found after delete, got %v", err)
+	}
+	got, err := data.GetAnnouncedRaid("m3")
+	assertNoError(t, err)
+	assertRaid(t, got, ony)
+}
+
+func testSchedules(t *testing.T, data prototype.RaidDataProvider) {
+	if got, err := data.GetSchedules(); err != nil || len(got) != 0 {
+		t.Errorf("want no schedules, got %v, %v", got, err)
+	}
+
+	mc, err := data.AddSchedule(entities.Schedule{
+		Name:     "Molten Core",
+		Days:     []time.Weekday{time.Wednesday, time.Sunday},
+		Hour:     20,
+		Minute:   30,
+		Location: "Europe/Madrid",
+	})
+	assertNoError(t, err)
+	bwl, err := data.AddSchedule(entities.Schedule{Name: "Blackwing Lair", Days: []time.Weekday{time.Friday}, Hour: 21})
+	assertNoError(t, err)
+	if mc.Id == "" || mc.Id == bwl.Id {
+		t.Errorf("want different schedule ids, got %q and %q", mc.Id, bwl.Id)
+	}
+
+	mc.Until = raidDate
+	assertNoError(t, data.UpdateSchedule(mc))
+
+	got, err := data.GetSchedules()
+	assertNoError(t, err)
+	if len(got) != 2 {
+		t.Fatalf("want 2 schedules, got %v", got)
+	}
+	assertSchedule(t, got[0], mc)
+	assertSchedule(t, got[1], bwl)
+
+	// the days given and returned are not the ones stored
+	mc.Days[0] = time.Monday
+	got[1].Days[0] = time.Monday
+	got, err = data.GetSchedules()
+	assertNoError(t, err)
+	if !reflect.DeepEqual(got[0].Days, []time.Weekday{time.Wednesday, time.Sunday}) || got[1].Days[0] != time.Friday {
+		t.Errorf("want stored days unchanged, got %v and %v", got[0].Days, got[1].Days)
+	}
+	mc = got[0]
+
+	if err := data.UpdateSchedule(entities.Schedule{Id: "99"}); err != entities.ErrScheduleNotFound {
+		t.Errorf("want schedule not found updating, got %v", err)
+	}
+	if err := data.DeleteSchedule("99"); err != entities.ErrScheduleNotFound {
+		t.Errorf("want schedule not found deleting, got %v", err)
+	}
+
+	assertNoError(t, data.DeleteSchedule(mc.Id))
+	got, err = data.GetSchedules()
+	assertNoError(t, err)
+	if len(got) != 1 {
+		t.Fatalf("want 1 schedule after delete, got %v", got)
+	}
+	assertSchedule(t, got[0], bwl)
+}
+
+func testCharacters(t *testing.T, data prototype.RaidDataProvider) {
+	if got, err := data.GetCharacters("1"); err != nil || len(got) != 0 {
+		t.Errorf("want no characters, got %v, %v", got, err)
+	}
+
+	thrall := entities.Character{Member: "1", Name: "Thrall", Class: "Shaman", Spec: "Restoration", Main: true}
+	rexxar := entities.Character{Member: "1", Name: "Rexxar", Class: "Hunter", Spec: "Survival"}
+	jaina := entities.Character{Member: "2", Name: "Jaina", Class: "Mage", Spec: "Frost", Main: true}
+	assertNoError(t, data.AddCharacter(thrall))
+	assertNoError(t, data.AddCharacter(rexxar))
+	assertNoError(t, data.AddCharacter(jaina))
+
+	want := []entities.Character{thrall, rexxar}
+	if got, _ := data.GetCharacters("1"); !reflect.DeepEqual(got, want) {
+		t.Errorf("want characters %v, got %v", want, got)
+	}
+
+	rexxar.Spec = "Beast Mastery"
+	rexxar.Main = true
+	assertNoError(t, data.AddCharacter(rexxar))
+	thrall.Main = false
+	want = []entities.Character{thrall, rexxar}
+	if got, _ := data.GetCharacters("1"); !reflect.DeepEqual(got, want) {
+		t.Errorf("want characters with a new main %v, got %v", want, got)
+	}
+
+	if err := data.DeleteCharacter("1", "Jaina"); err != entities.ErrCharacterNotFound {
+		t.Errorf("want character not found deleting the character of other member, got %v", err)
+	}
+	assertNoError(t, data.DeleteCharacter("1", "Thrall"))
+	want = []entities.Character{rexxar}
+	if got, _ := data.GetCharacters("1"); !reflect.DeepEqual(got, want) {
+		t.Errorf("want characters after delete %v, got %v", want, got)
+	}
+	want = []entities.Character{jaina}
+	if got, _ := data.GetCharacters("2"); !reflect.DeepEqual(got, want) {
+		t.Errorf("want characters of other member %v, got %v", want, got)
+	}
+}
+
+func testTransactions(t *testing.T, data prototype.RaidDataProvider) {
+	if got, err := data.GetTransactions(""); err != nil || len(got) != 0 {
+		t.Errorf("want no transactions, got %v, %v", got, err)
+	}
+
+	award, err := data.AddTransaction(entities.Transaction{
+		Member: "1", Points: 10, Kind: entities.TransactionAttendance, Reason: "raid attendance", Raid: "5", Date: raidDate,
+	})
+	assertNoError(t, err)
+	loot, err := data.AddTransaction(entities.Transaction{
+		Member: "2", Points: -50, Kind: entities.TransactionLoot, Char: "Jaina", Item: "Staff of Dominance", Author: "123", Date: raidDate,
+	})
+	assertNoError(t, err)
+	decay, err := data.AddTransaction(entities.Transaction{Member: "1", Points: -1, Kind: entities.TransactionDecay, Date: raidDate})
+	assertNoError(t, err)
+	if award.Id == "" || award.Id == loot.Id || loot.Id == decay.Id {
+		t.Errorf("want different transaction ids, got %q, %q and %q", award.Id, loot.Id, decay.Id)
+	}
+
+	all, err := data.GetTransactions("")
+	assertNoError(t, err)
+	assertTransactions(t, all, []entities.Transaction{award, loot, decay})
+
+	member, err := data.GetTransactions("1")
+	assertNoError(t, err)
+	assertTransactions(t, member, []entities.Transaction{award, decay})
+}
+
+func testGuilds(t *testing.T, store prototype.RaidDataStore) {
+	data := store.Guild(guild)
+	other := store.Guild(otherGuild)
+
+	if got, err := store.Guilds(); err != nil || len(got) != 0 {
+		t.Errorf("want no guilds, got %v, %v", got, err)
+	}
+
+	assertNoError(t, data.AddOfficer("123"))
+	raid, err := data.AddRaid(entities.Raid{Name: "Molten Core", Date: raidDate})
+	assertNoError(t, err)
+	signup := entities.Signup{Member: "1", Char: "Thrall", Class: "Shaman", Spec: "Restoration"}
+	assertNoError(t, data.SignUp(raid.Id, signup))
+
+	if got, _ := other.GetOfficers(); len(got) != 0 {
+		t.Errorf("want no officers in other guild, got %v", got)
+	}
+	if got, _ := other.GetRaids(); len(got) != 0 {
+		t.Errorf("want no raids in other guild, got %v", got)
+	}
+	if _, err := other.GetRaid(raid.Id); err != entities.ErrRaidNotFound {
+		t.Errorf("want raid not found in other guild, got %v", err)
+	}
+	if _, err := other.GetSignups(raid.Id); err != entities.ErrRaidNotFound {
+		t.Errorf("want raid not found getting signups in other guild, got %v", err)
+	}
+	if err := other.SignUp(raid.Id, signup); err != entities.ErrRaidNotFound {
+		t.Errorf("want raid not found signing up in other guild, got %v", err)
+	}
+	if err := other.UpdateRaid(raid); err != entities.ErrRaidNotFound {
+		t.Errorf("want raid not found updating in other guild, got %v", err)
+	}
+	if err := other.CloseRaid(raid.Id, nil); err != entities.ErrRaidNotFound {
+		t.Errorf("want raid not found closing in other guild, got %v", err)
+	}
+	if _, err := other.GetAttendance(raid.Id); err != entities.ErrRaidNotFound {
+		t.Errorf("want raid not found getting attendance in other guild, got %v", err)
+	}
+	if err := other.DeleteRaid(raid.Id); err != entities.ErrRaidNotFound {
+		t.Errorf("want raid not found deleting in other guild, got %v", err)
+	}
+
+	assertNoError(t, data.SetSetting("prefix", "!"))
+	if got, _ := other.GetSetting("prefix"); got != "" {
+		t.Errorf("want no settings in other guild, got %q", got)
+	}
+
+	assertNoError(t, data.AddAnnouncement(raid.Id, "m1"))
+	if _, err := other.GetAnnouncedRaid("m1"); err != entities.ErrRaidNotFound {
+		t.Errorf("want raid not found for an announcement in other guild, got %v", err)
+	}
+	if err := other.AddAnnouncement(raid.Id, "m2"); err != entities.ErrRaidNotFound {
+		t.Errorf("want raid not found announcing in other guild, got %v", err)
+	}
+
+	schedule, err := data.AddSchedule(entities.Schedule{Name: "Molten Core", Days: []time.Weekday{time.Wednesday}, Hour: 20})
+	assertNoError(t, err)
+	if got, _ := other.GetSchedules(); len(got) != 0 {
+		t.Errorf("want no schedules in other guild, got %v", got)
+	}
+	if err := other.UpdateSchedule(schedule); err != entities.ErrScheduleNotFound {
+		t.Errorf("want schedule not found updating in other guild, got %v", err)
+	}
+	if err := other.DeleteSchedule(schedule.Id); err != entities.ErrScheduleNotFound {
+		t.Errorf("want schedule not found deleting in other guild, got %v", err)
+	}
+
+	assertNoError(t, data.AddCharacter(entities.Character{Member: "1", Name: "Thrall", Class: "Shaman", Spec: "Restoration"}))
+	if got, _ := other.GetCharacters("1"); len(got) != 0 {
+		t.Errorf("want no characters in other guild, got %v", got)
+	}
+	if err := other.DeleteCharacter("1", "Thrall"); err != entities.ErrCharacterNotFound {
+		t.Errorf("want character not found deleting in other guild, got %v", err)
+	}
+
+	_, err = data.AddTransaction(entities.Transaction{Member: "1", Points: 10, Kind: entities.TransactionAward, Date: raidDate})
+	assertNoError(t, err)
+	if got, _ := other.GetTransactions(""); len(got) != 0 {
+		t.Errorf("want no transactions in other guild, got %v", got)
+	}
+
+	assertNoError(t, other.AddOfficer("456"))
+	assertNoError(t, other.DeleteOfficer("123"))
+
+	guilds, err := store.Guilds()
+	assertNoError(t, err)
+	if want := []string{guild, otherGuild}; !reflect.DeepEqual(guilds, want) {
+		t.Errorf("want guilds %v, got %v", want, guilds)
+	}
+
+	if got, want := mustOfficers(t, data), []entities.Officer{{Id: "123"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("want officers %v, got %v", want, got)
+	}
+	if got, want := mustOfficers(t, other), []entities.Officer{{Id: "456"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("want officers in other guild %v, got %v", want, got)
+	}
+}
+
+func mustOfficers(t *testing.T, data prototype.RaidDataProvider) []entities.Officer {
+	t.Helper()
+	officers, err := data.GetOfficers()
+	assertNoError(t, err)
+	return officers
+}
+
+// TestProvider runs the conformance suite against new stores created by factory.
+func TestProvider(t *testing.T, factory Factory) {
+	t.Run("officers", func(t *testing.T) {
+		testOfficers(t, factory(t).Guild(guild))
+	})
+	t.Run("raids", func(t *testing.T) {
+		testRaids(t, factory(t).Guild(guild))
+	})
+	t.Run("signups", func(t *testing.T) {
+		testSignups(t, factory(t).Guild(guild))
+	})
+	t.Run("attendance", func(t *testing.T) {
+		testAttendance(t, factory(t).Guild(guild))
+	})
+	t.Run("settings", func(t *testing.T) {
+		testSettings(t, factory(t).Guild(guild))
+	})
+	t.Run("user settings", func(t *testing.T) {
+		testUserSettings(t, factory(t))
+	})
+	t.Run("announcements", func(t *testing.T) {
+		testAnnouncements(t, factory(t).Guild(guild))
+	})
+	t.Run("schedules", func(t *testing.T) {
+		testSchedules(t, factory(t).Guild(guild))
+	})
+	t.Run("characters", func(t *testing.T) {
+		testCharacters(t, factory(t).Guild(guild))
+	})
+	t.Run("transactions", func(t *testing.T) {
+		testTransactions(t, factory(t).Guild(guild))
+	})
+	t.Run("guilds", func(t *testing.T) {
+		testGuilds(t, factory(t))
+	})
+}
+
+// TestPersistence checks that the data stored by a provider is available when the storage is opened again.
+func TestPersistence(t *testing.T, open Opener) {
+	store := open(t)
+	data := store.Guild(guild)
+	assertNoError(t, data.AddOfficer("123"))
+	raid, err := data.AddRaid(entities.Raid{Name: "Molten Core", Date: raidDate})
+	assertNoError(t, err)
+	signup := entities.Signup{Member: "1", Char: "Thrall", Class: "Shaman", Spec: "Restoration", Role: "Tank", Status: entities.SignupWaitlisted}
+	assertNoError(t, data.SignUp(raid.Id, signup))
+	assertNoError(t, data.SetSetting("prefix", "!"))
+	assertNoError(t, store.SetUserSetting("1", "timezone", "Europe/Madrid"))
+	assertNoError(t, data.AddAnnouncement(raid.Id, "m1"))
+	raid.Channel = "channel1"
+	raid.Message = "m1"
+	raid.Size = 40
+	raid.Limits = map[string]int{"Tank": 4}
+	assertNoError(t, data.UpdateRaid(raid))
+	schedule, err := data.AddSchedule(entities.Schedule{
+		Name:     "Molten Core",
+		Days:     []time.Weekday{time.Wednesday, time.Sunday},
+		Hour:     20,
+		Location: "Europe/Madrid",
+		Until:    raidDate,
+	})
+	assertNoError(t, err)
+	character := entities.Character{Member: "1", Name: "Thrall", Class: "Shaman", Spec: "Restoration", Main: true}
+	assertNoError(t, data.AddCharacter(character))
+	closed, err := data.AddRaid(entities.Raid{Name: "Onyxia's Lair", Date: raidDate})
+	assertNoError(t, err)
+	attendance := []entities.Attendance{{Member: "1", Char: "Thrall", Status: entities.AttendanceLate}}
+	assertNoError(t, data.CloseRaid(closed.Id, attendance))
+	transaction, err := data.AddTransaction(entities.Transaction{
+		Member: "1", Points: -50, Kind: entities.TransactionLoot, Raid: closed.Id, Char: "Thrall", Item: "Earthfury Helmet", Author: "123", Date: raidDate,
+	})
+	assertNoError(t, err)
+
+	store = open(t)
+	data = store.Guild(guild)
+
+	if got, err := data.GetRaid(closed.Id); err != nil || !got.Closed {
+		t.Errorf("want closed raid, got %v, %v", got, err)
+	}
+	if got, _ := data.GetAttendance(closed.Id); !reflect.DeepEqual(got, attendance) {
+		t.Errorf("want attendance %v, got %v", attendance, got)
+	}
+
+	transactions, err := data.GetTransactions("1")
+	assertNoError(t, err)
+	assertTransactions(t, transactions, []entities.Transaction{transaction})
+
+	characters, err := data.GetCharacters("1")
+	assertNoError(t, err)
+	if want := []entities.Character{character}; !reflect.DeepEqual(characters, want) {
+		t.Errorf("want characters %v, got %v", want, characters)
+	}
+
+	schedules, err := data.GetSchedules()
+	assertNoError(t, err)
+	if len(schedules) != 1 {
+		t.Fatalf("want 1 schedule, got %v", schedules)
+	}
+	assertSchedule(t, schedules[0], schedule)
+
+	if announced, err := data.GetAnnouncedRaid("m1"); err != nil || announced.Id != raid.Id {
+		t.Errorf("want announced raid %q, got %v, %v", raid.Id, announced, err)
+	}
+
+	if got, _ := data.GetSetting("prefix"); got != "!" {
+		t.Errorf("want setting %q, got %q", "!", got)
+	}
+	if got, _ := store.GetUserSetting("1", "timezone"); got != "Europe/Madrid" {
+		t.Errorf("want user setting %q, got %q", "Europe/Madrid", got)
+	}
+
+	officers, err := data.GetOfficers()
+	assertNoError(t, err)
+	if want := []entities.Officer{{Id: "123"}}; !reflect.DeepEqual(officers, want) {
+		t.Errorf("want officers %v, got %v", want, officers)
+	}
+
+	got, err := data.GetRaid(raid.Id)
+	assertNoError(t, err)
+	assertRaid(t, got, raid)
+
+	signups, err := data.GetSignups(raid.Id)
+	assertNoError(t, err)
+	if want := []entities.Signup{signup}; !reflect.DeepEqual(signups, want) {
+		t.Errorf("want signups %v, got %v", want, signups)
+	}
+
+	next, err := data.AddRaid(entities.Raid{Name: "Onyxia's Lair", Date: raidDate})
+	assertNoError(t, err)
+	if next.Id == raid.Id {
+		t.Errorf("want raid ids not to be reused after reopening, got %q", next.Id)
+	}
+}
@@ -1,13 +1,103 @@
 package entities
 
-import "time"
+import (
+	"errors"
+	"time"
+)
+
+var ErrRaidNotFound = errors.New("raid not found")
+var ErrSignupNotFound = errors.New("signup not found")
+var ErrScheduleNotFound = errors.New("schedule not found")
+var ErrCharacterNotFound = errors.New("character not found")
 
 type Officer struct {
 	Id string
 }
 
+// Raid is a scheduled raid, Channel and Message are set once it is announced to keep its roster up to date.
+// Size and Limits, by role, cap the confirmed signups, zero is no limit. Closed raids have their attendance recorded
 type Raid struct {
-	Id   string
-	name string
-	date time.Time
+	Id        string
+	Name      string
+	Date      time.Time
+	Cancelled bool
+	Channel   string
+	Message   string
+	Size      int
+	Limits    map[string]int
+	Closed    bool
+}
+
+// the status of a signup, the waitlisted members are promoted in order when there is room and the benched only
+// by an officer
+const (
+	SignupConfirmed  = ""
+	SignupWaitlisted = "waitlist"
+	SignupBenched    = "bench"
+)
+
+// Signup is a member attending a raid, Role is set when it is not given by the class and spec
+type Signup struct {
+	Member string
+	Char   string
+	Class  string
+	Spec   string
+	Role   string
+	Status string
+}
+
+// Schedule is a raid repeated every week on Days at Hour:Minute in Location, Until is the date of the last raid created
+type Schedule struct {
+	Id       string
+	Name     string
+	Days     []time.Weekday
+	Hour     int
+	Minute   int
+	Location string
+	Until    time.Time
+}
+
+// Character is a char of a member, a member has a single Main and the rest are alts
+type Character struct {
+	Member string
+	Name   string
+	Class  string
+	Spec   string
+	Main   bool
+}
+
+const (
+	AttendanceAttended = "attended"
+	AttendanceLate     = "late"
+	AttendanceNoShow   = "noshow"
+	AttendanceBenched  = "benched"
+)
+
+type Attendance struct {
+	Member string
+	Char   string
+	Status string
+}
+
+const (
+	TransactionAward      = "award"
+	TransactionLoot       = "loot"
+	TransactionAttendance = "attendance"
+	TransactionOnTime     = "ontime"
+	TransactionDecay      = "decay"
+)
+
+// Transaction is an entry of the loot points ledger, Raid is set for the points of a raid and Char and Item for loot.
+// Author is the officer that made it, empty for the automatic ones
+type Transaction struct {
+	Id     string
+	Member string
+	Points int
+	Kind   string
+	Reason string
+	Raid   string
+	Char   string
+	Item   string
+	Author string
+	Date   time.Time
 }
@@ -0,0 +1,156 @@
+package file
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/juan-medina/cecibot/commands/raid/data/memory"
+	"github.com/juan-medina/cecibot/prototype"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+const formatVersion = 2
+
+// noGuild is where the data stored before guilds were supported is read, until it is moved to the legacy guild
+const noGuild = ""
+
+type document struct {
+	Version int
+	State   json.RawMessage
+}
+
+type fileStore struct {
+	path        string
+	legacyGuild string
+}
+
+func (f fileStore) tempPath() string {
+	return f.path + ".tmp"
+}
+
+func (f fileStore) load() (*memory.State, error) {
+	// a leftover temporary file is a write that never got committed, the data file is still the last good state
+	if err := os.Remove(f.tempPath()); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	content, err := ioutil.ReadFile(f.path)
+	if os.IsNotExist(err) {
+		return memory.NewState(), nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	doc := document{}
+	if err := json.Unmarshal(content, &doc); err != nil {
+		return nil, fmt.Errorf("invalid raid data file %q: %v", f.path, err)
+	}
+
+	if doc.Version < 1 || doc.Version > formatVersion {
+		return nil, fmt.Errorf("invalid raid data file %q: unknown version %d", f.path, doc.Version)
+	}
+
+	state := memory.NewState()
+	if len(doc.State) == 0 || string(doc.State) == "null" {
+		return state, nil
+	}
+
+	switch doc.Version {
+	case 1:
+		legacy := &memory.GuildState{}
+		err = json.Unmarshal(doc.State, legacy)
+		state.Guilds[noGuild] = legacy
+	default:
+		err = json.Unmarshal(doc.State, state)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid raid data file %q: %v", f.path, err)
+	}
+
+	if err := f.adoptLegacy(state); err != nil {
+		return nil, err
+	}
+
+	return state, nil
+}
+
+// adoptLegacy moves the data stored before guilds were supported to the configured legacy guild, no command could
+// reach it otherwise
+func (f fileStore) adoptLegacy(state *memory.State) error {
+	legacy, ok := state.Guilds[noGuild]
+	if !ok {
+		return nil
+	}
+	if f.legacyGuild == "" {
+		return fmt.Errorf("raid data file %q has data stored before guilds were supported, "+
+			"configure the legacy guild that keeps it", f.path)
+	}
+	if _, ok := state.Guilds[f.legacyGuild]; ok {
+		return fmt.Errorf("raid data file %q already has data for the legacy guild %q", f.path, f.legacyGuild)
+	}
+
+	state.Guilds[f.legacyGuild] = legacy
+	delete(state.Guilds, noGuild)
+	return nil
+}
+
+func (f fileStore) save(state *memory.State) error {
+	raw, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	content, err := json.MarshalIndent(document{Version: formatVersion, State: raw}, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.OpenFile(f.tempPath(), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+
+	if _, err = tmp.Write(content); err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(f.tempPath())
+		return err
+	}
+
+	if err := os.Rename(f.tempPath(), f.path); err != nil {
+		_ = os.Remove(f.tempPath())
+		return err
+	}
+
+	return syncDir(filepath.Dir(f.path))
+}
+
+func syncDir(path string) error {
+	dir, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+
+	// some platforms does not allow to sync directories, the rename is done anyway
+	_ = dir.Sync()
+	return nil
+}
+
+// New creates a store that keeps the raid data in memory and stores every change in the file at path, the data stored
+// before guilds were supported is moved to legacyGuild
+func New(path string, legacyGuild string) (prototype.RaidDataStore, error) {
+	store := fileStore{path: path, legacyGuild: legacyGuild}
+
+	state, err := store.load()
+	if err != nil {
+		return nil, err
+	}
+
+	return memory.Restore(state, store.save), nil
+}
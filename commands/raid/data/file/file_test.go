@@ -0,0 +1,142 @@
+package file
+
+import (
+	"github.com/juan-medina/cecibot/commands/raid/data/datatest"
+	"github.com/juan-medina/cecibot/commands/raid/data/entities"
+	"github.com/juan-medina/cecibot/prototype"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func tempDir(t *testing.T) (string, func()) {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "cecibot")
+	if err != nil {
+		t.Fatalf("want not error creating temp dir, got %v", err)
+	}
+	return dir, func() { _ = os.RemoveAll(dir) }
+}
+
+func open(t *testing.T, path string) prototype.RaidDataStore {
+	t.Helper()
+	data, err := New(path, "")
+	if err != nil {
+		t.Fatalf("want not error opening %q, got %v", path, err)
+	}
+	return data
+}
+
+func TestFileStore(t *testing.T) {
+	dir, clean := tempDir(t)
+	defer clean()
+
+	files := 0
+	datatest.TestProvider(t, func(t *testing.T) prototype.RaidDataStore {
+		files++
+		return open(t, filepath.Join(dir, strconv.Itoa(files)+".json"))
+	})
+}
+
+func TestFileStore_persistence(t *testing.T) {
+	dir, clean := tempDir(t)
+	defer clean()
+
+	path := filepath.Join(dir, "raids.json")
+	datatest.TestPersistence(t, func(t *testing.T) prototype.RaidDataStore {
+		return open(t, path)
+	})
+}
+
+func TestFileStore_recovery(t *testing.T) {
+	dir, clean := tempDir(t)
+	defer clean()
+
+	path := filepath.Join(dir, "raids.json")
+
+	t.Run("should ignore an uncommitted write", func(t *testing.T) {
+		data := open(t, path).Guild("guild1")
+		_ = data.AddOfficer("123")
+
+		if err := ioutil.WriteFile(path+".tmp", []byte("{\"Version\":1,\"Sta"), 0600); err != nil {
+			t.Fatalf("want not error, got %v", err)
+		}
+
+		data = open(t, path).Guild("guild1")
+		if got, _ := data.GetOfficers(); len(got) != 1 {
+			t.Errorf("want last committed officers, got %v", got)
+		}
+		if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+			t.Errorf("want temporary file removed, got %v", err)
+		}
+	})
+
+	legacy := `{"Version":1,"State":{"Officers":{"123":{"Id":"123"}},"Raids":{},"Signups":{},"LastRaidId":3}}`
+
+	t.Run("should fail with data stored before guilds without a legacy guild", func(t *testing.T) {
+		if err := ioutil.WriteFile(path, []byte(legacy), 0600); err != nil {
+			t.Fatalf("want not error, got %v", err)
+		}
+		if _, err := New(path, ""); err == nil {
+			t.Errorf("want error, got nil")
+		}
+	})
+
+	t.Run("should move data stored before guilds to the legacy guild", func(t *testing.T) {
+		if err := ioutil.WriteFile(path, []byte(legacy), 0600); err != nil {
+			t.Fatalf("want not error, got %v", err)
+		}
+
+		store, err := New(path, "guild1")
+		if err != nil {
+			t.Fatalf("want not error, got %v", err)
+		}
+		data := store.Guild("guild1")
+		if got, _ := data.GetOfficers(); len(got) != 1 {
+			t.Errorf("want legacy officers, got %v", got)
+		}
+		raid, err := data.AddRaid(entities.Raid{Name: "Molten Core", Date: time.Now()})
+		if err != nil || raid.Id != "4" {
+			t.Errorf("want raid id \"4\", got %q %v", raid.Id, err)
+		}
+
+		if got, _ := open(t, path).Guild("guild1").GetOfficers(); len(got) != 1 {
+			t.Errorf("want legacy officers stored in the legacy guild, got %v", got)
+		}
+	})
+
+	t.Run("should fail with a corrupted file", func(t *testing.T) {
+		if err := ioutil.WriteFile(path, []byte("not json"), 0600); err != nil {
+			t.Fatalf("want not error, got %v", err)
+		}
+		if _, err := New(path, ""); err == nil {
+			t.Errorf("want error, got nil")
+		}
+	})
+
+	t.Run("should fail with an unknown version", func(t *testing.T) {
+		if err := ioutil.WriteFile(path, []byte("{\"Version\":99}"), 0600); err != nil {
+			t.Fatalf("want not error, got %v", err)
+		}
+		if _, err := New(path, ""); err == nil {
+			t.Errorf("want error, got nil")
+		}
+	})
+}
+
+func TestFileStore_writeFailure(t *testing.T) {
+	dir, clean := tempDir(t)
+	defer clean()
+
+	data := open(t, filepath.Join(dir, "missing", "raids.json")).Guild("guild1")
+
+	if err := data.AddOfficer("123"); err == nil {
+		t.Errorf("want error, got nil")
+	}
+	if got, _ := data.GetOfficers(); len(got) != 0 {
+		t.Errorf("want failed changes discarded, got %v", got)
+	}
+}
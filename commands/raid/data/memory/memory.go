@@ -4,37 +4,628 @@ import (
 	"github.com/juan-medina/cecibot/commands/raid/data/entities"
 	"github.com/juan-medina/cecibot/prototype"
 	"sort"
+	"strconv"
+	"sync"
+	"time"
 )
 
+// State is the whole raid data kept by the in memory store, it is exported so other stores could persist it.
+type State struct {
+	Guilds map[string]*GuildState
+	Users  map[string]map[string]string // settings of each user
+}
+
+// GuildState is the raid data of a single guild.
+type GuildState struct {
+	Officers          map[string]entities.Officer
+	Raids             map[string]entities.Raid
+	Signups           map[string][]entities.Signup
+	Settings          map[string]string
+	Announcements     map[string]string // raid id of each announcement message
+	Schedules         map[string]entities.Schedule
+	Characters        map[string][]entities.Character  // characters of each member
+	Attendance        map[string][]entities.Attendance // attendance of each closed raid
+	Transactions      []entities.Transaction
+	LastRaidId        int
+	LastScheduleId    int
+	LastTransactionId int
+}
+
+// PersistFunction is called with the new state before any change is applied, if it fails the change is discarded.
+type PersistFunction func(state *State) error
+
 type inMemory struct {
-	officers map[string]entities.Officer
+	mu      sync.RWMutex
+	state   *State
+	persist PersistFunction
 }
 
-func (d *inMemory) AddOfficer(id string) {
-	officer := entities.Officer{Id: id}
-	d.officers[id] = officer
+type guildData struct {
+	store *inMemory
+	guild string
 }
 
-func (d *inMemory) DeleteOfficer(id string) {
-	delete(d.officers, id)
+func NewState() *State {
+	return &State{
+		Guilds: make(map[string]*GuildState),
+		Users:  make(map[string]map[string]string),
+	}
 }
 
-func (d *inMemory) GetOfficers() []entities.Officer {
-	result := make([]entities.Officer, 0)
+func newGuildState() *GuildState {
+	return &GuildState{
+		Officers:      make(map[string]entities.Officer),
+		Raids:         make(map[string]entities.Raid),
+		Signups:       make(map[string][]entities.Signup),
+		Settings:      make(map[string]string),
+		Announcements: make(map[string]string),
+		Schedules:     make(map[string]entities.Schedule),
+		Characters:    make(map[string][]entities.Character),
+		Attendance:    make(map[string][]entities.Attendance),
+	}
+}
+
+func (g *GuildState) clone() *GuildState {
+	result := newGuildState()
+	result.LastRaidId = g.LastRaidId
+	result.LastScheduleId = g.LastScheduleId
+	result.LastTransactionId = g.LastTransactionId
+	result.Transactions = append([]entities.Transaction(nil), g.Transactions...)
+	for key, officer := range g.Officers {
+		result.Officers[key] = officer
+	}
+	for key, raid := range g.Raids {
+		result.Raids[key] = copyRaid(raid)
+	}
+	for key, signups := range g.Signups {
+		result.Signups[key] = append([]entities.Signup(nil), signups...)
+	}
+	for key, value := range g.Settings {
+		result.Settings[key] = value
+	}
+	for key, raidId := range g.Announcements {
+		result.Announcements[key] = raidId
+	}
+	for key, schedule := range g.Schedules {
+		result.Schedules[key] = copySchedule(schedule)
+	}
+	for key, characters := range g.Characters {
+		result.Characters[key] = append([]entities.Character(nil), characters...)
+	}
+	for key, attendance := range g.Attendance {
+		result.Attendance[key] = append([]entities.Attendance(nil), attendance...)
+	}
+	return result
+}
+
+func (g *GuildState) ensure() {
+	if g.Officers == nil {
+		g.Officers = make(map[string]entities.Officer)
+	}
+	if g.Raids == nil {
+		g.Raids = make(map[string]entities.Raid)
+	}
+	if g.Signups == nil {
+		g.Signups = make(map[string][]entities.Signup)
+	}
+	if g.Settings == nil {
+		g.Settings = make(map[string]string)
+	}
+	if g.Announcements == nil {
+		g.Announcements = make(map[string]string)
+	}
+	if g.Schedules == nil {
+		g.Schedules = make(map[string]entities.Schedule)
+	}
+	if g.Characters == nil {
+		g.Characters = make(map[string][]entities.Character)
+	}
+	if g.Attendance == nil {
+		g.Attendance = make(map[string][]entities.Attendance)
+	}
+}
+
+// copyRaid copies the limits of a raid, so the raids given and returned by the store never share them with its state
+func copyRaid(raid entities.Raid) entities.Raid {
+	if raid.Limits == nil {
+		return raid
+	}
+	limits := make(map[string]int, len(raid.Limits))
+	for role, limit := range raid.Limits {
+		limits[role] = limit
+	}
+	raid.Limits = limits
+	return raid
+}
 
-	keys := make([]string, 0)
-	for key := range d.officers {
-		keys = append(keys, key)
+// copySchedule copies the days of a schedule, so the schedules given and returned by the store never share them with
+// its state
+func copySchedule(schedule entities.Schedule) entities.Schedule {
+	schedule.Days = append([]time.Weekday(nil), schedule.Days...)
+	return schedule
+}
+
+func (s *State) clone() *State {
+	result := NewState()
+	for key, guild := range s.Guilds {
+		result.Guilds[key] = guild.clone()
 	}
-	sort.Strings(keys)
-	for _, key := range keys {
-		result = append(result, d.officers[key])
+	for user, settings := range s.Users {
+		result.Users[user] = make(map[string]string, len(settings))
+		for key, value := range settings {
+			result.Users[user][key] = value
+		}
 	}
 	return result
 }
 
-func New() prototype.RaidDataProvider {
+func (s *State) ensure() {
+	if s.Guilds == nil {
+		s.Guilds = make(map[string]*GuildState)
+	}
+	if s.Users == nil {
+		s.Users = make(map[string]map[string]string)
+	}
+	for _, guild := range s.Guilds {
+		guild.ensure()
+	}
+}
+
+// Guild returns the state of the guild with the given id, creating it if it does not exist
+func (s *State) Guild(id string) *GuildState {
+	guild, found := s.Guilds[id]
+	if !found {
+		guild = newGuildState()
+		s.Guilds[id] = guild
+	}
+	return guild
+}
+
+var emptyGuild = newGuildState()
+
+func (d *guildData) read(fn func(g *GuildState)) {
+	d.store.mu.RLock()
+	defer d.store.mu.RUnlock()
+
+	guild, found := d.store.state.Guilds[d.guild]
+	if !found {
+		guild = emptyGuild
+	}
+	fn(guild)
+}
+
+func (d *guildData) update(fn func(g *GuildState) error) error {
+	return d.store.update(func(s *State) error {
+		return fn(s.Guild(d.guild))
+	})
+}
+
+func (d *inMemory) update(fn func(s *State) error) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.persist == nil {
+		return fn(d.state)
+	}
+
+	next := d.state.clone()
+	if err := fn(next); err != nil {
+		return err
+	}
+	if err := d.persist(next); err != nil {
+		return err
+	}
+	d.state = next
+	return nil
+}
+
+func (d *guildData) AddOfficer(id string) error {
+	return d.update(func(g *GuildState) error {
+		g.Officers[id] = entities.Officer{Id: id}
+		return nil
+	})
+}
+
+func (d *guildData) DeleteOfficer(id string) error {
+	return d.update(func(g *GuildState) error {
+		delete(g.Officers, id)
+		return nil
+	})
+}
+
+func (d *guildData) GetOfficers() ([]entities.Officer, error) {
+	result := make([]entities.Officer, 0)
+
+	d.read(func(g *GuildState) {
+		keys := make([]string, 0)
+		for key := range g.Officers {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			result = append(result, g.Officers[key])
+		}
+	})
+	return result, nil
+}
+
+func (d *guildData) AddRaid(raid entities.Raid) (entities.Raid, error) {
+	err := d.update(func(g *GuildState) error {
+		g.LastRaidId++
+		raid.Id = strconv.Itoa(g.LastRaidId)
+		g.Raids[raid.Id] = copyRaid(raid)
+		return nil
+	})
+	if err != nil {
+		return entities.Raid{}, err
+	}
+	return raid, nil
+}
+
+func (d *guildData) GetRaid(id string) (entities.Raid, error) {
+	var raid entities.Raid
+	var found bool
+	d.read(func(g *GuildState) {
+		raid, found = g.Raids[id]
+		raid = copyRaid(raid)
+	})
+	if !found {
+		return entities.Raid{}, entities.ErrRaidNotFound
+	}
+	return raid, nil
+}
+
+func (d *guildData) GetRaids() ([]entities.Raid, error) {
+	result := make([]entities.Raid, 0)
+	d.read(func(g *GuildState) {
+		for _, raid := range g.Raids {
+			result = append(result, copyRaid(raid))
+		}
+	})
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Date.Equal(result[j].Date) {
+			return result[i].Id < result[j].Id
+		}
+		return result[i].Date.Before(result[j].Date)
+	})
+	return result, nil
+}
+
+func (d *guildData) UpdateRaid(raid entities.Raid) error {
+	return d.update(func(g *GuildState) error {
+		if _, found := g.Raids[raid.Id]; !found {
+			return entities.ErrRaidNotFound
+		}
+		g.Raids[raid.Id] = copyRaid(raid)
+		return nil
+	})
+}
+
+func (d *guildData) DeleteRaid(id string) error {
+	return d.update(func(g *GuildState) error {
+		if _, found := g.Raids[id]; !found {
+			return entities.ErrRaidNotFound
+		}
+		delete(g.Raids, id)
+		delete(g.Signups, id)
+		delete(g.Attendance, id)
+		for messageId, raidId := range g.Announcements {
+			if raidId == id {
+				delete(g.Announcements, messageId)
+			}
+		}
+		return nil
+	})
+}
+
+func (d *guildData) SignUp(raidId string, signup entities.Signup) error {
+	return d.update(func(g *GuildState) error {
+		if _, found := g.Raids[raidId]; !found {
+			return entities.ErrRaidNotFound
+		}
+		signups := g.Signups[raidId]
+		for i, current := range signups {
+			if current.Member == signup.Member {
+				signups[i] = signup
+				return nil
+			}
+		}
+		g.Signups[raidId] = append(signups, signup)
+		return nil
+	})
+}
+
+func (d *guildData) SignDown(raidId string, member string) error {
+	return d.update(func(g *GuildState) error {
+		if _, found := g.Raids[raidId]; !found {
+			return entities.ErrRaidNotFound
+		}
+		signups := g.Signups[raidId]
+		for i, current := range signups {
+			if current.Member == member {
+				g.Signups[raidId] = append(signups[:i:i], signups[i+1:]...)
+				return nil
+			}
+		}
+		return entities.ErrSignupNotFound
+	})
+}
+
+func (d *guildData) GetSignups(raidId string) ([]entities.Signup, error) {
+	var result []entities.Signup
+	var found bool
+	d.read(func(g *GuildState) {
+		if _, found = g.Raids[raidId]; found {
+			result = append(make([]entities.Signup, 0), g.Signups[raidId]...)
+		}
+	})
+	if !found {
+		return nil, entities.ErrRaidNotFound
+	}
+	return result, nil
+}
+
+func (d *guildData) SetSignupStatus(raidId string, member string, status string) error {
+	return d.update(func(g *GuildState) error {
+		if _, found := g.Raids[raidId]; !found {
+			return entities.ErrRaidNotFound
+		}
+		for i, current := range g.Signups[raidId] {
+			if current.Member == member {
+				g.Signups[raidId][i].Status = status
+				return nil
+			}
+		}
+		return entities.ErrSignupNotFound
+	})
+}
+
+// CloseRaid records the attendance of a raid, replacing the previous one if it was already closed
+func (d *guildData) CloseRaid(raidId string, attendance []entities.Attendance) error {
+	return d.update(func(g *GuildState) error {
+		raid, found := g.Raids[raidId]
+		if !found {
+			return entities.ErrRaidNotFound
+		}
+		raid.Closed = true
+		g.Raids[raidId] = raid
+		g.Attendance[raidId] = append([]entities.Attendance(nil), attendance...)
+		return nil
+	})
+}
+
+func (d *guildData) GetAttendance(raidId string) ([]entities.Attendance, error) {
+	var result []entities.Attendance
+	var found bool
+	d.read(func(g *GuildState) {
+		if _, found = g.Raids[raidId]; found {
+			result = append(make([]entities.Attendance, 0), g.Attendance[raidId]...)
+		}
+	})
+	if !found {
+		return nil, entities.ErrRaidNotFound
+	}
+	return result, nil
+}
+
+func (d *guildData) GetSetting(key string) (string, error) {
+	var value string
+	d.read(func(g *GuildState) {
+		value = g.Settings[key]
+	})
+	return value, nil
+}
+
+func (d *guildData) SetSetting(key string, value string) error {
+	return d.update(func(g *GuildState) error {
+		if value == "" {
+			delete(g.Settings, key)
+		} else {
+			g.Settings[key] = value
+		}
+		return nil
+	})
+}
+
+func (d *guildData) AddAnnouncement(raidId string, messageId string) error {
+	return d.update(func(g *GuildState) error {
+		if _, found := g.Raids[raidId]; !found {
+			return entities.ErrRaidNotFound
+		}
+		g.Announcements[messageId] = raidId
+		return nil
+	})
+}
+
+func (d *guildData) GetAnnouncedRaid(messageId string) (entities.Raid, error) {
+	var raid entities.Raid
+	var found bool
+	d.read(func(g *GuildState) {
+		if raidId, announced := g.Announcements[messageId]; announced {
+			raid, found = g.Raids[raidId]
+			raid = copyRaid(raid)
+		}
+	})
+	if !found {
+		return entities.Raid{}, entities.ErrRaidNotFound
+	}
+	return raid, nil
+}
+
+func (d *guildData) AddSchedule(schedule entities.Schedule) (entities.Schedule, error) {
+	err := d.update(func(g *GuildState) error {
+		g.LastScheduleId++
+		schedule.Id = strconv.Itoa(g.LastScheduleId)
+		g.Schedules[schedule.Id] = copySchedule(schedule)
+		return nil
+	})
+	if err != nil {
+		return entities.Schedule{}, err
+	}
+	return schedule, nil
+}
+
+func (d *guildData) GetSchedules() ([]entities.Schedule, error) {
+	result := make([]entities.Schedule, 0)
+	d.read(func(g *GuildState) {
+		for _, schedule := range g.Schedules {
+			result = append(result, copySchedule(schedule))
+		}
+	})
+	sort.Slice(result, func(i, j int) bool {
+		first, _ := strconv.Atoi(result[i].Id)
+		second, _ := strconv.Atoi(result[j].Id)
+		return first < second
+	})
+	return result, nil
+}
+
+func (d *guildData) UpdateSchedule(schedule entities.Schedule) error {
+	return d.update(func(g *GuildState) error {
+		if _, found := g.Schedules[schedule.Id]; !found {
+			return entities.ErrScheduleNotFound
+		}
+		g.Schedules[schedule.Id] = copySchedule(schedule)
+		return nil
+	})
+}
+
+func (d *guildData) DeleteSchedule(id string) error {
+	return d.update(func(g *GuildState) error {
+		if _, found := g.Schedules[id]; !found {
+			return entities.ErrScheduleNotFound
+		}
+		delete(g.Schedules, id)
+		return nil
+	})
+}
+
+// AddCharacter replaces the character with the same name, a new main turns the other characters into alts
+func (d *guildData) AddCharacter(character entities.Character) error {
+	return d.update(func(g *GuildState) error {
+		characters := g.Characters[character.Member]
+		if character.Main {
+			for i := range characters {
+				characters[i].Main = false
+			}
+		}
+		for i, current := range characters {
+			if current.Name == character.Name {
+				characters[i] = character
+				return nil
+			}
+		}
+		g.Characters[character.Member] = append(characters, character)
+		return nil
+	})
+}
+
+func (d *guildData) GetCharacters(member string) ([]entities.Character, error) {
+	result := make([]entities.Character, 0)
+	d.read(func(g *GuildState) {
+		result = append(result, g.Characters[member]...)
+	})
+	return result, nil
+}
+
+func (d *guildData) DeleteCharacter(member string, name string) error {
+	return d.update(func(g *GuildState) error {
+		characters := g.Characters[member]
+		for i, current := range characters {
+			if current.Name == name {
+				if len(characters) == 1 {
+					delete(g.Characters, member)
+				} else {
+					g.Characters[member] = append(characters[:i:i], characters[i+1:]...)
+				}
+				return nil
+			}
+		}
+		return entities.ErrCharacterNotFound
+	})
+}
+
+func (d *guildData) AddTransaction(transaction entities.Transaction) (entities.Transaction, error) {
+	err := d.update(func(g *GuildState) error {
+		g.LastTransactionId++
+		transaction.Id = strconv.Itoa(g.LastTransactionId)
+		g.Transactions = append(g.Transactions, transaction)
+		return nil
+	})
+	if err != nil {
+		return entities.Transaction{}, err
+	}
+	return transaction, nil
+}
+
+// GetTransactions returns the transactions of a member, or of every member if it is empty, in the order they were made
+func (d *guildData) GetTransactions(member string) ([]entities.Transaction, error) {
+	result := make([]entities.Transaction, 0)
+	d.read(func(g *GuildState) {
+		for _, transaction := range g.Transactions {
+			if member == "" || transaction.Member == member {
+				result = append(result, transaction)
+			}
+		}
+	})
+	return result, nil
+}
+
+func (d *inMemory) Guild(id string) prototype.RaidDataProvider {
+	return &guildData{store: d, guild: id}
+}
+
+func (d *inMemory) Guilds() ([]string, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	result := make([]string, 0, len(d.state.Guilds))
+	for id := range d.state.Guilds {
+		result = append(result, id)
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+func (d *inMemory) GetUserSetting(user string, key string) (string, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return d.state.Users[user][key], nil
+}
+
+func (d *inMemory) SetUserSetting(user string, key string, value string) error {
+	return d.update(func(s *State) error {
+		settings, found := s.Users[user]
+		if !found {
+			settings = make(map[string]string)
+			s.Users[user] = settings
+		}
+		if value == "" {
+			delete(settings, key)
+		} else {
+			settings[key] = value
+		}
+		if len(settings) == 0 {
+			delete(s.Users, user)
+		}
+		return nil
+	})
+}
+
+func (d *inMemory) Close() error {
+	return nil
+}
+
+// Restore creates a store with a previous state, calling persist on every change.
+func Restore(state *State, persist PersistFunction) prototype.RaidDataStore {
+	state.ensure()
 	return &inMemory{
-		officers: make(map[string]entities.Officer),
+		state:   state,
+		persist: persist,
 	}
 }
+
+func New() prototype.RaidDataStore {
+	return Restore(NewState(), nil)
+}
@@ -0,0 +1,42 @@
+package memory
+
+import (
+	"errors"
+	"github.com/juan-medina/cecibot/commands/raid/data/datatest"
+	"github.com/juan-medina/cecibot/prototype"
+	"testing"
+)
+
+func TestInMemory(t *testing.T) {
+	datatest.TestProvider(t, func(t *testing.T) prototype.RaidDataStore {
+		return New()
+	})
+}
+
+func TestRestore(t *testing.T) {
+	var persisted *State
+	fail := false
+	data := Restore(NewState(), func(state *State) error {
+		if fail {
+			return errors.New("fake error")
+		}
+		persisted = state
+		return nil
+	}).Guild("guild1")
+
+	if err := data.AddOfficer("123"); err != nil {
+		t.Errorf("want not error, got %v", err)
+		return
+	}
+	if _, found := persisted.Guilds["guild1"].Officers["123"]; !found {
+		t.Errorf("want officer persisted, got %v", persisted.Guilds)
+	}
+
+	fail = true
+	if err := data.AddOfficer("456"); err == nil {
+		t.Errorf("want error, got nil")
+	}
+	if got, _ := data.GetOfficers(); len(got) != 1 {
+		t.Errorf("want failed changes discarded, got %v", got)
+	}
+}
@@ -1,10 +1,41 @@
 package data
 
 import (
+	"database/sql"
+	"fmt"
+	"github.com/juan-medina/cecibot/commands/raid/data/database"
+	"github.com/juan-medina/cecibot/commands/raid/data/file"
 	"github.com/juan-medina/cecibot/commands/raid/data/memory"
+	"github.com/juan-medina/cecibot/config"
 	"github.com/juan-medina/cecibot/prototype"
+	_ "github.com/mattn/go-sqlite3"
 )
 
-func New() prototype.RaidDataProvider {
-	return memory.New()
+const sqliteDriver = "sqlite3"
+
+func New(cfg config.Config) (prototype.RaidDataStore, error) {
+	switch cfg.GetStorage() {
+	case "", "memory":
+		return memory.New(), nil
+	case "file":
+		return file.New(cfg.GetStoragePath(), cfg.GetLegacyGuild())
+	case "sqlite":
+		return database.New(sqliteDriver, cfg.GetStoragePath(), cfg.GetLegacyGuild())
+	}
+	return nil, fmt.Errorf("config error, unknown storage %q", cfg.GetStorage())
+}
+
+// Migrate updates the schema of the configured storage, returning the versions that has been applied.
+func Migrate(cfg config.Config) ([]int, error) {
+	if cfg.GetStorage() != "sqlite" {
+		return nil, fmt.Errorf("storage %q does not support migrations", cfg.GetStorage())
+	}
+
+	db, err := sql.Open(sqliteDriver, cfg.GetStoragePath())
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	return database.Migrate(db)
 }
@@ -0,0 +1,336 @@
+package raid
+
+import (
+	"fmt"
+	"github.com/juan-medina/cecibot/command"
+	"github.com/juan-medina/cecibot/commands/raid/data/entities"
+	"github.com/juan-medina/cecibot/commands/raid/dkp"
+	"github.com/juan-medina/cecibot/commands/raid/shared"
+	"github.com/juan-medina/cecibot/prototype"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// the settings with the loot points awarded to the members that attend a raid, and to the ones that are on time
+const attendancePointsSetting = "dkp:attendance"
+const onTimePointsSetting = "dkp:ontime"
+
+const historyEntries = 10
+
+const transactionDateFormat = "2006-01-02"
+
+var dkpOptions = map[string]string{
+	"attendance": attendancePointsSetting,
+	"ontime":     onTimePointsSetting,
+	"decay":      dkp.DecaySetting,
+}
+
+func describeTransaction(transaction entities.Transaction) string {
+	detail := transaction.Reason
+	switch transaction.Kind {
+	case entities.TransactionLoot:
+		detail = fmt.Sprintf("*%s*", transaction.Item)
+		if transaction.Char != "" {
+			detail += fmt.Sprintf(" for **%s**", transaction.Char)
+		}
+	case entities.TransactionAttendance:
+		detail = fmt.Sprintf("attendance to raid **%s**", transaction.Raid)
+	case entities.TransactionOnTime:
+		detail = fmt.Sprintf("on time to raid **%s**", transaction.Raid)
+	}
+
+	result := fmt.Sprintf("`%s` **%+d** %s %s", transaction.Date.Format(transactionDateFormat), transaction.Points, transaction.Kind, detail)
+	if transaction.Author != "" {
+		result += fmt.Sprintf(" by <@%s>", transaction.Author)
+	}
+	return strings.TrimSpace(result)
+}
+
+func (d *raidCommands) points(data prototype.RaidDataProvider, key string) int {
+	value, err := data.GetSetting(key)
+	if err != nil || value == "" {
+		return 0
+	}
+	points, _ := strconv.Atoi(value)
+	return points
+}
+
+// awardAttendance gives the loot points for the attendance of a raid, if it was closed before only the difference is
+// added to the ledger, so it keeps every change. Returns the number of members that have changed their points
+func (d *raidCommands) awardAttendance(data prototype.RaidDataProvider, raid entities.Raid, attendance []entities.Attendance, author string) (int, error) {
+	type award struct {
+		member string
+		kind   string
+	}
+
+	attendancePoints := d.points(data, attendancePointsSetting)
+	onTimePoints := d.points(data, onTimePointsSetting)
+
+	chars := make(map[string]string)
+	target := make(map[award]int)
+	for _, record := range attendance {
+		chars[record.Member] = record.Char
+		switch record.Status {
+		case entities.AttendanceAttended:
+			target[award{record.Member, entities.TransactionAttendance}] = attendancePoints
+			target[award{record.Member, entities.TransactionOnTime}] = onTimePoints
+		case entities.AttendanceLate, entities.AttendanceBenched:
+			target[award{record.Member, entities.TransactionAttendance}] = attendancePoints
+		}
+	}
+
+	transactions, err := data.GetTransactions("")
+	if err != nil {
+		return 0, err
+	}
+	given := make(map[award]int)
+	for _, transaction := range transactions {
+		if transaction.Raid != raid.Id {
+			continue
+		}
+		if transaction.Kind == entities.TransactionAttendance || transaction.Kind == entities.TransactionOnTime {
+			given[award{transaction.Member, transaction.Kind}] += transaction.Points
+		}
+	}
+
+	awards := make([]award, 0, len(target)+len(given))
+	for key := range target {
+		awards = append(awards, key)
+	}
+	for key := range given {
+		if _, found := target[key]; !found {
+			awards = append(awards, key)
+		}
+	}
+	sort.Slice(awards, func(i, j int) bool {
+		if awards[i].member != awards[j].member {
+			return awards[i].member < awards[j].member
+		}
+		return awards[i].kind < awards[j].kind
+	})
+
+	changed := make(map[string]bool)
+	for _, key := range awards {
+		points := target[key] - given[key]
+		if points == 0 {
+			continue
+		}
+		_, err := data.AddTransaction(entities.Transaction{
+			Member: key.member,
+			Points: points,
+			Kind:   key.kind,
+			Raid:   raid.Id,
+			Char:   chars[key.member],
+			Author: author,
+			Date:   d.now(),
+		})
+		if err != nil {
+			return len(changed), err
+		}
+		changed[key.member] = true
+	}
+	return len(changed), nil
+}
+
+func (d *raidCommands) standings(data prototype.RaidDataProvider, req *prototype.Request, args []string) string {
+	transactions, err := data.GetTransactions("")
+	if err != nil {
+		return shared.Failure(err, "")
+	}
+	if len(transactions) == 0 {
+		return "there are no loot points yet"
+	}
+
+	balances := dkp.Balances(transactions)
+	members := make([]string, 0, len(balances))
+	for member := range balances {
+		members = append(members, member)
+	}
+	sort.Slice(members, func(i, j int) bool {
+		if balances[members[i]] != balances[members[j]] {
+			return balances[members[i]] > balances[members[j]]
+		}
+		return members[i] < members[j]
+	})
+
+	result := "loot points standings:\n"
+	for _, member := range members {
+		result += fmt.Sprintf("\t<@%s> : **%d**\n", member, balances[member])
+	}
+	return result
+}
+
+func (d *raidCommands) history(data prototype.RaidDataProvider, req *prototype.Request, args []string) string {
+	member := req.Author
+	if len(args) > 0 {
+		var err error
+		if member, err = mentionedMember(req, args[0]); err != nil {
+			return err.Error()
+		}
+	}
+
+	transactions, err := data.GetTransactions(member)
+	if err != nil {
+		return shared.Failure(err, "")
+	}
+
+	result := fmt.Sprintf("loot points of <@%s> : **%d**", member, dkp.Balances(transactions)[member])
+	if len(transactions) > historyEntries {
+		transactions = transactions[len(transactions)-historyEntries:]
+	}
+	for _, transaction := range transactions {
+		result += "\n\t" + describeTransaction(transaction)
+	}
+	return result
+}
+
+func (d *raidCommands) addPoints(data prototype.RaidDataProvider, req *prototype.Request, args []string, sign int) string {
+	argc := len(args)
+	if argc > 1 {
+		points, err := strconv.Atoi(args[1])
+		if err != nil || points <= 0 {
+			return fmt.Sprintf("invalid points %q, use a number greater than 0", args[1])
+		}
+		member, err := mentionedMember(req, args[0])
+		if err != nil {
+			return err.Error()
+		}
+
+		transaction := entities.Transaction{
+			Member: member,
+			Points: sign * points,
+			Kind:   entities.TransactionAward,
+			Reason: strings.Join(args[2:], " "),
+			Author: req.Author,
+			Date:   d.now(),
+		}
+		if _, err := data.AddTransaction(transaction); err != nil {
+			return shared.Failure(err, "")
+		}
+		return fmt.Sprintf("**%+d** loot points for <@%s>", transaction.Points, transaction.Member)
+	}
+
+	return ""
+}
+
+func (d *raidCommands) award(data prototype.RaidDataProvider, req *prototype.Request, args []string) string {
+	return d.addPoints(data, req, args, 1)
+}
+
+func (d *raidCommands) deduct(data prototype.RaidDataProvider, req *prototype.Request, args []string) string {
+	return d.addPoints(data, req, args, -1)
+}
+
+func (d *raidCommands) loot(data prototype.RaidDataProvider, req *prototype.Request, args []string) string {
+	argc := len(args)
+	if argc > 3 {
+		cost, err := strconv.Atoi(args[2])
+		if err != nil || cost < 0 {
+			return fmt.Sprintf("invalid cost %q, use a number of points", args[2])
+		}
+
+		member, err := mentionedMember(req, args[0])
+		if err != nil {
+			return err.Error()
+		}
+		char := args[1]
+		if character, found := d.findCharacter(data, member, char); found {
+			char = character.Name
+		}
+
+		transaction := entities.Transaction{
+			Member: member,
+			Points: -cost,
+			Kind:   entities.TransactionLoot,
+			Char:   char,
+			Item:   strings.Join(args[3:], " "),
+			Author: req.Author,
+			Date:   d.now(),
+		}
+		if _, err := data.AddTransaction(transaction); err != nil {
+			return shared.Failure(err, "")
+		}
+		return fmt.Sprintf("*%s* given to **%s** <@%s> for **%d** loot points", transaction.Item, char, member, cost)
+	}
+
+	return ""
+}
+
+func (d *raidCommands) dkpConfig(data prototype.RaidDataProvider, req *prototype.Request, args []string) string {
+	// every option is checked before changing any, so an invalid one leaves the configuration as it was
+	keys := make([]string, 0, len(args))
+	values := make(map[string]string)
+	for _, arg := range args {
+		parts := strings.SplitN(arg, "=", 2)
+		key, found := dkpOptions[strings.ToLower(parts[0])]
+		if !found || len(parts) != 2 {
+			return fmt.Sprintf("invalid option %q, use *attendance=N*, *ontime=N* or *decay=N*", arg)
+		}
+
+		value := parts[1]
+		if key == dkp.DecaySetting {
+			if _, err := dkp.ParsePercentage(value); err != nil {
+				return err.Error()
+			}
+		} else if points, err := strconv.Atoi(value); err != nil || points < 0 {
+			return fmt.Sprintf("invalid points %q, use a number of points", value)
+		}
+
+		if _, found := values[key]; !found {
+			keys = append(keys, key)
+		}
+		values[key] = value
+	}
+
+	if _, found := values[dkp.DecaySetting]; found {
+		// the decay starts counting the weeks again
+		keys = append([]string{dkp.DecayedSetting}, keys...)
+		values[dkp.DecayedSetting] = ""
+	}
+	for _, key := range keys {
+		if err := data.SetSetting(key, values[key]); err != nil {
+			return shared.Failure(err, "")
+		}
+	}
+
+	return fmt.Sprintf("loot points for attendance **%d**, for being on time **%d**, weekly decay **%d%%**",
+		d.points(data, attendancePointsSetting), d.points(data, onTimePointsSetting), d.points(data, dkp.DecaySetting))
+}
+
+func (d *raidCommands) dkp(req *prototype.Request) *prototype.Response {
+	if len(req.Args) == 0 {
+		standings := *req
+		standings.Args = []string{"standings"}
+		req = &standings
+	}
+	return d.dispatch(req, d.dkpCommands)
+}
+
+func (d *raidCommands) addDkpCommand(key string, officersOnly bool, fun subCommandFunction) {
+	d.dkpCommands[key] = subCommand{officersOnly: officersOnly, fun: fun}
+}
+
+func newDkpCommand(prov *raidCommands) *prototype.Command {
+	return command.New("dkp",
+		"Manage *loot points*.",
+		`With this command you could see and manage the loot points of the members
+Usage:
+	**dkp** *option* *parameters*
+*Options* for *members* and *officer* are:
+	**standings**
+		list the loot points of every member, the default option
+	**history** *member*
+		shows your last loot points transactions, or the ones of the *member*
+*Options* for *officers* only are:
+	**award** *member* *points* *reason*
+		gives loot points to a *member*
+	**deduct** *member* *points* *reason*
+		takes loot points from a *member*
+	**loot** *member* *char* *cost* *item*
+		records the *item* given to the *char* of a *member*, that costs the given loot points
+	**config** *attendance=N* *ontime=N* *decay=N*
+		sets the loot points given when a raid is closed to the members that attended, and to the ones that were on time, and the weekly *decay* percentage. Shows the current configuration
+`,
+		prov.dkp)
+}
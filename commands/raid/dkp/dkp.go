@@ -0,0 +1,29 @@
+package dkp
+
+import (
+	"fmt"
+	"github.com/juan-medina/cecibot/commands/raid/data/entities"
+	"strconv"
+)
+
+// DecaySetting is the weekly percentage that the loot points of every member decay, none if it is not set
+const DecaySetting = "dkp:decay"
+
+// DecayedSetting keeps when the decay was applied for the last time
+const DecayedSetting = "dkp:decayed"
+
+func ParsePercentage(text string) (int, error) {
+	value, err := strconv.Atoi(text)
+	if err != nil || value < 0 || value > 100 {
+		return 0, fmt.Errorf("invalid percentage %q, use a number from 0 to 100", text)
+	}
+	return value, nil
+}
+
+func Balances(transactions []entities.Transaction) map[string]int {
+	result := make(map[string]int)
+	for _, transaction := range transactions {
+		result[transaction.Member] += transaction.Points
+	}
+	return result
+}
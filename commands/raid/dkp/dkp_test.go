@@ -0,0 +1,44 @@
+package dkp
+
+import (
+	"github.com/juan-medina/cecibot/commands/raid/data/entities"
+	"reflect"
+	"testing"
+)
+
+func TestParsePercentage(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		want    int
+		wantErr bool
+	}{
+		{"percentage", "10", 10, false},
+		{"no decay", "0", 0, false},
+		{"not a number", "ten", 0, true},
+		{"over 100", "101", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePercentage(tt.text)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("want error %v, got %v", tt.wantErr, err)
+			}
+			if got != tt.want {
+				t.Errorf("want %d, got %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestBalances(t *testing.T) {
+	transactions := []entities.Transaction{
+		{Member: "1", Points: 100},
+		{Member: "2", Points: 55},
+		{Member: "1", Points: -30},
+	}
+	want := map[string]int{"1": 70, "2": 55}
+	if got := Balances(transactions); !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
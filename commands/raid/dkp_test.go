@@ -0,0 +1,151 @@
+package raid
+
+import (
+	"github.com/juan-medina/cecibot/commands/raid/data/entities"
+	"github.com/juan-medina/cecibot/commands/raid/data/memory"
+	"github.com/juan-medina/cecibot/commands/raid/shared"
+	"testing"
+	"time"
+)
+
+func Test_raidCommands_dkp(t *testing.T) {
+	prc := fakeProcessor{}
+	store := memory.New()
+	data := store.Guild(fakeGuild)
+	rc := newRaidCommands(prc, store, fakeNow)
+
+	raid := addRaid(t, data, "Molten Core", time.Date(2019, 10, 30, 20, 0, 0, 0, time.Local))
+	_ = data.SignUp(raid.Id, entities.Signup{Member: "1", Char: "Thrall", Class: "Shaman", Spec: "Restoration", Status: entities.SignupConfirmed})
+	_ = data.SignUp(raid.Id, entities.Signup{Member: "2", Char: "Jaina", Class: "Mage", Spec: "Arcane", Status: entities.SignupConfirmed})
+	_ = data.AddCharacter(entities.Character{Member: "1", Name: "Thrall", Class: "Shaman", Spec: "Restoration", Main: true})
+
+	cases := []struct {
+		name   string
+		args   []string
+		author string
+		want   string
+	}{
+		{
+			name:   "there are no loot points",
+			args:   []string{"dkp"},
+			author: "1",
+			want:   "there are no loot points yet",
+		},
+		{
+			name:   "should be only for officers",
+			args:   []string{"dkp", "award", "<@1>", "50"},
+			author: "1",
+			want:   shared.OfficersOnly,
+		},
+		{
+			name:   "should fail with invalid points",
+			args:   []string{"dkp", "award", "<@1>", "ten"},
+			author: "123",
+			want:   `invalid points "ten", use a number greater than 0`,
+		},
+		{
+			name:   "should award points",
+			args:   []string{"dkp", "award", "<@1>", "50", "world", "boss"},
+			author: "123",
+			want:   "**+50** loot points for <@1>",
+		},
+		{
+			name:   "should deduct points",
+			args:   []string{"dkp", "deduct", "<@!2>", "5", "late"},
+			author: "123",
+			want:   "**-5** loot points for <@2>",
+		},
+		{
+			name:   "should fail with invalid config points",
+			args:   []string{"dkp", "config", "attendance=ten"},
+			author: "123",
+			want:   `invalid points "ten", use a number of points`,
+		},
+		{
+			name:   "should fail with an invalid decay",
+			args:   []string{"dkp", "config", "decay=200"},
+			author: "123",
+			want:   `invalid percentage "200", use a number from 0 to 100`,
+		},
+		{
+			name:   "should fail with an invalid option",
+			args:   []string{"dkp", "config", "boss=10"},
+			author: "123",
+			want:   `invalid option "boss=10", use *attendance=N*, *ontime=N* or *decay=N*`,
+		},
+		{
+			name:   "should configure the points",
+			args:   []string{"dkp", "config", "attendance=10", "ontime=5"},
+			author: "123",
+			want:   "loot points for attendance **10**, for being on time **5**, weekly decay **0%**",
+		},
+		{
+			name:   "should not configure anything if an option is invalid",
+			args:   []string{"dkp", "config", "attendance=20", "decay=200"},
+			author: "123",
+			want:   `invalid percentage "200", use a number from 0 to 100`,
+		},
+		{
+			name:   "should keep the configuration after an invalid option",
+			args:   []string{"dkp", "config"},
+			author: "123",
+			want:   "loot points for attendance **10**, for being on time **5**, weekly decay **0%**",
+		},
+		{
+			name:   "should award the attendance closing a raid",
+			args:   []string{"raid", "close", raid.Id, "late", "<@2>"},
+			author: "123",
+			want: "raid **Molten Core** (**1**) closed, attended **1**, late **1**, no-show **0**, benched **0**\n" +
+				"loot points updated for **2** members",
+		},
+		{
+			name:   "should award only the difference closing again",
+			args:   []string{"raid", "close", raid.Id},
+			author: "123",
+			want: "attendance of raid **Molten Core** (**1**) updated, attended **2**, late **0**, no-show **0**, benched **0**\n" +
+				"loot points updated for **1** members",
+		},
+		{
+			name:   "should fail with an invalid cost",
+			args:   []string{"dkp", "loot", "<@1>", "thrall", "free", "Onslaught", "Girdle"},
+			author: "123",
+			want:   `invalid cost "free", use a number of points`,
+		},
+		{
+			name:   "should record the loot",
+			args:   []string{"dkp", "loot", "<@1>", "thrall", "20", "Onslaught", "Girdle"},
+			author: "123",
+			want:   "*Onslaught Girdle* given to **Thrall** <@1> for **20** loot points",
+		},
+		{
+			name:   "should show the standings",
+			args:   []string{"dkp", "standings"},
+			author: "1",
+			want:   "loot points standings:\n\t<@1> : **45**\n\t<@2> : **10**\n",
+		},
+		{
+			name:   "should show the history of a member",
+			args:   []string{"dkp", "history", "<@2>"},
+			author: "1",
+			want: "loot points of <@2> : **10**\n" +
+				"\t`2019-11-01` **-5** award late by <@123>\n" +
+				"\t`2019-11-01` **+10** attendance attendance to raid **1** by <@123>\n" +
+				"\t`2019-11-01` **+5** ontime on time to raid **1** by <@123>",
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			req := newRequest(tt.args[1:], tt.author, fakeGuild)
+			var got string
+			if tt.args[0] == "raid" {
+				got = rc.raid(req).String()
+			} else {
+				got = rc.dkp(req).String()
+			}
+			if got != tt.want {
+				t.Errorf("want %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package raid
+
+import (
+	"fmt"
+	"github.com/juan-medina/cecibot/command"
+	"github.com/juan-medina/cecibot/commands/raid/export"
+	"github.com/juan-medina/cecibot/commands/raid/shared"
+	"github.com/juan-medina/cecibot/prototype"
+)
+
+// exportOptions are what could be exported, with the formats completed after them
+var exportOptions = []string{"raids", "roster", "attendance", "calendar"}
+
+var exportFormats = []string{export.CSV, export.JSON}
+
+func (d *raidCommands) exportRaids(data prototype.RaidDataProvider, req *prototype.Request, args []string) *prototype.Response {
+	argc := len(args)
+	if argc > 0 {
+		file, err := export.Export(data, args, d.now())
+		if err != nil {
+			if invalid, ok := err.(*export.InvalidError); ok {
+				return command.Text(invalid.Error())
+			}
+			raidId := ""
+			if argc > 1 {
+				raidId = args[1]
+			}
+			return command.Text(shared.Failure(err, raidId))
+		}
+		return command.Files(fmt.Sprintf("exported *%s*", file.Name), file)
+	}
+
+	return command.Text("")
+}
@@ -0,0 +1,301 @@
+// Package export writes the raids, rosters and attendance of a guild as csv or json, and the upcoming raids as an
+// iCalendar, for the export command and the export mode.
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/juan-medina/cecibot/commands/raid/classes"
+	"github.com/juan-medina/cecibot/commands/raid/data/entities"
+	"github.com/juan-medina/cecibot/prototype"
+	"strings"
+	"time"
+)
+
+const (
+	CSV  = "csv"
+	JSON = "json"
+)
+
+// raidDuration is the length of the raids in the calendar, since they only have a start
+const raidDuration = 3 * time.Hour
+
+const calendarDateFormat = "20060102T150405Z"
+
+// maxLineLength is the longest calendar line in octets, the longer ones are folded
+const maxLineLength = 75
+
+var contentTypes = map[string]string{
+	CSV:  "text/csv",
+	JSON: "application/json",
+}
+
+// InvalidError is an error in the arguments of an export, its text could be shown to the user
+type InvalidError struct {
+	message string
+}
+
+func (e *InvalidError) Error() string {
+	return e.message
+}
+
+func invalid(format string, a ...interface{}) error {
+	return &InvalidError{message: fmt.Sprintf(format, a...)}
+}
+
+type raidRecord struct {
+	Id        string `json:"id"`
+	Name      string `json:"name"`
+	Date      string `json:"date"`
+	Cancelled bool   `json:"cancelled"`
+	Closed    bool   `json:"closed"`
+	Signups   int    `json:"signups"`
+}
+
+type signupRecord struct {
+	Raid   string `json:"raid"`
+	Member string `json:"member"`
+	Char   string `json:"char"`
+	Class  string `json:"class"`
+	Spec   string `json:"spec"`
+	Role   string `json:"role"`
+	Status string `json:"status"`
+}
+
+type attendanceRecord struct {
+	Raid   string `json:"raid"`
+	Name   string `json:"name"`
+	Date   string `json:"date"`
+	Member string `json:"member"`
+	Char   string `json:"char"`
+	Status string `json:"status"`
+}
+
+func formatDate(date time.Time) string {
+	return date.UTC().Format(time.RFC3339)
+}
+
+func formatBool(value bool) string {
+	if value {
+		return "true"
+	}
+	return "false"
+}
+
+// signupStatus names the status of a signup, the confirmed ones do not have it
+func signupStatus(status string) string {
+	if status == entities.SignupConfirmed {
+		return "confirmed"
+	}
+	return status
+}
+
+func encode(name string, format string, records interface{}, header []string, rows [][]string) (prototype.File, error) {
+	buffer := &bytes.Buffer{}
+	switch format {
+	case CSV:
+		writer := csv.NewWriter(buffer)
+		if err := writer.Write(header); err != nil {
+			return prototype.File{}, err
+		}
+		if err := writer.WriteAll(rows); err != nil {
+			return prototype.File{}, err
+		}
+	case JSON:
+		encoder := json.NewEncoder(buffer)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(records); err != nil {
+			return prototype.File{}, err
+		}
+	default:
+		return prototype.File{}, invalid("unknown format %q, use *csv* or *json*", format)
+	}
+
+	return prototype.File{Name: name + "." + format, ContentType: contentTypes[format], Data: buffer.Bytes()}, nil
+}
+
+func Raids(data prototype.RaidDataProvider, format string) (prototype.File, error) {
+	raids, err := data.GetRaids()
+	if err != nil {
+		return prototype.File{}, err
+	}
+
+	records := make([]raidRecord, 0, len(raids))
+	rows := make([][]string, 0, len(raids))
+	for _, raid := range raids {
+		signups, err := data.GetSignups(raid.Id)
+		if err != nil {
+			return prototype.File{}, err
+		}
+		record := raidRecord{
+			Id:        raid.Id,
+			Name:      raid.Name,
+			Date:      formatDate(raid.Date),
+			Cancelled: raid.Cancelled,
+			Closed:    raid.Closed,
+			Signups:   len(signups),
+		}
+		records = append(records, record)
+		rows = append(rows, []string{record.Id, record.Name, record.Date, formatBool(record.Cancelled),
+			formatBool(record.Closed), fmt.Sprint(record.Signups)})
+	}
+
+	header := []string{"id", "name", "date", "cancelled", "closed", "signups"}
+	return encode("raids", format, records, header, rows)
+}
+
+func Roster(data prototype.RaidDataProvider, raidId string, format string) (prototype.File, error) {
+	raid, err := data.GetRaid(raidId)
+	if err != nil {
+		return prototype.File{}, err
+	}
+	signups, err := data.GetSignups(raid.Id)
+	if err != nil {
+		return prototype.File{}, err
+	}
+
+	records := make([]signupRecord, 0, len(signups))
+	rows := make([][]string, 0, len(signups))
+	for _, signup := range signups {
+		role := signup.Role
+		if role == "" {
+			role = string(classes.RoleOf(signup.Class, signup.Spec))
+		}
+		record := signupRecord{
+			Raid:   raid.Id,
+			Member: signup.Member,
+			Char:   signup.Char,
+			Class:  signup.Class,
+			Spec:   signup.Spec,
+			Role:   role,
+			Status: signupStatus(signup.Status),
+		}
+		records = append(records, record)
+		rows = append(rows, []string{record.Raid, record.Member, record.Char, record.Class, record.Spec, record.Role, record.Status})
+	}
+
+	header := []string{"raid", "member", "char", "class", "spec", "role", "status"}
+	return encode("roster-"+raid.Id, format, records, header, rows)
+}
+
+func Attendance(data prototype.RaidDataProvider, format string) (prototype.File, error) {
+	raids, err := data.GetRaids()
+	if err != nil {
+		return prototype.File{}, err
+	}
+
+	records := make([]attendanceRecord, 0)
+	rows := make([][]string, 0)
+	for _, raid := range raids {
+		if !raid.Closed || raid.Cancelled {
+			continue
+		}
+		attendance, err := data.GetAttendance(raid.Id)
+		if err != nil {
+			return prototype.File{}, err
+		}
+		for _, entry := range attendance {
+			record := attendanceRecord{
+				Raid:   raid.Id,
+				Name:   raid.Name,
+				Date:   formatDate(raid.Date),
+				Member: entry.Member,
+				Char:   entry.Char,
+				Status: entry.Status,
+			}
+			records = append(records, record)
+			rows = append(rows, []string{record.Raid, record.Name, record.Date, record.Member, record.Char, record.Status})
+		}
+	}
+
+	header := []string{"raid", "name", "date", "member", "char", "status"}
+	return encode("attendance", format, records, header, rows)
+}
+
+func escapeText(text string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`)
+	return replacer.Replace(text)
+}
+
+// foldLine splits the lines longer than 75 octets, without splitting a character, continuing them with a space
+func foldLine(line string) string {
+	result := ""
+	length := 0
+	for _, r := range line {
+		size := len(string(r))
+		if length+size > maxLineLength {
+			result += "\r\n "
+			length = 1
+		}
+		result += string(r)
+		length += size
+	}
+	return result + "\r\n"
+}
+
+// Calendar writes the raids that have not started, and are not cancelled, as iCalendar events
+func Calendar(data prototype.RaidDataProvider, now time.Time) (prototype.File, error) {
+	raids, err := data.GetRaids()
+	if err != nil {
+		return prototype.File{}, err
+	}
+
+	lines := []string{"BEGIN:VCALENDAR", "VERSION:2.0", "PRODID:-//cecibot//raids//EN", "CALSCALE:GREGORIAN"}
+	for _, raid := range raids {
+		if raid.Cancelled || !raid.Date.After(now) {
+			continue
+		}
+		signups, err := data.GetSignups(raid.Id)
+		if err != nil {
+			return prototype.File{}, err
+		}
+		lines = append(lines,
+			"BEGIN:VEVENT",
+			fmt.Sprintf("UID:raid-%s-%d@cecibot", raid.Id, raid.Date.Unix()),
+			"DTSTAMP:"+now.UTC().Format(calendarDateFormat),
+			"DTSTART:"+raid.Date.UTC().Format(calendarDateFormat),
+			"DTEND:"+raid.Date.Add(raidDuration).UTC().Format(calendarDateFormat),
+			"SUMMARY:"+escapeText(raid.Name),
+			"DESCRIPTION:"+escapeText(fmt.Sprintf("raid-id %s, %d signed up", raid.Id, len(signups))),
+			"END:VEVENT",
+		)
+	}
+	lines = append(lines, "END:VCALENDAR")
+
+	buffer := &bytes.Buffer{}
+	for _, line := range lines {
+		buffer.WriteString(foldLine(line))
+	}
+	return prototype.File{Name: "raids.ics", ContentType: "text/calendar", Data: buffer.Bytes()}, nil
+}
+
+// Export uses csv unless json is given after what to export
+func Export(data prototype.RaidDataProvider, args []string, now time.Time) (prototype.File, error) {
+	if len(args) == 0 {
+		return prototype.File{}, invalid("give what to export, use *raids*, *roster raid-id*, *attendance* or *calendar*")
+	}
+
+	format := func(position int) string {
+		if len(args) > position {
+			return strings.ToLower(args[position])
+		}
+		return CSV
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "raids":
+		return Raids(data, format(1))
+	case "roster":
+		if len(args) < 2 {
+			return prototype.File{}, invalid("give the *raid-id* of the roster to export")
+		}
+		return Roster(data, args[1], format(2))
+	case "attendance":
+		return Attendance(data, format(1))
+	case "calendar":
+		return Calendar(data, now)
+	}
+	return prototype.File{}, invalid("unknown export %q, use *raids*, *roster raid-id*, *attendance* or *calendar*", args[0])
+}
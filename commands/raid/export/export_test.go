@@ -0,0 +1,172 @@
+package export
+
+import (
+	"github.com/juan-medina/cecibot/commands/raid/data/entities"
+	"github.com/juan-medina/cecibot/commands/raid/data/memory"
+	"github.com/juan-medina/cecibot/prototype"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testData(t *testing.T) prototype.RaidDataProvider {
+	t.Helper()
+	data := memory.New().Guild("guild1")
+
+	mc, _ := data.AddRaid(entities.Raid{Name: "Molten Core", Date: time.Date(2019, 10, 30, 19, 0, 0, 0, time.UTC)})
+	ony, _ := data.AddRaid(entities.Raid{Name: "Onyxia's Lair, again", Date: time.Date(2019, 11, 6, 19, 0, 0, 0, time.UTC)})
+	bwl, _ := data.AddRaid(entities.Raid{Name: "Blackwing Lair", Date: time.Date(2019, 11, 8, 19, 0, 0, 0, time.UTC)})
+	bwl.Cancelled = true
+	_ = data.UpdateRaid(bwl)
+
+	_ = data.SignUp(mc.Id, entities.Signup{Member: "1", Char: "Thrall", Class: "Shaman", Spec: "Restoration"})
+	_ = data.SignUp(mc.Id, entities.Signup{Member: "2", Char: "Jaina", Class: "Mage", Spec: "Arcane", Status: entities.SignupBenched})
+	_ = data.SignUp(ony.Id, entities.Signup{Member: "1", Char: "Thrall", Class: "Shaman", Spec: "Enhancement", Role: "Tank"})
+	_ = data.CloseRaid(mc.Id, []entities.Attendance{
+		{Member: "1", Char: "Thrall", Status: entities.AttendanceAttended},
+		{Member: "2", Char: "Jaina", Status: entities.AttendanceBenched},
+	})
+	return data
+}
+
+func TestExport(t *testing.T) {
+	data := testData(t)
+	now := time.Date(2019, 11, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		args        []string
+		wantName    string
+		wantType    string
+		wantData    string
+		wantInvalid string
+	}{
+		{
+			name:     "should export the raids",
+			args:     []string{"raids"},
+			wantName: "raids.csv",
+			wantType: "text/csv",
+			wantData: "id,name,date,cancelled,closed,signups\n" +
+				"1,Molten Core,2019-10-30T19:00:00Z,false,true,2\n" +
+				"2,\"Onyxia's Lair, again\",2019-11-06T19:00:00Z,false,false,1\n" +
+				"3,Blackwing Lair,2019-11-08T19:00:00Z,true,false,0\n",
+		},
+		{
+			name:     "should export a roster as json",
+			args:     []string{"roster", "1", "JSON"},
+			wantName: "roster-1.json",
+			wantType: "application/json",
+			wantData: `[
+  {
+    "raid": "1",
+    "member": "1",
+    "char": "Thrall",
+    "class": "Shaman",
+    "spec": "Restoration",
+    "role": "Healer",
+    "status": "confirmed"
+  },
+  {
+    "raid": "1",
+    "member": "2",
+    "char": "Jaina",
+    "class": "Mage",
+    "spec": "Arcane",
+    "role": "Ranged",
+    "status": "bench"
+  }
+]
+`,
+		},
+		{
+			name:     "should export the role chosen in a roster",
+			args:     []string{"roster", "2"},
+			wantName: "roster-2.csv",
+			wantType: "text/csv",
+			wantData: "raid,member,char,class,spec,role,status\n2,1,Thrall,Shaman,Enhancement,Tank,confirmed\n",
+		},
+		{
+			name:     "should export the attendance",
+			args:     []string{"attendance", "csv"},
+			wantName: "attendance.csv",
+			wantType: "text/csv",
+			wantData: "raid,name,date,member,char,status\n" +
+				"1,Molten Core,2019-10-30T19:00:00Z,1,Thrall,attended\n" +
+				"1,Molten Core,2019-10-30T19:00:00Z,2,Jaina,benched\n",
+		},
+		{
+			name:     "should export the upcoming raids as a calendar",
+			args:     []string{"calendar"},
+			wantName: "raids.ics",
+			wantType: "text/calendar",
+			wantData: "BEGIN:VCALENDAR\r\n" +
+				"VERSION:2.0\r\n" +
+				"PRODID:-//cecibot//raids//EN\r\n" +
+				"CALSCALE:GREGORIAN\r\n" +
+				"BEGIN:VEVENT\r\n" +
+				"UID:raid-2-1573066800@cecibot\r\n" +
+				"DTSTAMP:20191101T120000Z\r\n" +
+				"DTSTART:20191106T190000Z\r\n" +
+				"DTEND:20191106T220000Z\r\n" +
+				"SUMMARY:Onyxia's Lair\\, again\r\n" +
+				"DESCRIPTION:raid-id 2\\, 1 signed up\r\n" +
+				"END:VEVENT\r\n" +
+				"END:VCALENDAR\r\n",
+		},
+		{
+			name:        "should fail with an unknown format",
+			args:        []string{"raids", "xml"},
+			wantInvalid: `unknown format "xml", use *csv* or *json*`,
+		},
+		{
+			name:        "should fail with an unknown export",
+			args:        []string{"loot"},
+			wantInvalid: `unknown export "loot", use *raids*, *roster raid-id*, *attendance* or *calendar*`,
+		},
+		{
+			name:        "should fail without the raid-id of the roster",
+			args:        []string{"roster"},
+			wantInvalid: "give the *raid-id* of the roster to export",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Export(data, tt.args, now)
+			if tt.wantInvalid != "" {
+				if invalid, ok := err.(*InvalidError); !ok || invalid.Error() != tt.wantInvalid {
+					t.Errorf("want error %q, got %v", tt.wantInvalid, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("want not error, got %v", err)
+			}
+			if got.Name != tt.wantName || got.ContentType != tt.wantType {
+				t.Errorf("want file %q of %q, got %q of %q", tt.wantName, tt.wantType, got.Name, got.ContentType)
+			}
+			if string(got.Data) != tt.wantData {
+				t.Errorf("want %q, got %q", tt.wantData, got.Data)
+			}
+		})
+	}
+
+	t.Run("should fail with a raid not found", func(t *testing.T) {
+		if _, err := Export(data, []string{"roster", "9"}, now); err != entities.ErrRaidNotFound {
+			t.Errorf("want %v, got %v", entities.ErrRaidNotFound, err)
+		}
+	})
+}
+
+func Test_foldLine(t *testing.T) {
+	line := "SUMMARY:" + strings.Repeat("á", 40)
+	got := foldLine(line)
+
+	for _, part := range strings.Split(strings.TrimSuffix(got, "\r\n"), "\r\n") {
+		if len(part) > maxLineLength {
+			t.Errorf("want lines up to %d octets, got %d", maxLineLength, len(part))
+		}
+	}
+	if unfolded := strings.Replace(got, "\r\n ", "", -1); unfolded != line+"\r\n" {
+		t.Errorf("want %q unfolded, got %q", line, unfolded)
+	}
+}
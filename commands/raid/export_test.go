@@ -0,0 +1,87 @@
+package raid
+
+import (
+	"github.com/juan-medina/cecibot/commands/raid/data/memory"
+	"github.com/juan-medina/cecibot/commands/raid/shared"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_raidCommands_exportRaids(t *testing.T) {
+	prc := fakeProcessor{}
+	store := memory.New()
+	data := store.Guild(fakeGuild)
+	rc := newRaidCommands(prc, store, fakeNow)
+
+	addRaid(t, data, "Molten Core", time.Date(2019, 11, 2, 20, 0, 0, 0, time.Local))
+
+	cases := []struct {
+		name   string
+		args   []string
+		author string
+		want   string
+		file   string
+	}{
+		{
+			name:   "should be only for officers",
+			args:   []string{"export", "raids"},
+			author: "1",
+			want:   shared.OfficersOnly,
+		},
+		{
+			name:   "should export the raids",
+			args:   []string{"export", "raids", "json"},
+			author: "123",
+			want:   "exported *raids.json*",
+			file:   "raids.json",
+		},
+		{
+			name:   "should export the calendar",
+			args:   []string{"export", "calendar"},
+			author: "123",
+			want:   "exported *raids.ics*",
+			file:   "raids.ics",
+		},
+		{
+			name:   "should fail with an unknown format",
+			args:   []string{"export", "attendance", "xml"},
+			author: "123",
+			want:   `unknown format "xml", use *csv* or *json*`,
+		},
+		{
+			name:   "should fail with a raid not found",
+			args:   []string{"export", "roster", "9"},
+			author: "123",
+			want:   "raid **9** not found",
+		},
+		{
+			name:   "should do nothing without arguments",
+			args:   []string{"export"},
+			author: "123",
+			want:   "",
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := rc.raid(newRequest(tt.args, tt.author, fakeGuild))
+			if got := resp.String(); got != tt.want {
+				t.Errorf("want %q, got %q", tt.want, got)
+			}
+
+			files := 0
+			for _, msg := range resp.Messages {
+				for _, file := range msg.Files {
+					files++
+					if file.Name != tt.file || !strings.Contains(string(file.Data), "Molten Core") {
+						t.Errorf("want %q with the raid, got %q", tt.file, file.Name)
+					}
+				}
+			}
+			if tt.file != "" && files != 1 {
+				t.Errorf("want one file, got %d", files)
+			}
+		})
+	}
+}
@@ -4,89 +4,164 @@ import (
 	"fmt"
 	"github.com/juan-medina/cecibot/command"
 	"github.com/juan-medina/cecibot/command/provider"
-	"github.com/juan-medina/cecibot/commands/raid/data"
+	"github.com/juan-medina/cecibot/commands/raid/shared"
 	"github.com/juan-medina/cecibot/prototype"
 	"go.uber.org/zap"
+	"sync"
+	"time"
 )
 
+type subCommandFunction func(data prototype.RaidDataProvider, req *prototype.Request, args []string) *prototype.Response
+
+type textSubCommandFunction func(data prototype.RaidDataProvider, req *prototype.Request, args []string) string
+
+func text(fun textSubCommandFunction) subCommandFunction {
+	return func(data prototype.RaidDataProvider, req *prototype.Request, args []string) *prototype.Response {
+		return command.Text(fun(data, req, args))
+	}
+}
+
+type subCommand struct {
+	officersOnly bool
+	fun          subCommandFunction
+}
+
 type raidCommands struct {
 	*provider.BaseProvider
-	data prototype.RaidDataProvider
+	store       prototype.RaidDataStore
+	now         func() time.Time
+	subCommands map[string]subCommand
+	dkpCommands map[string]subCommand
+	mu          sync.Mutex
+	servers     map[string]string // server selected by each member for direct messages
+	rosterMu    sync.Mutex        // held while the signups are checked against the raid limits and changed
 }
 
-func (d *raidCommands) officers() string {
+func (d *raidCommands) officers(data prototype.RaidDataProvider, req *prototype.Request, args []string) string {
+	officers, err := data.GetOfficers()
+	if err != nil {
+		return shared.Failure(err, "")
+	}
+
 	result := "raid officers:\n"
-	for _, officer := range d.data.GetOfficers() {
+	for _, officer := range officers {
 		result += fmt.Sprintf("\t<@%s>\n", officer.Id)
 	}
 	return result
 }
 
-func (d *raidCommands) deleteOfficer(args []string) string {
+func (d *raidCommands) deleteOfficer(data prototype.RaidDataProvider, args []string) string {
 	argc := len(args)
 	if argc > 0 {
 		id := args[0]
-		d.data.DeleteOfficer(id)
+		if err := data.DeleteOfficer(id); err != nil {
+			return shared.Failure(err, "")
+		}
 		return fmt.Sprintf("officer <@%s> deleted", id)
 	}
 
 	return ""
 }
 
-func (d *raidCommands) addOfficer(args []string) string {
+func (d *raidCommands) addOfficer(data prototype.RaidDataProvider, args []string) string {
 	argc := len(args)
 	if argc > 0 {
 		id := args[0]
-		d.data.AddOfficer(id)
+		if err := data.AddOfficer(id); err != nil {
+			return shared.Failure(err, "")
+		}
 		return fmt.Sprintf("officer <@%s> added", id)
 	}
 
 	return ""
 }
 
-func (d *raidCommands) officer(args []string) string {
+func (d *raidCommands) officer(data prototype.RaidDataProvider, req *prototype.Request, args []string) string {
 	argc := len(args)
 	if argc > 0 {
 		sub := args[0]
 		if sub == "delete" {
-			return d.deleteOfficer(args[1:])
+			return d.deleteOfficer(data, args[1:])
 		} else if sub == "add" {
-			return d.addOfficer(args[1:])
+			return d.addOfficer(data, args[1:])
 		}
 	}
 	return ""
 }
 
-func (d *raidCommands) raid(args []string, author string) string {
-	argc := len(args)
+// dispatch runs the sub command of a request, in direct messages with the data of the server selected by the member
+func (d *raidCommands) dispatch(req *prototype.Request, subCommands map[string]subCommand) *prototype.Response {
+	argc := len(req.Args)
 	if argc > 0 {
-		sub := args[0]
-		if sub == "officers" {
-			return d.officers()
-		} else if sub == "officer" {
-			if d.isOfficer(author) {
-				return d.officer(args[1:])
-			} else {
-				return "this command is for **officers** only"
+		sub, found := subCommands[req.Args[0]]
+		if found {
+			guild := req.Guild
+			if guild == "" {
+				var message string
+				if guild, message = d.directMessageGuild(req); guild == "" {
+					return command.Text(message)
+				}
+			}
+			data := d.store.Guild(guild)
+			if sub.officersOnly && !shared.IsOfficer(d.GetProcessor(), data, req.Author) {
+				return command.Text(shared.OfficersOnly)
 			}
+			return sub.fun(data, req, req.Args[1:])
 		}
 	}
 
-	return ""
+	return command.Text("")
 }
 
-func (d *raidCommands) isOfficer(id string) bool {
-	if d.GetProcessor().IsOwner(id) {
-		return true
+func (d *raidCommands) raid(req *prototype.Request) *prototype.Response {
+	if len(req.Args) > 0 && req.Args[0] == "server" {
+		return d.server(req)
 	}
+	return d.dispatch(req, d.subCommands)
+}
 
-	for _, officer := range d.data.GetOfficers() {
-		if officer.Id == id {
-			return true
-		}
+func (d *raidCommands) addSubCommand(key string, officersOnly bool, fun subCommandFunction) {
+	d.subCommands[key] = subCommand{officersOnly: officersOnly, fun: fun}
+}
+
+func newRaidCommands(p prototype.Processor, store prototype.RaidDataStore, now func() time.Time) *raidCommands {
+	var prov = &raidCommands{
+		BaseProvider: provider.New(p),
+		store:        store,
+		now:          now,
+		subCommands:  make(map[string]subCommand),
+		dkpCommands:  make(map[string]subCommand),
+		servers:      make(map[string]string),
 	}
 
-	return false
+	prov.addSubCommand("list", false, text(prov.listRaids))
+	prov.addSubCommand("sign", false, prov.sign)
+	prov.addSubCommand("roster", false, prov.roster)
+	prov.addSubCommand("rooster", false, prov.roster)
+	prov.addSubCommand("officers", false, text(prov.officers))
+	prov.addSubCommand("timezone", false, text(prov.timezone))
+	prov.addSubCommand("char", false, text(prov.character))
+	prov.addSubCommand("stats", false, text(prov.stats))
+	prov.addSubCommand("attendance", false, text(prov.attendance))
+	prov.addSubCommand("create", true, text(prov.createRaid))
+	prov.addSubCommand("schedule", true, text(prov.schedule))
+	prov.addSubCommand("announce", true, prov.announce)
+	prov.addSubCommand("bench", true, prov.bench)
+	prov.addSubCommand("unbench", true, prov.unbench)
+	prov.addSubCommand("cancels", true, prov.cancelRaid)
+	prov.addSubCommand("cancel", true, prov.cancelRaid)
+	prov.addSubCommand("close", true, text(prov.closeRaid))
+	prov.addSubCommand("export", true, prov.exportRaids)
+	prov.addSubCommand("officer", true, text(prov.officer))
+
+	prov.addDkpCommand("standings", false, text(prov.standings))
+	prov.addDkpCommand("history", false, text(prov.history))
+	prov.addDkpCommand("award", true, text(prov.award))
+	prov.addDkpCommand("deduct", true, text(prov.deduct))
+	prov.addDkpCommand("loot", true, text(prov.loot))
+	prov.addDkpCommand("config", true, text(prov.dkpConfig))
+
+	return prov
 }
 
 func New(p prototype.Processor) prototype.Provider {
@@ -94,12 +169,9 @@ func New(p prototype.Processor) prototype.Provider {
 	defer log.Sync()
 
 	log.Info("Creating raid commands")
-	var prov = raidCommands{
-		BaseProvider: provider.New(p),
-		data:         data.New(),
-	}
+	var prov = newRaidCommands(p, p.GetStore(), time.Now)
 
-	prov.AddCommand(command.New("raid",
+	cmd := command.New("raid",
 		"Manage *raid* attendance.",
 		`With this command you could create, list and confirm raid attendance
 Usage:
@@ -108,25 +180,61 @@ Usage:
 	**list**
 		list next raids, and their *raid-id*
 	**sign up** *raid-id* *char* *class* *spec*
-		confirm/change attendance for the desired *raid-id* wth the *char* using the given *class* and *spec*
+		confirm/change attendance for the desired *raid-id* with the *char* using the given *class* and *spec*, without *class* and *spec* the *char* is one of your characters, and without *char* your main
 	**sign down** *raid-id*
 		sign down for attendance for the desired *raid-id*
-	**rooster** *raid-id*
-		shows the rooster for the given *raid-id*
+	**roster** *raid-id*
+		shows the roster for the given *raid-id* grouped by role
+	**char add** *name* *class* *spec* *main/alt*
+		adds, or updates, one of your characters, your first one is your main unless *alt* is given
+	**char list** *member*
+		list your characters, *officers* could list the characters of a *member*
+	**char delete** *name*
+		deletes one of your characters
+	**stats** *member* *raids=N*
+		shows your attendance, or the one of the *member*, in the last closed raids, *10* unless *raids=N* is given
+	**attendance** *raids=N*
+		shows the attendance of every *member* in the last closed raids, *10* unless *raids=N* is given
 	**officers**
 		list raid officers
+	**timezone** *time-zone*
+		shows the raid times to you in the given *time-zone*, as *Europe/Madrid*, or in the server one with *none*. Without *time-zone* shows the current one
+	**server** *number*
+		in *direct messages*, choose which of the servers that we share receives the raid commands
 *Options* for *officers* only are:
-    **create** *name* *date*
-		creates a raid with the given *name* and *date*. Shows the *raid-id*
-	**cancels** *raid-id*
-		cancel the raid indicated by the *raid-id*
+	**create** *name* *date* *limits*
+		creates a raid with the given *name* and *date* as *YYYY-MM-DD HH:MM*, *tomorrow HH:MM* or a week day as *wed HH:MM*, in your time zone. Shows the *raid-id*. Optional *limits* as *size=40 tank=4 healer=8* cap the signups, the rest go to the waitlist and are promoted when there is room
+	**schedule add** *name* *days* *HH:MM* *time-zone*
+		repeats a raid every week on the *days*, as *wed,sun*, at the given time of the optional *time-zone*, as *Europe/Madrid*, creating its raids the next weeks
+	**schedule list**
+		list the raid schedules, and their *schedule-id*
+	**schedule delete** *schedule-id*
+		stops creating raids for the *schedule-id*, the raids already created are kept
+	**announce** *raid-id*
+		posts the roster of the raid, kept up to date on every signup, *members* react to it with 🛡️ 💚 ⚔️ 🏹 to sign up for that role
+	**bench** *raid-id* *member*
+		moves a signed up *member* to the bench, promoting the waitlist
+	**unbench** *raid-id* *member*
+		moves a benched or waitlisted *member* to the roster, even if the raid is full
+	**cancel** *raid-id*
+		cancel the raid indicated by the *raid-id*, notifying the signed up *members*
+	**close** *raid-id* **late** *members* **noshow** *members*
+		records the attendance of a started raid, the signed up *members* attended unless they are given as *late* or *noshow*, and the ones in the bench or the waitlist were benched. Closing it again corrects the attendance
+	**export** *raids/attendance* *format*
+		sends every raid, or the attendance of the closed raids, as a file in the *format*, *csv* unless *json* is given
+	**export roster** *raid-id* *format*
+		sends the roster of the *raid-id* as a file in the *format*, *csv* unless *json* is given
+	**export calendar**
+		sends the upcoming raids as an *iCalendar* file
 	**officer add** *discord-id*
 		add a raid officer with it *discord-id*
 	**officer delete** *discord-id*
 		delete a raid officer with it *discord-id*
 `,
-		prov.raid),
-	)
+		prov.raid)
+	cmd.Complete = prov.complete
+	prov.AddCommand(cmd)
+	prov.AddCommand(newDkpCommand(prov))
 
 	log.Info("Raid commands created", zap.Int("number of commands", len(*prov.GetCommands())))
 	return prov
@@ -1,19 +1,105 @@
 package raid
 
 import (
-	"github.com/juan-medina/cecibot/command/provider"
+	"github.com/juan-medina/cecibot/commands/raid/data/entities"
 	"github.com/juan-medina/cecibot/commands/raid/data/memory"
+	"github.com/juan-medina/cecibot/commands/raid/shared"
+	"github.com/juan-medina/cecibot/config"
 	"github.com/juan-medina/cecibot/prototype"
 	"testing"
+	"time"
 )
 
+const fakeGuild = "guild1"
+
+type fakeCfg struct {
+	timezone string
+}
+
+func (f fakeCfg) GetOwner() string {
+	return "123"
+}
+
+func (f fakeCfg) GetToken() string {
+	return "12345"
+}
+
+func (f fakeCfg) GetStorage() string {
+	return "memory"
+}
+
+func (f fakeCfg) GetStoragePath() string {
+	return ""
+}
+
+func (f fakeCfg) GetPrefix() string {
+	return ""
+}
+
+func (f fakeCfg) GetApplicationId() string {
+	return ""
+}
+
+func (f fakeCfg) GetPublicKey() string {
+	return ""
+}
+
+func (f fakeCfg) GetInteractionsAddress() string {
+	return ""
+}
+
+func (f fakeCfg) GetReminders() string {
+	return "none"
+}
+
+func (f fakeCfg) GetScheduleWeeks() string {
+	return "2"
+}
+
+func (f fakeCfg) GetLegacyGuild() string {
+	return ""
+}
+
+func (f fakeCfg) GetTimezone() string {
+	return f.timezone
+}
+
 type fakeProcessor struct {
+	cfg   fakeCfg
+	store prototype.RaidDataStore
+}
+
+func (f fakeProcessor) GetStore() prototype.RaidDataStore {
+	return f.store
 }
 
-func (f fakeProcessor) ProcessMessage(text string, author string) string {
+func (f fakeProcessor) GetPrefix(guild string) string {
 	return ""
 }
 
+func (f fakeProcessor) SetPrefix(guild string, prefix string) error {
+	return nil
+}
+
+func (f fakeProcessor) GetCommands() []*prototype.Command {
+	return nil
+}
+
+func (f fakeProcessor) Send(channel string, message prototype.Message) {
+}
+
+func (f fakeProcessor) ProcessReaction(reaction *prototype.Reaction) *prototype.Response {
+	return nil
+}
+
+func (f fakeProcessor) Complete(req *prototype.Request) []prototype.Choice {
+	return nil
+}
+
+func (f fakeProcessor) ProcessMessage(req *prototype.Request) *prototype.Response {
+	return nil
+}
+
 func (f fakeProcessor) Init(bot prototype.Bot) error {
 	return nil
 }
@@ -33,8 +119,16 @@ func (f fakeProcessor) GetHelp() string {
 	return ""
 }
 
+func (f fakeProcessor) GetHelpEmbed() *prototype.Embed {
+	return nil
+}
+
+func (f fakeProcessor) GetConfig() config.Config {
+	return f.cfg
+}
+
 func TestNew(t *testing.T) {
-	prc := fakeProcessor{}
+	prc := fakeProcessor{store: memory.New()}
 	got := New(prc)
 
 	gotCommands := got.GetCommands()
@@ -46,11 +140,15 @@ func TestNew(t *testing.T) {
 
 	gotNumCommands := len(*gotCommands)
 
-	if gotNumCommands != 1 {
-		t.Errorf("want 1 command, got %d", gotNumCommands)
+	if gotNumCommands != 2 {
+		t.Errorf("want 2 commands, got %d", gotNumCommands)
 		return
 	}
 
+	if _, found := (*gotCommands)["dkp"]; !found {
+		t.Errorf("want dkp command, got not found")
+	}
+
 	cmd, found := (*gotCommands)["raid"]
 
 	if !found {
@@ -65,15 +163,12 @@ func TestNew(t *testing.T) {
 
 func Test_raidCommands_raid(t *testing.T) {
 	prc := fakeProcessor{}
-	base := provider.New(prc)
-	data := memory.New()
-	rc := raidCommands{
-		BaseProvider: base,
-		data:         data,
-	}
+	store := memory.New()
+	data := store.Guild(fakeGuild)
+	rc := newRaidCommands(prc, store, time.Now)
 
 	t.Run("should return empty string with not sub command", func(t *testing.T) {
-		got := rc.raid([]string{}, "123")
+		got := rc.raid(newRequest([]string{}, "123", fakeGuild)).String()
 		want := ""
 		if got != want {
 			t.Errorf("want %q, got %q", want, got)
@@ -84,7 +179,7 @@ func Test_raidCommands_raid(t *testing.T) {
 	t.Run("should return officers", func(t *testing.T) {
 		data.AddOfficer("123")
 		data.AddOfficer("456")
-		got := rc.raid([]string{"officers"}, "123")
+		got := rc.raid(newRequest([]string{"officers"}, "123", fakeGuild)).String()
 		want := "raid officers:\n\t<@123>\n\t<@456>\n"
 		if got != want {
 			t.Errorf("want %q, got %q", want, got)
@@ -94,7 +189,7 @@ func Test_raidCommands_raid(t *testing.T) {
 	})
 
 	t.Run("should return empty string without officer action", func(t *testing.T) {
-		got := rc.raid([]string{"officer"}, "123")
+		got := rc.raid(newRequest([]string{"officer"}, "123", fakeGuild)).String()
 		want := ""
 		if got != want {
 			t.Errorf("want %q, got %q", want, got)
@@ -106,13 +201,13 @@ func Test_raidCommands_raid(t *testing.T) {
 		data.AddOfficer("123")
 		data.AddOfficer("456")
 
-		var got = rc.raid([]string{"officer", "delete", "456"}, "123")
+		var got = rc.raid(newRequest([]string{"officer", "delete", "456"}, "123", fakeGuild)).String()
 		var want = "officer <@456> deleted"
 		if got != want {
 			t.Errorf("want %q, got %q", want, got)
 		}
 
-		got = rc.raid([]string{"officers"}, "123")
+		got = rc.raid(newRequest([]string{"officers"}, "123", fakeGuild)).String()
 		want = "raid officers:\n\t<@123>\n"
 		if got != want {
 			t.Errorf("want %q, got %q", want, got)
@@ -123,7 +218,7 @@ func Test_raidCommands_raid(t *testing.T) {
 	})
 
 	t.Run("should return empty string deleting without id", func(t *testing.T) {
-		got := rc.raid([]string{"officer", "delete"}, "123")
+		got := rc.raid(newRequest([]string{"officer", "delete"}, "123", fakeGuild)).String()
 		want := ""
 		if got != want {
 			t.Errorf("want %q, got %q", want, got)
@@ -132,13 +227,13 @@ func Test_raidCommands_raid(t *testing.T) {
 	})
 
 	t.Run("should add an officer", func(t *testing.T) {
-		var got = rc.raid([]string{"officer", "add", "456"}, "123")
+		var got = rc.raid(newRequest([]string{"officer", "add", "456"}, "123", fakeGuild)).String()
 		var want = "officer <@456> added"
 		if got != want {
 			t.Errorf("want %q, got %q", want, got)
 		}
 
-		got = rc.raid([]string{"officers"}, "123")
+		got = rc.raid(newRequest([]string{"officers"}, "123", fakeGuild)).String()
 		want = "raid officers:\n\t<@456>\n"
 		if got != want {
 			t.Errorf("want %q, got %q", want, got)
@@ -148,7 +243,7 @@ func Test_raidCommands_raid(t *testing.T) {
 	})
 
 	t.Run("should return empty string adding without id", func(t *testing.T) {
-		got := rc.raid([]string{"officer", "add"}, "123")
+		got := rc.raid(newRequest([]string{"officer", "add"}, "123", fakeGuild)).String()
 		want := ""
 		if got != want {
 			t.Errorf("want %q, got %q", want, got)
@@ -157,13 +252,13 @@ func Test_raidCommands_raid(t *testing.T) {
 	})
 
 	t.Run("couldn't delete or add officer if is not officer", func(t *testing.T) {
-		var got = rc.raid([]string{"officer", "delete", "456"}, "231")
+		var got = rc.raid(newRequest([]string{"officer", "delete", "456"}, "231", fakeGuild)).String()
 		var want = "this command is for **officers** only"
 		if got != want {
 			t.Errorf("want %q, got %q", want, got)
 		}
 
-		got = rc.raid([]string{"officer", "add", "456"}, "231")
+		got = rc.raid(newRequest([]string{"officer", "add", "456"}, "231", fakeGuild)).String()
 		want = "this command is for **officers** only"
 		if got != want {
 			t.Errorf("want %q, got %q", want, got)
@@ -173,19 +268,19 @@ func Test_raidCommands_raid(t *testing.T) {
 	t.Run("could delete or add officer if is officer", func(t *testing.T) {
 		data.AddOfficer("456")
 
-		var got = rc.raid([]string{"officer", "add", "678"}, "456")
+		var got = rc.raid(newRequest([]string{"officer", "add", "678"}, "456", fakeGuild)).String()
 		var want = "officer <@678> added"
 		if got != want {
 			t.Errorf("want %q, got %q", want, got)
 		}
 
-		got = rc.raid([]string{"officer", "delete", "678"}, "456")
+		got = rc.raid(newRequest([]string{"officer", "delete", "678"}, "456", fakeGuild)).String()
 		want = "officer <@678> deleted"
 		if got != want {
 			t.Errorf("want %q, got %q", want, got)
 		}
 
-		got = rc.raid([]string{"officers"}, "123")
+		got = rc.raid(newRequest([]string{"officers"}, "123", fakeGuild)).String()
 		want = "raid officers:\n\t<@456>\n"
 		if got != want {
 			t.Errorf("want %q, got %q", want, got)
@@ -194,3 +289,136 @@ func Test_raidCommands_raid(t *testing.T) {
 		data.DeleteOfficer("456")
 	})
 }
+
+// userMentions are the members that discord resolves from the mentions in the arguments
+func userMentions(args []string) []string {
+	result := make([]string, 0)
+	for _, arg := range args {
+		if match := memberMention.FindStringSubmatch(arg); match != nil {
+			result = append(result, match[1])
+		}
+	}
+	return result
+}
+
+func newRequest(args []string, author string, guild string) *prototype.Request {
+	return &prototype.Request{Args: args, Author: author, Guild: guild, Mentions: userMentions(args)}
+}
+
+func addRaid(t *testing.T, data prototype.RaidDataProvider, name string, date time.Time) entities.Raid {
+	t.Helper()
+	raid, err := data.AddRaid(entities.Raid{Name: name, Date: date})
+	if err != nil {
+		t.Fatalf("want not error adding raid, got %v", err)
+	}
+	return raid
+}
+
+func Test_raidCommands_guilds(t *testing.T) {
+	prc := fakeProcessor{}
+	store := memory.New()
+	rc := newRaidCommands(prc, store, time.Now)
+
+	t.Run("raid commands need a guild", func(t *testing.T) {
+		got := rc.raid(newRequest([]string{"officers"}, "123", "")).String()
+		want := noSharedServers
+		if got != want {
+			t.Errorf("want %q, got %q", want, got)
+		}
+	})
+
+	t.Run("officers of a guild couldn't manage other guild", func(t *testing.T) {
+		_ = store.Guild("guild1").AddOfficer("456")
+
+		got := rc.raid(newRequest([]string{"officer", "add", "789"}, "456", "guild1")).String()
+		want := "officer <@789> added"
+		if got != want {
+			t.Errorf("want %q, got %q", want, got)
+		}
+
+		got = rc.raid(newRequest([]string{"officer", "add", "789"}, "456", "guild2")).String()
+		want = shared.OfficersOnly
+		if got != want {
+			t.Errorf("want %q, got %q", want, got)
+		}
+
+		got = rc.raid(newRequest([]string{"officers"}, "456", "guild2")).String()
+		want = "raid officers:\n"
+		if got != want {
+			t.Errorf("want %q, got %q", want, got)
+		}
+	})
+}
+
+func newDirectMessage(args []string, author string, guilds ...prototype.GuildRef) *prototype.Request {
+	return &prototype.Request{Args: args, Author: author, DM: true, Guilds: guilds, Mentions: userMentions(args)}
+}
+
+func Test_raidCommands_directMessages(t *testing.T) {
+	prc := fakeProcessor{}
+	store := memory.New()
+	rc := newRaidCommands(prc, store, time.Now)
+
+	_ = store.Guild("guild1").AddOfficer("456")
+	first := prototype.GuildRef{Id: "guild1", Name: "First"}
+	second := prototype.GuildRef{Id: "guild2", Name: "Second"}
+
+	tests := []struct {
+		name string
+		req  *prototype.Request
+		want string
+	}{
+		{
+			name: "should use the only shared server",
+			req:  newDirectMessage([]string{"officers"}, "456", first),
+			want: "raid officers:\n\t<@456>\n",
+		},
+		{
+			name: "should ask which server to use",
+			req:  newDirectMessage([]string{"officers"}, "456", first, second),
+			want: chooseServer + "\t**1** : First\n\t**2** : Second\n",
+		},
+		{
+			name: "should list the shared servers",
+			req:  newDirectMessage([]string{"server"}, "456", first, second),
+			want: "servers that we share :\n\t**1** : First\n\t**2** : Second\n",
+		},
+		{
+			name: "should not select an invalid server",
+			req:  newDirectMessage([]string{"server", "3"}, "456", first, second),
+			want: "invalid server **3**, choose one of :\n\t**1** : First\n\t**2** : Second\n",
+		},
+		{
+			name: "should select a server",
+			req:  newDirectMessage([]string{"server", "2"}, "456", first, second),
+			want: "raid commands in direct messages will be sent to **Second**",
+		},
+		{
+			name: "should use the selected server",
+			req:  newDirectMessage([]string{"officer", "add", "789"}, "456", first, second),
+			want: shared.OfficersOnly,
+		},
+		{
+			name: "should show the selected server",
+			req:  newDirectMessage([]string{"server"}, "456", first, second),
+			want: "servers that we share :\n\t**1** : First\n\t**2** : Second *(selected)*\n",
+		},
+		{
+			name: "should ask again if the selected server is not shared anymore",
+			req:  newDirectMessage([]string{"officers"}, "456", first, prototype.GuildRef{Id: "guild3", Name: "Third"}),
+			want: chooseServer + "\t**1** : First\n\t**2** : Third\n",
+		},
+		{
+			name: "should not select servers outside direct messages",
+			req:  newRequest([]string{"server", "1"}, "456", "guild1"),
+			want: directMessagesOnly,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rc.raid(tt.req).String(); got != tt.want {
+				t.Errorf("want %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package raid
+
+import (
+	"fmt"
+	"github.com/juan-medina/cecibot/command"
+	"github.com/juan-medina/cecibot/commands/raid/data/entities"
+	"github.com/juan-medina/cecibot/commands/raid/shared"
+	"github.com/juan-medina/cecibot/message"
+	"github.com/juan-medina/cecibot/prototype"
+	"strings"
+	"time"
+)
+
+const raidDateFormat = "Mon 02 Jan 2006 15:04"
+
+var raidDateLayouts = []string{
+	"2006-01-02 15:04",
+	"2006-01-02T15:04",
+	"02/01/2006 15:04",
+	"2006-01-02",
+	"02/01/2006",
+}
+
+var raidTimeLayouts = []string{
+	"15:04",
+	"3:04pm",
+	"3pm",
+}
+
+const invalidRaidDate = "invalid date %q, use the format *YYYY-MM-DD HH:MM*, *tomorrow HH:MM* or a week day as *wed HH:MM*"
+
+func parseRaidTime(text string) (hour int, minute int, ok bool) {
+	for _, layout := range raidTimeLayouts {
+		if at, err := time.Parse(layout, strings.ToLower(text)); err == nil {
+			return at.Hour(), at.Minute(), true
+		}
+	}
+	return 0, 0, false
+}
+
+// parseRaidDate reads a date in the given time zone, as an absolute date, today, tomorrow or the next week day,
+// followed by an optional time
+func parseRaidDate(text string, now time.Time, loc *time.Location) (time.Time, error) {
+	for _, layout := range raidDateLayouts {
+		if date, err := time.ParseInLocation(layout, text, loc); err == nil {
+			return date, nil
+		}
+	}
+
+	fields := strings.Fields(strings.ToLower(text))
+	if len(fields) == 0 || len(fields) > 2 {
+		return time.Time{}, fmt.Errorf(invalidRaidDate, text)
+	}
+
+	hour, minute := 0, 0
+	if len(fields) == 2 {
+		var ok bool
+		if hour, minute, ok = parseRaidTime(fields[1]); !ok {
+			return time.Time{}, fmt.Errorf(invalidRaidDate, text)
+		}
+	}
+
+	today := now.In(loc)
+	at := func(days int) time.Time {
+		return time.Date(today.Year(), today.Month(), today.Day()+days, hour, minute, 0, 0, loc)
+	}
+
+	switch fields[0] {
+	case "today":
+		return at(0), nil
+	case "tomorrow":
+		return at(1), nil
+	}
+
+	weekday, found := parseWeekday(fields[0])
+	if !found {
+		return time.Time{}, fmt.Errorf(invalidRaidDate, text)
+	}
+	date := at((int(weekday) - int(today.Weekday()) + 7) % 7)
+	if !date.After(now) {
+		date = date.AddDate(0, 0, 7)
+	}
+	return date, nil
+}
+
+func (d *raidCommands) createRaid(data prototype.RaidDataProvider, req *prototype.Request, args []string) string {
+	args, size, limits, err := parseLimits(args)
+	if err != nil {
+		return err.Error()
+	}
+
+	argc := len(args)
+	if argc > 1 {
+		name := args[0]
+		loc := d.memberLocation(req.Author)
+		now := d.now()
+		date, err := parseRaidDate(strings.Join(args[1:], " "), now, loc)
+		if err != nil {
+			return err.Error()
+		}
+		if !date.After(now) {
+			return fmt.Sprintf("the date %s has already passed, use a date in the future", formatDate(date, loc))
+		}
+		raid, err := data.AddRaid(entities.Raid{Name: name, Date: date, Size: size, Limits: limits})
+		if err != nil {
+			return shared.Failure(err, "")
+		}
+		result := fmt.Sprintf("raid **%s** on %s created with raid-id **%s**", raid.Name, formatDate(raid.Date, loc), raid.Id)
+		if size > 0 || len(limits) > 0 {
+			result += ", " + describeLimits(raid)
+		}
+		return result
+	}
+
+	return ""
+}
+
+func (d *raidCommands) listRaids(data prototype.RaidDataProvider, req *prototype.Request, args []string) string {
+	raids, err := data.GetRaids()
+	if err != nil {
+		return shared.Failure(err, "")
+	}
+
+	now := d.now()
+	loc := d.memberLocation(req.Author)
+	result := ""
+	for _, raid := range raids {
+		if raid.Cancelled || raid.Date.Before(now) {
+			continue
+		}
+		result += fmt.Sprintf("\t**%s** : %s, %s\n", raid.Id, raid.Name, formatDate(raid.Date, loc))
+	}
+
+	if result == "" {
+		return "there are no raids scheduled"
+	}
+
+	return "next raids:\n" + result
+}
+
+func (d *raidCommands) cancelRaid(data prototype.RaidDataProvider, req *prototype.Request, args []string) *prototype.Response {
+	argc := len(args)
+	if argc > 0 {
+		raid, err := data.GetRaid(args[0])
+		if err != nil {
+			return command.Text(fmt.Sprintf(shared.RaidNotFound, args[0]))
+		}
+		if raid.Cancelled {
+			return command.Text(fmt.Sprintf("raid %s is already cancelled", shared.RaidTitle(raid)))
+		}
+
+		raid.Cancelled = true
+		if err := data.UpdateRaid(raid); err != nil {
+			return command.Text(shared.Failure(err, raid.Id))
+		}
+
+		result := fmt.Sprintf("raid %s on %s has been cancelled", shared.RaidTitle(raid), message.Timestamp(raid.Date))
+		signups, _ := data.GetSignups(raid.Id)
+		if len(signups) > 0 {
+			mentions := make([]string, 0, len(signups))
+			for _, signup := range signups {
+				mentions = append(mentions, fmt.Sprintf("<@%s>", signup.Member))
+			}
+			result += "\nsigned up members: " + strings.Join(mentions, " ")
+		}
+
+		return d.withRosterUpdate(data, raid.Id, command.Text(result))
+	}
+
+	return command.Text("")
+}
@@ -0,0 +1,296 @@
+package raid
+
+import (
+	"github.com/juan-medina/cecibot/commands/raid/data/entities"
+	"github.com/juan-medina/cecibot/commands/raid/data/memory"
+	"github.com/juan-medina/cecibot/commands/raid/shared"
+	"github.com/juan-medina/cecibot/message"
+	"testing"
+	"time"
+)
+
+func fakeNow() time.Time {
+	return time.Date(2019, 11, 1, 12, 0, 0, 0, time.Local)
+}
+
+func Test_parseRaidDate(t *testing.T) {
+	type testCase struct {
+		name    string
+		text    string
+		want    time.Time
+		wantErr bool
+	}
+
+	cases := []testCase{
+		{
+			name: "date and time",
+			text: "2019-11-20 20:00",
+			want: time.Date(2019, 11, 20, 20, 0, 0, 0, time.Local),
+		},
+		{
+			name: "iso date and time",
+			text: "2019-11-20T20:30",
+			want: time.Date(2019, 11, 20, 20, 30, 0, 0, time.Local),
+		},
+		{
+			name: "european date and time",
+			text: "20/11/2019 21:00",
+			want: time.Date(2019, 11, 20, 21, 0, 0, 0, time.Local),
+		},
+		{
+			name: "only date",
+			text: "2019-11-20",
+			want: time.Date(2019, 11, 20, 0, 0, 0, 0, time.Local),
+		},
+		{
+			name: "tomorrow",
+			text: "tomorrow 20:00",
+			want: time.Date(2019, 11, 2, 20, 0, 0, 0, time.Local),
+		},
+		{
+			name: "today with am/pm time",
+			text: "Today 8:30pm",
+			want: time.Date(2019, 11, 1, 20, 30, 0, 0, time.Local),
+		},
+		{
+			name: "next week day",
+			text: "wed 20:00",
+			want: time.Date(2019, 11, 6, 20, 0, 0, 0, time.Local),
+		},
+		{
+			name: "same week day later today",
+			text: "friday 20:00",
+			want: time.Date(2019, 11, 1, 20, 0, 0, 0, time.Local),
+		},
+		{
+			name: "same week day already passed",
+			text: "fri 10:00",
+			want: time.Date(2019, 11, 8, 10, 0, 0, 0, time.Local),
+		},
+		{
+			name:    "invalid date",
+			text:    "next wednesday",
+			wantErr: true,
+		},
+		{
+			name:    "invalid time",
+			text:    "tomorrow 25:00",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseRaidDate(tt.text, fakeNow(), time.Local)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("want error %v, got %v", tt.wantErr, err)
+				return
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("want %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func Test_parseRaidDate_location(t *testing.T) {
+	madrid, err := time.LoadLocation("Europe/Madrid")
+	if err != nil {
+		t.Fatalf("want not error, got %v", err)
+	}
+	now := time.Date(2019, 11, 1, 23, 30, 0, 0, time.UTC)
+
+	cases := map[string]time.Time{
+		"2019-11-20 20:00": time.Date(2019, 11, 20, 20, 0, 0, 0, madrid),
+		"today 21:00":      time.Date(2019, 11, 2, 21, 0, 0, 0, madrid),
+		"tomorrow 21:00":   time.Date(2019, 11, 3, 21, 0, 0, 0, madrid),
+		"sat 21:00":        time.Date(2019, 11, 2, 21, 0, 0, 0, madrid),
+	}
+	for text, want := range cases {
+		got, err := parseRaidDate(text, now, madrid)
+		if err != nil || !got.Equal(want) {
+			t.Errorf("want %v for %q, got %v, %v", want, text, got, err)
+		}
+	}
+}
+
+func Test_raidCommands_createAndList(t *testing.T) {
+	prc := fakeProcessor{}
+	store := memory.New()
+	rc := newRaidCommands(prc, store, fakeNow)
+
+	t.Run("there are no raids", func(t *testing.T) {
+		got := rc.raid(newRequest([]string{"list"}, "456", fakeGuild)).String()
+		want := "there are no raids scheduled"
+		if got != want {
+			t.Errorf("want %q, got %q", want, got)
+		}
+	})
+
+	t.Run("members couldn't create raids", func(t *testing.T) {
+		got := rc.raid(newRequest([]string{"create", "Molten Core", "2019-11-20", "20:00"}, "456", fakeGuild)).String()
+		want := shared.OfficersOnly
+		if got != want {
+			t.Errorf("want %q, got %q", want, got)
+		}
+	})
+
+	t.Run("should return empty string creating without date", func(t *testing.T) {
+		got := rc.raid(newRequest([]string{"create", "Molten Core"}, "123", fakeGuild)).String()
+		want := ""
+		if got != want {
+			t.Errorf("want %q, got %q", want, got)
+		}
+	})
+
+	t.Run("should fail with an invalid date", func(t *testing.T) {
+		got := rc.raid(newRequest([]string{"create", "Molten Core", "someday"}, "123", fakeGuild)).String()
+		want := "invalid date \"someday\", use the format *YYYY-MM-DD HH:MM*, *tomorrow HH:MM* or a week day as *wed HH:MM*"
+		if got != want {
+			t.Errorf("want %q, got %q", want, got)
+		}
+	})
+
+	t.Run("should create raids", func(t *testing.T) {
+		got := rc.raid(newRequest([]string{"create", "Molten Core", "2019-11-20", "20:00"}, "123", fakeGuild)).String()
+		want := "raid **Molten Core** on Wed 20 Nov 2019 20:00 created with raid-id **1**"
+		if got != want {
+			t.Errorf("want %q, got %q", want, got)
+		}
+
+		got = rc.raid(newRequest([]string{"create", "Onyxia's Lair", "2019-11-18 21:00"}, "123", fakeGuild)).String()
+		want = "raid **Onyxia's Lair** on Mon 18 Nov 2019 21:00 created with raid-id **2**"
+		if got != want {
+			t.Errorf("want %q, got %q", want, got)
+		}
+	})
+
+	t.Run("should not create raids in the past", func(t *testing.T) {
+		cases := []struct {
+			args []string
+			want string
+		}{
+			{
+				args: []string{"create", "Zul'Gurub", "2019-10-01 20:00"},
+				want: "the date Tue 01 Oct 2019 20:00 has already passed, use a date in the future",
+			},
+			{
+				args: []string{"create", "Zul'Gurub", "today", "10:00"},
+				want: "the date Fri 01 Nov 2019 10:00 has already passed, use a date in the future",
+			},
+			{
+				args: []string{"create", "Zul'Gurub", "today"},
+				want: "the date Fri 01 Nov 2019 00:00 has already passed, use a date in the future",
+			},
+		}
+		for _, tt := range cases {
+			if got := rc.raid(newRequest(tt.args, "123", fakeGuild)).String(); got != tt.want {
+				t.Errorf("want %q, got %q", tt.want, got)
+			}
+		}
+	})
+
+	t.Run("should list upcoming raids sorted by date", func(t *testing.T) {
+		got := rc.raid(newRequest([]string{"list"}, "456", fakeGuild)).String()
+		want := "next raids:\n" +
+			"\t**2** : Onyxia's Lair, Mon 18 Nov 2019 21:00\n" +
+			"\t**1** : Molten Core, Wed 20 Nov 2019 20:00\n"
+		if got != want {
+			t.Errorf("want %q, got %q", want, got)
+		}
+	})
+}
+
+func Test_raidCommands_cancel(t *testing.T) {
+	prc := fakeProcessor{}
+	store := memory.New()
+	data := store.Guild(fakeGuild)
+	rc := newRaidCommands(prc, store, fakeNow)
+
+	mc := addRaid(t, data, "Molten Core", time.Date(2019, 11, 20, 20, 0, 0, 0, time.Local))
+	ony := addRaid(t, data, "Onyxia's Lair", time.Date(2019, 11, 18, 21, 0, 0, 0, time.Local))
+	_ = data.SignUp(mc.Id, entities.Signup{Member: "456", Char: "Thrall", Class: "Shaman", Spec: "Restoration"})
+	_ = data.SignUp(mc.Id, entities.Signup{Member: "789", Char: "Brox", Class: "Warrior", Spec: "Protection"})
+
+	type testCase struct {
+		name   string
+		args   []string
+		author string
+		want   string
+	}
+
+	cases := []testCase{
+		{
+			name:   "members couldn't cancel raids",
+			args:   []string{"cancels", mc.Id},
+			author: "456",
+			want:   shared.OfficersOnly,
+		},
+		{
+			name:   "should return empty string cancelling without raid",
+			args:   []string{"cancels"},
+			author: "123",
+			want:   "",
+		},
+		{
+			name:   "should fail cancelling an unknown raid",
+			args:   []string{"cancels", "99"},
+			author: "123",
+			want:   "raid **99** not found",
+		},
+		{
+			name:   "should cancel a raid mentioning the signed up members",
+			args:   []string{"cancels", mc.Id},
+			author: "123",
+			want:   "raid **Molten Core** (**1**) on " + message.Timestamp(mc.Date) + " has been cancelled\nsigned up members: <@456> <@789>",
+		},
+		{
+			name:   "should not cancel twice",
+			args:   []string{"cancel", mc.Id},
+			author: "123",
+			want:   "raid **Molten Core** (**1**) is already cancelled",
+		},
+		{
+			name:   "cancelled raids are not listed",
+			args:   []string{"list"},
+			author: "456",
+			want:   "next raids:\n\t**2** : Onyxia's Lair, Mon 18 Nov 2019 21:00\n",
+		},
+		{
+			name:   "couldn't sign up to cancelled raids",
+			args:   []string{"sign", "up", mc.Id, "Rexxar", "hunter", "survival"},
+			author: "321",
+			want:   "raid **Molten Core** (**1**) has been cancelled",
+		},
+		{
+			name:   "should cancel a raid without signups",
+			args:   []string{"cancel", ony.Id},
+			author: "123",
+			want:   "raid **Onyxia's Lair** (**2**) on " + message.Timestamp(ony.Date) + " has been cancelled",
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			got := rc.raid(newRequest(tt.args, tt.author, fakeGuild)).String()
+			if got != tt.want {
+				t.Errorf("want %q, got %q", tt.want, got)
+			}
+		})
+	}
+
+	t.Run("cancelled raids are kept", func(t *testing.T) {
+		got, err := data.GetRaid(mc.Id)
+		if err != nil {
+			t.Errorf("want not error, got %v", err)
+			return
+		}
+		if !got.Cancelled {
+			t.Errorf("want raid cancelled, got %v", got)
+		}
+		signups, _ := data.GetSignups(mc.Id)
+		if len(signups) != 2 {
+			t.Errorf("want 2 signups, got %d", len(signups))
+		}
+	})
+}
@@ -0,0 +1,192 @@
+package raid
+
+import (
+	"fmt"
+	"github.com/juan-medina/cecibot/command"
+	"github.com/juan-medina/cecibot/commands/raid/classes"
+	"github.com/juan-medina/cecibot/commands/raid/data/entities"
+	"github.com/juan-medina/cecibot/commands/raid/shared"
+	"github.com/juan-medina/cecibot/message"
+	"github.com/juan-medina/cecibot/prototype"
+	"sort"
+	"strings"
+)
+
+const rosterColour = 0x2ecc71
+const cancelledColour = 0xe74c3c
+
+type rosterRole struct {
+	role    classes.Role
+	signups []entities.Signup
+}
+
+func roleOf(signup entities.Signup) classes.Role {
+	if signup.Role != "" {
+		return classes.Role(signup.Role)
+	}
+	return classes.RoleOf(signup.Class, signup.Spec)
+}
+
+func rosterLine(signup entities.Signup) string {
+	parts := make([]string, 0, 4)
+	if signup.Char != "" {
+		parts = append(parts, "**"+signup.Char+"**")
+	}
+	if signup.Class != "" {
+		parts = append(parts, "*"+signup.Class+"*", "*"+signup.Spec+"*")
+	}
+	return strings.Join(append(parts, fmt.Sprintf("<@%s>", signup.Member)), " ")
+}
+
+func groupByRole(signups []entities.Signup) []rosterRole {
+	result := make([]rosterRole, 0, len(classes.Roles))
+	for _, role := range classes.Roles {
+		group := rosterRole{role: role, signups: make([]entities.Signup, 0)}
+		for _, signup := range signups {
+			if roleOf(signup) == role {
+				group.signups = append(group.signups, signup)
+			}
+		}
+		result = append(result, group)
+	}
+	return result
+}
+
+func countByClass(signups []entities.Signup) string {
+	counts := make(map[string]int)
+	for _, signup := range signups {
+		if signup.Class != "" {
+			counts[signup.Class]++
+		}
+	}
+
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	result := make([]string, 0, len(names))
+	for _, name := range names {
+		result = append(result, fmt.Sprintf("%s %d", name, counts[name]))
+	}
+	return strings.Join(result, ", ")
+}
+
+func byStatus(signups []entities.Signup, status string) []entities.Signup {
+	result := make([]entities.Signup, 0)
+	for _, signup := range signups {
+		if signup.Status == status {
+			result = append(result, signup)
+		}
+	}
+	return result
+}
+
+// listFields puts the lines in as many fields as needed to keep them under the discord limit, the ones after the
+// first are named as a continuation
+func listFields(name string, lines []string, inline bool) []prototype.EmbedField {
+	result := make([]prototype.EmbedField, 0, 1)
+	value := ""
+	for _, line := range lines {
+		if value != "" && len(value)+len("\n")+len(line) > message.MaxFieldLength {
+			result = append(result, prototype.EmbedField{Name: name, Value: value, Inline: inline})
+			name = strings.SplitN(name, " (", 2)[0] + " (cont.)"
+			value = ""
+		}
+		if value != "" {
+			value += "\n"
+		}
+		value += line
+	}
+	return append(result, prototype.EmbedField{Name: name, Value: value, Inline: inline})
+}
+
+func rosterEmbed(raid entities.Raid, signups []entities.Signup) *prototype.Embed {
+	confirmed := byStatus(signups, entities.SignupConfirmed)
+	embed := &prototype.Embed{
+		Title:       fmt.Sprintf("%s (%s)", raid.Name, raid.Id),
+		Description: message.Timestamp(raid.Date),
+		Colour:      rosterColour,
+		Fields:      make([]prototype.EmbedField, 0),
+		Footer:      fmt.Sprintf("Total : %d", len(confirmed)),
+	}
+	if raid.Size > 0 {
+		embed.Footer += fmt.Sprintf("/%d", raid.Size)
+	}
+	if raid.Cancelled {
+		embed.Description += "\n*this raid has been cancelled*"
+		embed.Colour = cancelledColour
+	}
+
+	for _, group := range groupByRole(confirmed) {
+		lines := make([]string, 0, len(group.signups))
+		for _, signup := range group.signups {
+			lines = append(lines, rosterLine(signup))
+		}
+		if len(lines) == 0 {
+			lines = append(lines, "-")
+		}
+		name := fmt.Sprintf("%s (%d)", group.role, len(group.signups))
+		if limit := raid.Limits[string(group.role)]; limit > 0 {
+			name = fmt.Sprintf("%s (%d/%d)", group.role, len(group.signups), limit)
+		}
+		embed.Fields = append(embed.Fields, listFields(name, lines, true)...)
+	}
+	if summary := countByClass(confirmed); summary != "" {
+		embed.Fields = append(embed.Fields, prototype.EmbedField{Name: "Classes", Value: summary})
+	}
+
+	for _, list := range []struct {
+		name   string
+		status string
+	}{{"Waitlist", entities.SignupWaitlisted}, {"Bench", entities.SignupBenched}} {
+		lines := make([]string, 0)
+		for i, signup := range byStatus(signups, list.status) {
+			lines = append(lines, fmt.Sprintf("%d. %s", i+1, rosterLine(signup)))
+		}
+		if len(lines) > 0 {
+			embed.Fields = append(embed.Fields, listFields(list.name, lines, false)...)
+		}
+	}
+
+	return embed
+}
+
+func (d *raidCommands) roster(data prototype.RaidDataProvider, req *prototype.Request, args []string) *prototype.Response {
+	argc := len(args)
+	if argc > 0 {
+		raid, err := data.GetRaid(args[0])
+		if err != nil {
+			return command.Text(shared.Failure(err, args[0]))
+		}
+
+		signups, err := data.GetSignups(raid.Id)
+		if err != nil {
+			return command.Text(shared.Failure(err, raid.Id))
+		}
+
+		return command.Embed(rosterEmbed(raid, signups))
+	}
+
+	return command.Text("")
+}
+
+func (d *raidCommands) withRosterUpdate(data prototype.RaidDataProvider, raidId string, response *prototype.Response) *prototype.Response {
+	raid, err := data.GetRaid(raidId)
+	if err != nil || raid.Message == "" {
+		return response
+	}
+
+	signups, err := data.GetSignups(raid.Id)
+	if err != nil {
+		return response
+	}
+
+	response.Edits = append(response.Edits, prototype.Edit{
+		Channel:   raid.Channel,
+		MessageId: raid.Message,
+		Message:   prototype.Message{Embed: announcementEmbed(raid, signups)},
+	})
+	return response
+}
@@ -0,0 +1,169 @@
+package raid
+
+import (
+	"fmt"
+	"github.com/juan-medina/cecibot/commands/raid/data/entities"
+	"github.com/juan-medina/cecibot/commands/raid/data/memory"
+	"github.com/juan-medina/cecibot/message"
+	"github.com/juan-medina/cecibot/prototype"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_raidCommands_roster(t *testing.T) {
+	prc := fakeProcessor{}
+	store := memory.New()
+	data := store.Guild(fakeGuild)
+	rc := newRaidCommands(prc, store, fakeNow)
+
+	raid := addRaid(t, data, "Molten Core", time.Date(2019, 11, 20, 20, 0, 0, 0, time.Local))
+
+	t.Run("should return empty string without raid", func(t *testing.T) {
+		got := rc.raid(newRequest([]string{"roster"}, "456", fakeGuild)).String()
+		want := ""
+		if got != want {
+			t.Errorf("want %q, got %q", want, got)
+		}
+	})
+
+	t.Run("should fail with an unknown raid", func(t *testing.T) {
+		got := rc.raid(newRequest([]string{"roster", "99"}, "456", fakeGuild)).String()
+		want := "raid **99** not found"
+		if got != want {
+			t.Errorf("want %q, got %q", want, got)
+		}
+	})
+
+	t.Run("should show an empty roster", func(t *testing.T) {
+		got := rc.raid(newRequest([]string{"roster", raid.Id}, "456", fakeGuild))
+		want := &prototype.Embed{
+			Title:       "Molten Core (1)",
+			Description: message.Timestamp(time.Date(2019, 11, 20, 20, 0, 0, 0, time.Local)),
+			Colour:      rosterColour,
+			Fields: []prototype.EmbedField{
+				{Name: "Tank (0)", Value: "-", Inline: true},
+				{Name: "Healer (0)", Value: "-", Inline: true},
+				{Name: "Melee (0)", Value: "-", Inline: true},
+				{Name: "Ranged (0)", Value: "-", Inline: true},
+			},
+			Footer: "Total : 0",
+		}
+		assertEmbed(t, got, want)
+	})
+
+	_ = data.SignUp(raid.Id, entities.Signup{Member: "1", Char: "Thrall", Class: "Shaman", Spec: "Restoration"})
+	_ = data.SignUp(raid.Id, entities.Signup{Member: "2", Char: "Brox", Class: "Warrior", Spec: "Protection"})
+	_ = data.SignUp(raid.Id, entities.Signup{Member: "3", Char: "Rexxar", Class: "Hunter", Spec: "Marksmanship"})
+	_ = data.SignUp(raid.Id, entities.Signup{Member: "4", Char: "Garona", Class: "Rogue", Spec: "Combat"})
+	_ = data.SignUp(raid.Id, entities.Signup{Member: "5", Char: "Jaina", Class: "Mage", Spec: "Frost"})
+	_ = data.SignUp(raid.Id, entities.Signup{Member: "6", Char: "Drektar", Class: "Shaman", Spec: "Enhancement"})
+
+	want := &prototype.Embed{
+		Title:       "Molten Core (1)",
+		Description: message.Timestamp(time.Date(2019, 11, 20, 20, 0, 0, 0, time.Local)),
+		Colour:      rosterColour,
+		Fields: []prototype.EmbedField{
+			{Name: "Tank (1)", Value: "**Brox** *Warrior* *Protection* <@2>", Inline: true},
+			{Name: "Healer (1)", Value: "**Thrall** *Shaman* *Restoration* <@1>", Inline: true},
+			{Name: "Melee (2)", Value: "**Garona** *Rogue* *Combat* <@4>\n**Drektar** *Shaman* *Enhancement* <@6>", Inline: true},
+			{Name: "Ranged (2)", Value: "**Rexxar** *Hunter* *Marksmanship* <@3>\n**Jaina** *Mage* *Frost* <@5>", Inline: true},
+			{Name: "Classes", Value: "Hunter 1, Mage 1, Rogue 1, Shaman 2, Warrior 1"},
+		},
+		Footer: "Total : 6",
+	}
+
+	t.Run("should show the roster grouped by role", func(t *testing.T) {
+		got := rc.raid(newRequest([]string{"roster", raid.Id}, "456", fakeGuild))
+		assertEmbed(t, got, want)
+	})
+
+	t.Run("should accept the rooster alias", func(t *testing.T) {
+		got := rc.raid(newRequest([]string{"rooster", raid.Id}, "456", fakeGuild))
+		assertEmbed(t, got, want)
+	})
+
+	t.Run("should show signups with only a role", func(t *testing.T) {
+		other := addRaid(t, data, "Onyxia's Lair", time.Date(2019, 11, 21, 20, 0, 0, 0, time.Local))
+		_ = data.SignUp(other.Id, entities.Signup{Member: "1", Char: "Thrall", Role: "Healer"})
+		_ = data.SignUp(other.Id, entities.Signup{Member: "2", Char: "Brox", Class: "Warrior", Spec: "Fury", Role: "Ranged"})
+
+		got := rc.raid(newRequest([]string{"roster", other.Id}, "456", fakeGuild))
+		want := &prototype.Embed{
+			Title:       "Onyxia's Lair (2)",
+			Description: message.Timestamp(time.Date(2019, 11, 21, 20, 0, 0, 0, time.Local)),
+			Colour:      rosterColour,
+			Fields: []prototype.EmbedField{
+				{Name: "Tank (0)", Value: "-", Inline: true},
+				{Name: "Healer (1)", Value: "**Thrall** <@1>", Inline: true},
+				{Name: "Melee (0)", Value: "-", Inline: true},
+				{Name: "Ranged (1)", Value: "**Brox** *Warrior* *Fury* <@2>", Inline: true},
+				{Name: "Classes", Value: "Warrior 1"},
+			},
+			Footer: "Total : 2",
+		}
+		assertEmbed(t, got, want)
+	})
+
+	t.Run("should show cancelled raids", func(t *testing.T) {
+		raid.Cancelled = true
+		_ = data.UpdateRaid(raid)
+
+		got := rc.raid(newRequest([]string{"roster", raid.Id}, "456", fakeGuild))
+		cancelled := *want
+		cancelled.Description += "\n*this raid has been cancelled*"
+		cancelled.Colour = cancelledColour
+		assertEmbed(t, got, &cancelled)
+	})
+}
+
+func assertEmbed(t *testing.T, got *prototype.Response, want *prototype.Embed) {
+	t.Helper()
+	if len(got.Messages) != 1 || got.Messages[0].Embed == nil {
+		t.Errorf("want an embed response, got %+v", got)
+		return
+	}
+	if !reflect.DeepEqual(got.Messages[0].Embed, want) {
+		t.Errorf("want embed %+v, got %+v", want, got.Messages[0].Embed)
+	}
+}
+
+func Test_rosterEmbed_fullRaid(t *testing.T) {
+	raid := entities.Raid{Id: "1", Name: "Molten Core", Date: time.Date(2019, 11, 20, 20, 0, 0, 0, time.Local), Size: 40}
+
+	signups := make([]entities.Signup, 0)
+	signUp := func(count int, class string, spec string, status string) {
+		for i := 0; i < count; i++ {
+			member := fmt.Sprintf("1000000000000000%02d", len(signups))
+			signups = append(signups, entities.Signup{Member: member, Char: "Longcharactername", Class: class, Spec: spec, Status: status})
+		}
+	}
+	signUp(4, "Warrior", "Protection", entities.SignupConfirmed)
+	signUp(8, "Priest", "Holy", entities.SignupConfirmed)
+	signUp(10, "Rogue", "Combat", entities.SignupConfirmed)
+	signUp(18, "Mage", "Arcane", entities.SignupConfirmed)
+	signUp(20, "Mage", "Frost", entities.SignupWaitlisted)
+
+	embed := rosterEmbed(raid, signups)
+
+	names := make([]string, 0)
+	all := ""
+	for _, field := range embed.Fields {
+		names = append(names, field.Name)
+		all += field.Value + "\n"
+		if len(field.Value) > message.MaxFieldLength {
+			t.Errorf("want field %q up to %d characters, got %d", field.Name, message.MaxFieldLength, len(field.Value))
+		}
+	}
+	for _, signup := range signups {
+		if !strings.Contains(all, "<@"+signup.Member+">") {
+			t.Errorf("want member %s in the roster, got not found", signup.Member)
+		}
+	}
+
+	want := []string{"Tank (4)", "Healer (8)", "Melee (10)", "Ranged (18)", "Ranged (cont.)", "Classes", "Waitlist", "Waitlist (cont.)"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("want fields %v, got %v", want, names)
+	}
+}
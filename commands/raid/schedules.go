@@ -0,0 +1,156 @@
+package raid
+
+import (
+	"fmt"
+	"github.com/juan-medina/cecibot/commands/raid/data/entities"
+	"github.com/juan-medina/cecibot/commands/raid/shared"
+	"github.com/juan-medina/cecibot/prototype"
+	"github.com/juan-medina/cecibot/scheduler"
+	"strings"
+	"time"
+)
+
+const scheduleNotFound = "schedule **%s** not found"
+
+var weekdays = []time.Weekday{
+	time.Sunday, time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday,
+}
+
+// parseWeekday reads a week day by its name or its first three letters at least
+func parseWeekday(text string) (time.Weekday, bool) {
+	value := strings.ToLower(strings.TrimSpace(text))
+	for _, day := range weekdays {
+		if len(value) >= 3 && strings.HasPrefix(strings.ToLower(day.String()), value) {
+			return day, true
+		}
+	}
+	return time.Sunday, false
+}
+
+// parseDays reads comma separated week days, as wed,sun or wednesday,sunday
+func parseDays(text string) ([]time.Weekday, error) {
+	result := make([]time.Weekday, 0)
+	for _, value := range strings.Split(text, ",") {
+		day, found := parseWeekday(value)
+		if !found {
+			return nil, fmt.Errorf("invalid day %q, use week days as *wed,sun*", strings.ToLower(strings.TrimSpace(value)))
+		}
+		result = append(result, day)
+	}
+	return result, nil
+}
+
+func formatDays(days []time.Weekday) string {
+	names := make([]string, 0, len(days))
+	for _, day := range days {
+		names = append(names, day.String()[:3])
+	}
+	return strings.Join(names, ", ")
+}
+
+func describeSchedule(schedule entities.Schedule) string {
+	result := fmt.Sprintf("**%s** every %s at %02d:%02d", schedule.Name, formatDays(schedule.Days), schedule.Hour, schedule.Minute)
+	if schedule.Location != "" {
+		result += " " + schedule.Location
+	}
+	return result
+}
+
+func (d *raidCommands) addSchedule(data prototype.RaidDataProvider, req *prototype.Request, args []string) string {
+	argc := len(args)
+	if argc > 2 {
+		days, err := parseDays(args[1])
+		if err != nil {
+			return err.Error()
+		}
+
+		hour, minute, ok := parseRaidTime(args[2])
+		if !ok {
+			return fmt.Sprintf("invalid time %q, use the format *HH:MM*", args[2])
+		}
+
+		schedule := entities.Schedule{
+			Name:     args[0],
+			Days:     days,
+			Hour:     hour,
+			Minute:   minute,
+			Location: d.GetProcessor().GetConfig().GetTimezone(),
+		}
+		if argc > 3 {
+			schedule.Location = args[3]
+			if _, err := scheduler.Location(schedule); err != nil {
+				return fmt.Sprintf("invalid time zone %q, use a name as *Europe/Madrid*", args[3])
+			}
+		}
+
+		weeks, err := scheduler.ParseWeeks(d.GetProcessor().GetConfig().GetScheduleWeeks())
+		if err != nil {
+			return err.Error()
+		}
+
+		schedule, err = data.AddSchedule(schedule)
+		if err != nil {
+			return shared.Failure(err, "")
+		}
+
+		result := fmt.Sprintf("schedule %s created with schedule-id **%s**", describeSchedule(schedule), schedule.Id)
+		raids, err := scheduler.Materialize(data, schedule, d.now(), weeks)
+		if err != nil {
+			return result + "\n" + shared.Failure(err, "")
+		}
+		loc := d.memberLocation(req.Author)
+		for _, raid := range raids {
+			result += fmt.Sprintf("\n\traid-id **%s** : %s, %s", raid.Id, raid.Name, formatDate(raid.Date, loc))
+		}
+		return result
+	}
+
+	return ""
+}
+
+func (d *raidCommands) listSchedules(data prototype.RaidDataProvider) string {
+	schedules, err := data.GetSchedules()
+	if err != nil {
+		return shared.Failure(err, "")
+	}
+
+	if len(schedules) == 0 {
+		return "there are no raid schedules"
+	}
+
+	result := "raid schedules:\n"
+	for _, schedule := range schedules {
+		result += fmt.Sprintf("\t**%s** : %s\n", schedule.Id, describeSchedule(schedule))
+	}
+	return result
+}
+
+func (d *raidCommands) deleteSchedule(data prototype.RaidDataProvider, args []string) string {
+	argc := len(args)
+	if argc > 0 {
+		id := args[0]
+		if err := data.DeleteSchedule(id); err == entities.ErrScheduleNotFound {
+			return fmt.Sprintf(scheduleNotFound, id)
+		} else if err != nil {
+			return shared.Failure(err, "")
+		}
+		return fmt.Sprintf("schedule **%s** deleted, the raids already created are kept", id)
+	}
+
+	return ""
+}
+
+func (d *raidCommands) schedule(data prototype.RaidDataProvider, req *prototype.Request, args []string) string {
+	argc := len(args)
+	if argc > 0 {
+		switch args[0] {
+		case "add":
+			return d.addSchedule(data, req, args[1:])
+		case "list":
+			return d.listSchedules(data)
+		case "delete":
+			return d.deleteSchedule(data, args[1:])
+		}
+	}
+	return ""
+}
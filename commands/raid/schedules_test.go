@@ -0,0 +1,138 @@
+package raid
+
+import (
+	"github.com/juan-medina/cecibot/commands/raid/data/memory"
+	"github.com/juan-medina/cecibot/commands/raid/shared"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_parseDays(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		want    []time.Weekday
+		wantErr bool
+	}{
+		{"short names", "wed,sun", []time.Weekday{time.Wednesday, time.Sunday}, false},
+		{"long names", "Monday, FRIDAY", []time.Weekday{time.Monday, time.Friday}, false},
+		{"too short", "we", nil, true},
+		{"unknown day", "wed,someday", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseDays(tt.text)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("want error %v, got %v", tt.wantErr, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("want %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func Test_raidCommands_schedule(t *testing.T) {
+	prc := fakeProcessor{}
+	store := memory.New()
+	data := store.Guild(fakeGuild)
+	rc := newRaidCommands(prc, store, fakeNow)
+
+	t.Run("should be only for officers", func(t *testing.T) {
+		got := rc.raid(newRequest([]string{"schedule", "list"}, "456", fakeGuild)).String()
+		if got != shared.OfficersOnly {
+			t.Errorf("want %q, got %q", shared.OfficersOnly, got)
+		}
+	})
+
+	t.Run("there are no schedules", func(t *testing.T) {
+		got := rc.raid(newRequest([]string{"schedule", "list"}, "123", fakeGuild)).String()
+		want := "there are no raid schedules"
+		if got != want {
+			t.Errorf("want %q, got %q", want, got)
+		}
+	})
+
+	cases := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{
+			name: "should fail with invalid days",
+			args: []string{"schedule", "add", "Molten Core", "wed,someday", "20:00"},
+			want: `invalid day "someday", use week days as *wed,sun*`,
+		},
+		{
+			name: "should fail with an invalid time",
+			args: []string{"schedule", "add", "Molten Core", "wed,sun", "25:00"},
+			want: `invalid time "25:00", use the format *HH:MM*`,
+		},
+		{
+			name: "should fail with an invalid time zone",
+			args: []string{"schedule", "add", "Molten Core", "wed,sun", "20:00", "Azeroth/Orgrimmar"},
+			want: `invalid time zone "Azeroth/Orgrimmar", use a name as *Europe/Madrid*`,
+		},
+		{
+			name: "should create the raids of the schedule",
+			args: []string{"schedule", "add", "Molten Core", "wed,sun", "20:00"},
+			want: "schedule **Molten Core** every Wed, Sun at 20:00 created with schedule-id **1**\n" +
+				"\traid-id **1** : Molten Core, Sun 03 Nov 2019 20:00\n" +
+				"\traid-id **2** : Molten Core, Wed 06 Nov 2019 20:00\n" +
+				"\traid-id **3** : Molten Core, Sun 10 Nov 2019 20:00\n" +
+				"\traid-id **4** : Molten Core, Wed 13 Nov 2019 20:00",
+		},
+		{
+			name: "should create the raids in the time zone",
+			args: []string{"schedule", "add", "Blackwing Lair", "fri", "21:30", "Europe/Madrid"},
+			want: "schedule **Blackwing Lair** every Fri at 21:30 Europe/Madrid created with schedule-id **2**\n" +
+				"\traid-id **5** : Blackwing Lair, " + time.Date(2019, 11, 1, 20, 30, 0, 0, time.UTC).Local().Format(raidDateFormat) + "\n" +
+				"\traid-id **6** : Blackwing Lair, " + time.Date(2019, 11, 8, 20, 30, 0, 0, time.UTC).Local().Format(raidDateFormat),
+		},
+		{
+			name: "should list the schedules",
+			args: []string{"schedule", "list"},
+			want: "raid schedules:\n" +
+				"\t**1** : **Molten Core** every Wed, Sun at 20:00\n" +
+				"\t**2** : **Blackwing Lair** every Fri at 21:30 Europe/Madrid\n",
+		},
+		{
+			name: "should fail deleting an unknown schedule",
+			args: []string{"schedule", "delete", "99"},
+			want: "schedule **99** not found",
+		},
+		{
+			name: "should delete the schedule",
+			args: []string{"schedule", "delete", "1"},
+			want: "schedule **1** deleted, the raids already created are kept",
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			got := rc.raid(newRequest(tt.args, "123", fakeGuild)).String()
+			if got != tt.want {
+				t.Errorf("want %q, got %q", tt.want, got)
+			}
+		})
+	}
+
+	t.Run("should create raids like raid create", func(t *testing.T) {
+		raids, _ := data.GetRaids()
+		if len(raids) != 6 {
+			t.Fatalf("want 6 raids, got %v", raids)
+		}
+
+		got := rc.raid(newRequest([]string{"sign", "up", raids[0].Id, "Thrall", "shaman", "restoration"}, "1", fakeGuild)).String()
+		want := "**Thrall** signed up as *Shaman* *Restoration* for raid **Blackwing Lair** (**5**)"
+		if got != want {
+			t.Errorf("want %q, got %q", want, got)
+		}
+	})
+
+	schedules, _ := data.GetSchedules()
+	if len(schedules) != 1 || schedules[0].Id != "2" {
+		t.Errorf("want only the schedule 2, got %v", schedules)
+	}
+}
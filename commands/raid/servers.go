@@ -0,0 +1,78 @@
+package raid
+
+import (
+	"fmt"
+	"github.com/juan-medina/cecibot/command"
+	"github.com/juan-medina/cecibot/prototype"
+	"strconv"
+)
+
+const noSharedServers = "we don't share any **server**, send me raid commands from a server"
+const chooseServer = "we share several **servers**, choose one with **raid server** *number* :\n"
+const directMessagesOnly = "this option is only available in **direct messages**"
+
+func listServers(guilds []prototype.GuildRef, selected string) string {
+	result := ""
+	for i, guild := range guilds {
+		result += fmt.Sprintf("\t**%d** : %s", i+1, guild.Name)
+		if guild.Id == selected {
+			result += " *(selected)*"
+		}
+		result += "\n"
+	}
+	return result
+}
+
+func (d *raidCommands) selectedServer(author string) string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.servers[author]
+}
+
+// directMessageGuild returns a message to the author when the server could not be resolved
+func (d *raidCommands) directMessageGuild(req *prototype.Request) (string, string) {
+	switch len(req.Guilds) {
+	case 0:
+		return "", noSharedServers
+	case 1:
+		return req.Guilds[0].Id, ""
+	}
+
+	selected := d.selectedServer(req.Author)
+	for _, guild := range req.Guilds {
+		if guild.Id == selected {
+			return guild.Id, ""
+		}
+	}
+
+	return "", chooseServer + listServers(req.Guilds, "")
+}
+
+func (d *raidCommands) server(req *prototype.Request) *prototype.Response {
+	if !req.DM {
+		return command.Text(directMessagesOnly)
+	}
+
+	if len(req.Guilds) == 0 {
+		return command.Text(noSharedServers)
+	}
+
+	args := req.Args[1:]
+	if len(args) == 0 {
+		return command.Text("servers that we share :\n" + listServers(req.Guilds, d.selectedServer(req.Author)))
+	}
+
+	number, err := strconv.Atoi(args[0])
+	if err != nil || number < 1 || number > len(req.Guilds) {
+		return command.Text(fmt.Sprintf("invalid server **%s**, choose one of :\n", args[0]) + listServers(req.Guilds, ""))
+	}
+
+	guild := req.Guilds[number-1]
+
+	d.mu.Lock()
+	d.servers[req.Author] = guild.Id
+	d.mu.Unlock()
+
+	return command.Text(fmt.Sprintf("raid commands in direct messages will be sent to **%s**", guild.Name))
+}
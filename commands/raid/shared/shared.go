@@ -0,0 +1,48 @@
+// Package shared has the messages and checks used by the commands that work with the raid data.
+package shared
+
+import (
+	"fmt"
+	"github.com/juan-medina/cecibot/commands/raid/data/entities"
+	"github.com/juan-medina/cecibot/prototype"
+	"go.uber.org/zap"
+)
+
+const OfficersOnly = "this command is for **officers** only"
+const RaidNotFound = "raid **%s** not found"
+const StorageError = "there was an error accessing the raid data, please try again later"
+
+func Failure(err error, raidId string) string {
+	if err == entities.ErrRaidNotFound {
+		return fmt.Sprintf(RaidNotFound, raidId)
+	}
+
+	log, _ := zap.NewProduction()
+	defer log.Sync()
+
+	log.Error("Error accessing raid data.", zap.Error(err))
+	return StorageError
+}
+
+func IsOfficer(prc prototype.Processor, data prototype.RaidDataProvider, id string) bool {
+	if prc.IsOwner(id) {
+		return true
+	}
+
+	officers, err := data.GetOfficers()
+	if err != nil {
+		return false
+	}
+
+	for _, officer := range officers {
+		if officer.Id == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+func RaidTitle(raid entities.Raid) string {
+	return fmt.Sprintf("**%s** (**%s**)", raid.Name, raid.Id)
+}
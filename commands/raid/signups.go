@@ -0,0 +1,109 @@
+package raid
+
+import (
+	"fmt"
+	"github.com/juan-medina/cecibot/command"
+	"github.com/juan-medina/cecibot/commands/raid/data/entities"
+	"github.com/juan-medina/cecibot/commands/raid/shared"
+	"github.com/juan-medina/cecibot/prototype"
+)
+
+const signUpUsage = "sign up with **raid sign up** *raid-id* *char* *class* *spec*, " +
+	"or with **raid sign up** *raid-id* *char* for one of your characters"
+
+func (d *raidCommands) getOpenRaid(data prototype.RaidDataProvider, id string) (entities.Raid, string) {
+	raid, err := data.GetRaid(id)
+	if err != nil {
+		return raid, fmt.Sprintf(shared.RaidNotFound, id)
+	}
+	if raid.Cancelled {
+		return raid, fmt.Sprintf("raid %s has been cancelled", shared.RaidTitle(raid))
+	}
+	if raid.Date.Before(d.now()) {
+		return raid, fmt.Sprintf("raid %s has already started", shared.RaidTitle(raid))
+	}
+	return raid, ""
+}
+
+func (d *raidCommands) findSignup(data prototype.RaidDataProvider, raidId string, member string) (entities.Signup, bool) {
+	signups, _ := data.GetSignups(raidId)
+	for _, signup := range signups {
+		if signup.Member == member {
+			return signup, true
+		}
+	}
+	return entities.Signup{}, false
+}
+
+func (d *raidCommands) signUp(data prototype.RaidDataProvider, req *prototype.Request, args []string) *prototype.Response {
+	argc := len(args)
+	// a char and a class without spec is not enough to sign up
+	if argc == 3 {
+		return command.Text(signUpUsage)
+	}
+	if argc > 0 {
+		raid, msg := d.getOpenRaid(data, args[0])
+		if msg != "" {
+			return command.Text(msg)
+		}
+
+		signup, msg := d.characterSignup(data, req, args[1:])
+		if msg != "" {
+			return command.Text(msg)
+		}
+
+		previous, updated := d.findSignup(data, raid.Id, req.Author)
+		signup.Status = previous.Status
+		place, notices, err := d.saveSignup(data, raid, signup)
+		if err != nil {
+			return command.Text(shared.Failure(err, raid.Id))
+		}
+
+		if updated {
+			msg = fmt.Sprintf("signup for raid %s updated to **%s** as *%s* *%s*", shared.RaidTitle(raid), signup.Char, signup.Class, signup.Spec)
+		} else {
+			msg = fmt.Sprintf("**%s** signed up as *%s* *%s* for raid %s", signup.Char, signup.Class, signup.Spec, shared.RaidTitle(raid))
+		}
+		response := command.Text(msg + place)
+		response.Notices = notices
+		return d.withRosterUpdate(data, raid.Id, response)
+	}
+
+	return command.Text("")
+}
+
+func (d *raidCommands) signDown(data prototype.RaidDataProvider, req *prototype.Request, args []string) *prototype.Response {
+	argc := len(args)
+	if argc > 0 {
+		raid, msg := d.getOpenRaid(data, args[0])
+		if msg != "" {
+			return command.Text(msg)
+		}
+
+		notices, err := d.removeSignup(data, raid, req.Author)
+		if err == entities.ErrSignupNotFound {
+			return command.Text(fmt.Sprintf("you are not signed up for raid %s", shared.RaidTitle(raid)))
+		} else if err != nil {
+			return command.Text(shared.Failure(err, raid.Id))
+		}
+
+		response := command.Text(fmt.Sprintf("signed down from raid %s", shared.RaidTitle(raid)))
+		response.Notices = notices
+		return d.withRosterUpdate(data, raid.Id, response)
+	}
+
+	return command.Text("")
+}
+
+func (d *raidCommands) sign(data prototype.RaidDataProvider, req *prototype.Request, args []string) *prototype.Response {
+	argc := len(args)
+	if argc > 0 {
+		sub := args[0]
+		if sub == "up" {
+			return d.signUp(data, req, args[1:])
+		} else if sub == "down" {
+			return d.signDown(data, req, args[1:])
+		}
+	}
+	return command.Text("")
+}
@@ -0,0 +1,118 @@
+package raid
+
+import (
+	"github.com/juan-medina/cecibot/commands/raid/data/entities"
+	"github.com/juan-medina/cecibot/commands/raid/data/memory"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_raidCommands_sign(t *testing.T) {
+	prc := fakeProcessor{}
+	store := memory.New()
+	data := store.Guild(fakeGuild)
+	rc := newRaidCommands(prc, store, fakeNow)
+
+	raid := addRaid(t, data, "Molten Core", time.Date(2019, 11, 20, 20, 0, 0, 0, time.Local))
+	past := addRaid(t, data, "Onyxia's Lair", time.Date(2019, 10, 20, 20, 0, 0, 0, time.Local))
+
+	type testCase struct {
+		name   string
+		args   []string
+		author string
+		want   string
+	}
+
+	cases := []testCase{
+		{
+			name:   "should return empty string without sign action",
+			args:   []string{"sign"},
+			author: "456",
+			want:   "",
+		},
+		{
+			name:   "should show the usage signing up without spec",
+			args:   []string{"sign", "up", raid.Id, "Thrall", "shaman"},
+			author: "456",
+			want:   signUpUsage,
+		},
+		{
+			name:   "should fail signing up to an unknown raid",
+			args:   []string{"sign", "up", "99", "Thrall", "shaman", "restoration"},
+			author: "456",
+			want:   "raid **99** not found",
+		},
+		{
+			name:   "should fail signing up to a started raid",
+			args:   []string{"sign", "up", past.Id, "Thrall", "shaman", "restoration"},
+			author: "456",
+			want:   "raid **Onyxia's Lair** (**2**) has already started",
+		},
+		{
+			name:   "should fail with an invalid class",
+			args:   []string{"sign", "up", raid.Id, "Thrall", "monk", "mistweaver"},
+			author: "456",
+			want:   "invalid class \"monk\", valid classes are: Druid, Hunter, Mage, Paladin, Priest, Rogue, Shaman, Warlock, Warrior",
+		},
+		{
+			name:   "should fail with an invalid spec",
+			args:   []string{"sign", "up", raid.Id, "Thrall", "shaman", "frost"},
+			author: "456",
+			want:   "invalid spec \"frost\" for **Shaman**, valid specs are: Elemental, Enhancement, Restoration",
+		},
+		{
+			name:   "should sign up",
+			args:   []string{"sign", "up", raid.Id, "Thrall", "shaman", "restoration"},
+			author: "456",
+			want:   "**Thrall** signed up as *Shaman* *Restoration* for raid **Molten Core** (**1**)",
+		},
+		{
+			name:   "should sign up with a spec of several words",
+			args:   []string{"sign", "up", raid.Id, "Rexxar", "hunter", "beast", "mastery"},
+			author: "789",
+			want:   "**Rexxar** signed up as *Hunter* *Beast Mastery* for raid **Molten Core** (**1**)",
+		},
+		{
+			name:   "should update the sign up",
+			args:   []string{"sign", "up", raid.Id, "Thrall", "shaman", "elemental"},
+			author: "456",
+			want:   "signup for raid **Molten Core** (**1**) updated to **Thrall** as *Shaman* *Elemental*",
+		},
+		{
+			name:   "should return empty string signing down without raid",
+			args:   []string{"sign", "down"},
+			author: "456",
+			want:   "",
+		},
+		{
+			name:   "should sign down",
+			args:   []string{"sign", "down", raid.Id},
+			author: "789",
+			want:   "signed down from raid **Molten Core** (**1**)",
+		},
+		{
+			name:   "should fail signing down without sign up",
+			args:   []string{"sign", "down", raid.Id},
+			author: "789",
+			want:   "you are not signed up for raid **Molten Core** (**1**)",
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			got := rc.raid(newRequest(tt.args, tt.author, fakeGuild)).String()
+			if got != tt.want {
+				t.Errorf("want %q, got %q", tt.want, got)
+			}
+		})
+	}
+
+	t.Run("signups are stored by member", func(t *testing.T) {
+		got, _ := data.GetSignups(raid.Id)
+		want := []entities.Signup{{Member: "456", Char: "Thrall", Class: "Shaman", Spec: "Elemental"}}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("want %v, got %v", want, got)
+		}
+	})
+}
@@ -0,0 +1,80 @@
+package raid
+
+import (
+	"fmt"
+	"github.com/juan-medina/cecibot/commands/raid/shared"
+	"github.com/juan-medina/cecibot/prototype"
+	"go.uber.org/zap"
+	"time"
+)
+
+const timezoneSetting = "timezone"
+
+const noTimezone = "none"
+
+func formatDate(date time.Time, loc *time.Location) string {
+	return date.In(loc).Format(raidDateFormat)
+}
+
+// guildTimezone is the name of the configured time zone of the server, empty for the local one
+func (d *raidCommands) guildTimezone() string {
+	return d.GetProcessor().GetConfig().GetTimezone()
+}
+
+func (d *raidCommands) guildLocation() *time.Location {
+	name := d.guildTimezone()
+	if name == "" {
+		return time.Local
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		log, _ := zap.NewProduction()
+		defer log.Sync()
+
+		log.Error("Invalid time zone, using local time.", zap.String("timezone", name), zap.Error(err))
+		return time.Local
+	}
+	return loc
+}
+
+func (d *raidCommands) memberLocation(member string) *time.Location {
+	if name, err := d.store.GetUserSetting(member, timezoneSetting); err == nil && name != "" {
+		if loc, err := time.LoadLocation(name); err == nil {
+			return loc
+		}
+	}
+	return d.guildLocation()
+}
+
+func (d *raidCommands) timezone(data prototype.RaidDataProvider, req *prototype.Request, args []string) string {
+	if len(args) == 0 {
+		name, err := d.store.GetUserSetting(req.Author, timezoneSetting)
+		if err != nil {
+			return shared.Failure(err, "")
+		}
+		if name != "" {
+			return fmt.Sprintf("raid times are shown to you in **%s**", name)
+		}
+		if server := d.guildTimezone(); server != "" {
+			return fmt.Sprintf("raid times are shown to you in the server time zone, **%s**", server)
+		}
+		return "raid times are shown to you in the server time zone"
+	}
+
+	name := args[0]
+	if name == noTimezone {
+		if err := d.store.SetUserSetting(req.Author, timezoneSetting, ""); err != nil {
+			return shared.Failure(err, "")
+		}
+		return "raid times are shown to you in the server time zone"
+	}
+
+	if _, err := time.LoadLocation(name); err != nil || name == "Local" {
+		return fmt.Sprintf("invalid time zone %q, use a name as *Europe/Madrid*", name)
+	}
+	if err := d.store.SetUserSetting(req.Author, timezoneSetting, name); err != nil {
+		return shared.Failure(err, "")
+	}
+	return fmt.Sprintf("raid times are shown to you in **%s**", name)
+}
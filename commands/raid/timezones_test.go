@@ -0,0 +1,114 @@
+package raid
+
+import (
+	"github.com/juan-medina/cecibot/commands/raid/data/memory"
+	"testing"
+	"time"
+)
+
+func Test_raidCommands_timezone(t *testing.T) {
+	prc := fakeProcessor{cfg: fakeCfg{timezone: "Europe/Madrid"}}
+	store := memory.New()
+	data := store.Guild(fakeGuild)
+	rc := newRaidCommands(prc, store, fakeNow)
+
+	madrid, _ := time.LoadLocation("Europe/Madrid")
+	addRaid(t, data, "Molten Core", time.Date(2019, 11, 20, 20, 0, 0, 0, madrid))
+
+	cases := []struct {
+		name   string
+		args   []string
+		author string
+		want   string
+	}{
+		{
+			name:   "should use the server time zone by default",
+			args:   []string{"timezone"},
+			author: "1",
+			want:   "raid times are shown to you in the server time zone, **Europe/Madrid**",
+		},
+		{
+			name:   "should show the raids in the server time zone",
+			args:   []string{"list"},
+			author: "1",
+			want:   "next raids:\n\t**1** : Molten Core, Wed 20 Nov 2019 20:00\n",
+		},
+		{
+			name:   "should create raids in the server time zone",
+			args:   []string{"create", "Onyxia's Lair", "2019-11-21 21:00"},
+			author: "123",
+			want:   "raid **Onyxia's Lair** on Thu 21 Nov 2019 21:00 created with raid-id **2**",
+		},
+		{
+			name:   "should fail with an invalid time zone",
+			args:   []string{"timezone", "Azeroth/Orgrimmar"},
+			author: "1",
+			want:   `invalid time zone "Azeroth/Orgrimmar", use a name as *Europe/Madrid*`,
+		},
+		{
+			name:   "should set the time zone of the member",
+			args:   []string{"timezone", "America/New_York"},
+			author: "1",
+			want:   "raid times are shown to you in **America/New_York**",
+		},
+		{
+			name:   "should show the time zone of the member",
+			args:   []string{"timezone"},
+			author: "1",
+			want:   "raid times are shown to you in **America/New_York**",
+		},
+		{
+			name:   "should show the raids in the time zone of the member",
+			args:   []string{"list"},
+			author: "1",
+			want:   "next raids:\n\t**1** : Molten Core, Wed 20 Nov 2019 14:00\n\t**2** : Onyxia's Lair, Thu 21 Nov 2019 15:00\n",
+		},
+		{
+			name:   "should not change the time zone of other members",
+			args:   []string{"list"},
+			author: "2",
+			want:   "next raids:\n\t**1** : Molten Core, Wed 20 Nov 2019 20:00\n\t**2** : Onyxia's Lair, Thu 21 Nov 2019 21:00\n",
+		},
+		{
+			name:   "should remove the time zone of the member",
+			args:   []string{"timezone", "none"},
+			author: "1",
+			want:   "raid times are shown to you in the server time zone",
+		},
+		{
+			name:   "should show the raids in the server time zone again",
+			args:   []string{"list"},
+			author: "1",
+			want:   "next raids:\n\t**1** : Molten Core, Wed 20 Nov 2019 20:00\n\t**2** : Onyxia's Lair, Thu 21 Nov 2019 21:00\n",
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			got := rc.raid(newRequest(tt.args, tt.author, fakeGuild)).String()
+			if got != tt.want {
+				t.Errorf("want %q, got %q", tt.want, got)
+			}
+		})
+	}
+
+	t.Run("should keep the time zone of the member in every server", func(t *testing.T) {
+		_ = rc.raid(newRequest([]string{"timezone", "Asia/Tokyo"}, "1", fakeGuild))
+
+		want := "raid times are shown to you in **Asia/Tokyo**"
+		if got := rc.raid(newRequest([]string{"timezone"}, "1", "guild2")).String(); got != want {
+			t.Errorf("want %q, got %q", want, got)
+		}
+	})
+
+	t.Run("should create raids in the time zone of the officer", func(t *testing.T) {
+		_ = rc.raid(newRequest([]string{"timezone", "America/New_York"}, "123", fakeGuild))
+		_ = rc.raid(newRequest([]string{"create", "Zul'Gurub", "2019-11-22 20:00"}, "123", fakeGuild))
+
+		raid, _ := data.GetRaid("3")
+		newYork, _ := time.LoadLocation("America/New_York")
+		if want := time.Date(2019, 11, 22, 20, 0, 0, 0, newYork); !raid.Date.Equal(want) {
+			t.Errorf("want raid on %v, got %v", want, raid.Date)
+		}
+	})
+}
@@ -0,0 +1,291 @@
+package raid
+
+import (
+	"fmt"
+	"github.com/juan-medina/cecibot/command"
+	"github.com/juan-medina/cecibot/commands/raid/classes"
+	"github.com/juan-medina/cecibot/commands/raid/data/entities"
+	"github.com/juan-medina/cecibot/commands/raid/shared"
+	"github.com/juan-medina/cecibot/message"
+	"github.com/juan-medina/cecibot/prototype"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const sizeLimit = "size"
+
+// parseLimits reads the size and role limits of a raid, as size=40 tank=4, from the end of the arguments
+func parseLimits(args []string) (rest []string, size int, limits map[string]int, err error) {
+	rest = args
+	for len(rest) > 0 && strings.Contains(rest[len(rest)-1], "=") {
+		arg := rest[len(rest)-1]
+		rest = rest[:len(rest)-1]
+
+		parts := strings.SplitN(arg, "=", 2)
+		value, convErr := strconv.Atoi(parts[1])
+		if convErr != nil || value < 1 {
+			return nil, 0, nil, fmt.Errorf("invalid limit %q, use *size=N* or a role as *tank=N*", arg)
+		}
+
+		key := strings.ToLower(parts[0])
+		if key == sizeLimit {
+			size = value
+			continue
+		}
+		role, found := findRole(key)
+		if !found {
+			return nil, 0, nil, fmt.Errorf("invalid limit %q, use *size=N* or a role as *tank=N*", arg)
+		}
+		if limits == nil {
+			limits = make(map[string]int)
+		}
+		limits[string(role)] = value
+	}
+	return rest, size, limits, nil
+}
+
+func findRole(name string) (classes.Role, bool) {
+	for _, role := range classes.Roles {
+		if strings.ToLower(string(role)) == name {
+			return role, true
+		}
+	}
+	return "", false
+}
+
+func describeLimits(raid entities.Raid) string {
+	parts := make([]string, 0)
+	if raid.Size > 0 {
+		parts = append(parts, fmt.Sprintf("up to **%d** members", raid.Size))
+	}
+	for _, role := range classes.Roles {
+		if limit := raid.Limits[string(role)]; limit > 0 {
+			parts = append(parts, fmt.Sprintf("%s %d", role, limit))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+func fits(raid entities.Raid, signups []entities.Signup, signup entities.Signup) bool {
+	role := roleOf(signup)
+	confirmed, inRole := 0, 0
+	for _, other := range signups {
+		if other.Member == signup.Member || other.Status != entities.SignupConfirmed {
+			continue
+		}
+		confirmed++
+		if roleOf(other) == role {
+			inRole++
+		}
+	}
+
+	if raid.Size > 0 && confirmed >= raid.Size {
+		return false
+	}
+	if limit := raid.Limits[string(role)]; limit > 0 && inRole >= limit {
+		return false
+	}
+	return true
+}
+
+func waitlistPosition(signups []entities.Signup, member string) int {
+	position := 0
+	for _, signup := range signups {
+		if signup.Status != entities.SignupWaitlisted {
+			continue
+		}
+		position++
+		if signup.Member == member {
+			return position
+		}
+	}
+	return 0
+}
+
+func notice(member string, text string) prototype.Notice {
+	return prototype.Notice{Member: member, Message: prototype.Message{Text: text}}
+}
+
+// promote confirms the waitlisted members that have room in the raid, in the order they signed up, notifying them.
+// Must be called holding rosterMu
+func (d *raidCommands) promote(data prototype.RaidDataProvider, raid entities.Raid) ([]prototype.Notice, error) {
+	notices := make([]prototype.Notice, 0)
+
+	signups, err := data.GetSignups(raid.Id)
+	if err != nil {
+		return notices, err
+	}
+
+	for i, signup := range signups {
+		if signup.Status != entities.SignupWaitlisted || !fits(raid, signups, signup) {
+			continue
+		}
+		if err := data.SetSignupStatus(raid.Id, signup.Member, entities.SignupConfirmed); err != nil {
+			return notices, err
+		}
+		signups[i].Status = entities.SignupConfirmed
+		notices = append(notices, notice(signup.Member, fmt.Sprintf("there is room for you in raid %s on %s, you have been promoted from the waitlist",
+			shared.RaidTitle(raid), message.Timestamp(raid.Date))))
+	}
+	return notices, nil
+}
+
+// saveSignup stores a signup in the roster, or in the waitlist if there is no room for it, benched members stay on the
+// bench. The returned text tells the waitlist position, if any
+func (d *raidCommands) saveSignup(data prototype.RaidDataProvider, raid entities.Raid, signup entities.Signup) (string, []prototype.Notice, error) {
+	d.rosterMu.Lock()
+	defer d.rosterMu.Unlock()
+
+	signups, err := data.GetSignups(raid.Id)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if signup.Status != entities.SignupBenched {
+		signup.Status = entities.SignupConfirmed
+		if !fits(raid, signups, signup) {
+			signup.Status = entities.SignupWaitlisted
+		}
+	}
+	if err := data.SignUp(raid.Id, signup); err != nil {
+		return "", nil, err
+	}
+
+	// a member changing to a role that is full leaves room for others
+	notices, err := d.promote(data, raid)
+	if err != nil {
+		return "", notices, err
+	}
+
+	switch signup.Status {
+	case entities.SignupWaitlisted:
+		signups, err = data.GetSignups(raid.Id)
+		if err != nil {
+			return "", notices, err
+		}
+		return fmt.Sprintf("\nthe raid is full, you are **#%d** on the waitlist", waitlistPosition(signups, signup.Member)), notices, nil
+	case entities.SignupBenched:
+		return "\nyou are on the bench", notices, nil
+	}
+	return "", notices, nil
+}
+
+func (d *raidCommands) removeSignup(data prototype.RaidDataProvider, raid entities.Raid, member string) ([]prototype.Notice, error) {
+	d.rosterMu.Lock()
+	defer d.rosterMu.Unlock()
+
+	if err := data.SignDown(raid.Id, member); err != nil {
+		return nil, err
+	}
+	return d.promote(data, raid)
+}
+
+func (d *raidCommands) benchSignup(data prototype.RaidDataProvider, raid entities.Raid, member string) ([]prototype.Notice, error) {
+	d.rosterMu.Lock()
+	defer d.rosterMu.Unlock()
+
+	if err := data.SetSignupStatus(raid.Id, member, entities.SignupBenched); err != nil {
+		return nil, err
+	}
+	return d.promote(data, raid)
+}
+
+// unbenchSignup moves a member to the roster, the returned text tells why it could not be moved, if any
+func (d *raidCommands) unbenchSignup(data prototype.RaidDataProvider, raid entities.Raid, member string) (string, error) {
+	d.rosterMu.Lock()
+	defer d.rosterMu.Unlock()
+
+	signup, found := d.findSignup(data, raid.Id, member)
+	if !found {
+		return fmt.Sprintf("<@%s> is not signed up for raid %s", member, shared.RaidTitle(raid)), nil
+	}
+	if signup.Status == entities.SignupConfirmed {
+		return fmt.Sprintf("<@%s> is already in the roster of raid %s", member, shared.RaidTitle(raid)), nil
+	}
+	return "", data.SetSignupStatus(raid.Id, member, entities.SignupConfirmed)
+}
+
+var memberMention = regexp.MustCompile(`^<@!?(\d+)>$`)
+var memberIdPattern = regexp.MustCompile(`^\d+$`)
+
+// mentionedMember gets the id of a member from a mention of the request or the id itself, the mentions that discord
+// did not resolve to a member, like the ones of roles, are not members
+func mentionedMember(req *prototype.Request, text string) (string, error) {
+	if match := memberMention.FindStringSubmatch(text); match != nil {
+		for _, mention := range req.Mentions {
+			if mention == match[1] {
+				return mention, nil
+			}
+		}
+	} else if memberIdPattern.MatchString(text) {
+		return text, nil
+	}
+	return "", fmt.Errorf("invalid member %q, mention them or give their id", text)
+}
+
+func (d *raidCommands) bench(data prototype.RaidDataProvider, req *prototype.Request, args []string) *prototype.Response {
+	argc := len(args)
+	if argc > 1 {
+		raid, msg := d.getOpenRaid(data, args[0])
+		if msg != "" {
+			return command.Text(msg)
+		}
+
+		member, err := mentionedMember(req, args[1])
+		if err != nil {
+			return command.Text(err.Error())
+		}
+		signup, found := d.findSignup(data, raid.Id, member)
+		if !found {
+			return command.Text(fmt.Sprintf("<@%s> is not signed up for raid %s", member, shared.RaidTitle(raid)))
+		}
+		if signup.Status == entities.SignupBenched {
+			return command.Text(fmt.Sprintf("<@%s> is already on the bench of raid %s", member, shared.RaidTitle(raid)))
+		}
+
+		notices, err := d.benchSignup(data, raid, member)
+		if err != nil {
+			return command.Text(shared.Failure(err, raid.Id))
+		}
+
+		response := command.Text(fmt.Sprintf("<@%s> moved to the bench of raid %s", member, shared.RaidTitle(raid)))
+		response.Notices = append([]prototype.Notice{
+			notice(member, fmt.Sprintf("an officer has moved you to the bench of raid %s on %s", shared.RaidTitle(raid), message.Timestamp(raid.Date))),
+		}, notices...)
+		return d.withRosterUpdate(data, raid.Id, response)
+	}
+
+	return command.Text("")
+}
+
+// unbench confirms a benched or waitlisted member even if the raid is full, officers have the last word
+func (d *raidCommands) unbench(data prototype.RaidDataProvider, req *prototype.Request, args []string) *prototype.Response {
+	argc := len(args)
+	if argc > 1 {
+		raid, msg := d.getOpenRaid(data, args[0])
+		if msg != "" {
+			return command.Text(msg)
+		}
+
+		member, err := mentionedMember(req, args[1])
+		if err != nil {
+			return command.Text(err.Error())
+		}
+		msg, err = d.unbenchSignup(data, raid, member)
+		if err != nil {
+			return command.Text(shared.Failure(err, raid.Id))
+		}
+		if msg != "" {
+			return command.Text(msg)
+		}
+
+		response := command.Text(fmt.Sprintf("<@%s> moved to the roster of raid %s", member, shared.RaidTitle(raid)))
+		response.Notices = []prototype.Notice{
+			notice(member, fmt.Sprintf("an officer has moved you to the roster of raid %s on %s", shared.RaidTitle(raid), message.Timestamp(raid.Date))),
+		}
+		return d.withRosterUpdate(data, raid.Id, response)
+	}
+
+	return command.Text("")
+}
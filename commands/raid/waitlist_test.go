@@ -0,0 +1,297 @@
+package raid
+
+import (
+	"github.com/juan-medina/cecibot/commands/raid/data/entities"
+	"github.com/juan-medina/cecibot/commands/raid/data/memory"
+	"github.com/juan-medina/cecibot/message"
+	"github.com/juan-medina/cecibot/prototype"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_parseLimits(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		wantRest   []string
+		wantSize   int
+		wantLimits map[string]int
+		wantErr    bool
+	}{
+		{"without limits", []string{"Molten Core", "tomorrow"}, []string{"Molten Core", "tomorrow"}, 0, nil, false},
+		{"with size", []string{"Molten Core", "tomorrow", "size=40"}, []string{"Molten Core", "tomorrow"}, 40, nil, false},
+		{"with roles", []string{"Molten Core", "Tank=4", "healer=8"}, []string{"Molten Core"}, 0, map[string]int{"Tank": 4, "Healer": 8}, false},
+		{"unknown role", []string{"Molten Core", "tomorrow", "dps=4"}, nil, 0, nil, true},
+		{"invalid number", []string{"Molten Core", "tomorrow", "size=many"}, nil, 0, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rest, size, limits, err := parseLimits(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("want error %v, got %v", tt.wantErr, err)
+			}
+			if !reflect.DeepEqual(rest, tt.wantRest) || size != tt.wantSize || !reflect.DeepEqual(limits, tt.wantLimits) {
+				t.Errorf("want %v %d %v, got %v %d %v", tt.wantRest, tt.wantSize, tt.wantLimits, rest, size, limits)
+			}
+		})
+	}
+}
+
+func Test_mentionedMember(t *testing.T) {
+	req := &prototype.Request{Mentions: []string{"1", "2"}}
+	tests := []struct {
+		name    string
+		text    string
+		want    string
+		wantErr bool
+	}{
+		{"mention", "<@1>", "1", false},
+		{"nickname mention", "<@!2>", "2", false},
+		{"id", "3", "3", false},
+		{"role mention", "<@&1>", "", true},
+		{"mention not resolved", "<@4>", "", true},
+		{"not a member", "thrall", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := mentionedMember(req, tt.text)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("want error %v, got %v", tt.wantErr, err)
+			}
+			if got != tt.want {
+				t.Errorf("want %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func Test_raidCommands_waitlist(t *testing.T) {
+	prc := fakeProcessor{}
+	store := memory.New()
+	data := store.Guild(fakeGuild)
+	rc := newRaidCommands(prc, store, fakeNow)
+
+	date := time.Date(2019, 11, 20, 20, 0, 0, 0, time.Local)
+	promoted := func(member string) []prototype.Notice {
+		return []prototype.Notice{{Member: member, Message: prototype.Message{
+			Text: "there is room for you in raid **Molten Core** (**1**) on " + message.Timestamp(date) + ", you have been promoted from the waitlist",
+		}}}
+	}
+
+	cases := []struct {
+		name        string
+		args        []string
+		author      string
+		want        string
+		wantNotices []prototype.Notice
+	}{
+		{
+			name:   "should fail with an invalid limit",
+			args:   []string{"create", "Molten Core", "2019-11-20", "20:00", "dps=2"},
+			author: "123",
+			want:   `invalid limit "dps=2", use *size=N* or a role as *tank=N*`,
+		},
+		{
+			name:   "should create a raid with limits",
+			args:   []string{"create", "Molten Core", "2019-11-20", "20:00", "size=2", "tank=1"},
+			author: "123",
+			want:   "raid **Molten Core** on Wed 20 Nov 2019 20:00 created with raid-id **1**, up to **2** members, Tank 1",
+		},
+		{
+			name:   "should sign up while there is room",
+			args:   []string{"sign", "up", "1", "Garrosh", "warrior", "protection"},
+			author: "1",
+			want:   "**Garrosh** signed up as *Warrior* *Protection* for raid **Molten Core** (**1**)",
+		},
+		{
+			name:   "should waitlist when the role is full",
+			args:   []string{"sign", "up", "1", "Varian", "warrior", "protection"},
+			author: "2",
+			want:   "**Varian** signed up as *Warrior* *Protection* for raid **Molten Core** (**1**)\nthe raid is full, you are **#1** on the waitlist",
+		},
+		{
+			name:   "should sign up other roles",
+			args:   []string{"sign", "up", "1", "Thrall", "shaman", "restoration"},
+			author: "3",
+			want:   "**Thrall** signed up as *Shaman* *Restoration* for raid **Molten Core** (**1**)",
+		},
+		{
+			name:   "should waitlist when the raid is full",
+			args:   []string{"sign", "up", "1", "Jaina", "mage", "frost"},
+			author: "4",
+			want:   "**Jaina** signed up as *Mage* *Frost* for raid **Molten Core** (**1**)\nthe raid is full, you are **#2** on the waitlist",
+		},
+		{
+			name:        "should promote the first waitlisted member that fits",
+			args:        []string{"sign", "down", "1"},
+			author:      "1",
+			want:        "signed down from raid **Molten Core** (**1**)",
+			wantNotices: promoted("2"),
+		},
+		{
+			name:   "should fail benching a member not signed up",
+			args:   []string{"bench", "1", "<@9>"},
+			author: "123",
+			want:   "<@9> is not signed up for raid **Molten Core** (**1**)",
+		},
+		{
+			name:   "should bench a member",
+			args:   []string{"bench", "1", "<@!3>"},
+			author: "123",
+			want:   "<@3> moved to the bench of raid **Molten Core** (**1**)",
+			wantNotices: append([]prototype.Notice{{Member: "3", Message: prototype.Message{
+				Text: "an officer has moved you to the bench of raid **Molten Core** (**1**) on " + message.Timestamp(date),
+			}}}, promoted("4")...),
+		},
+		{
+			name:   "should keep benched members on the bench when they change their signup",
+			args:   []string{"sign", "up", "1", "Thrall", "shaman", "elemental"},
+			author: "3",
+			want:   "signup for raid **Molten Core** (**1**) updated to **Thrall** as *Shaman* *Elemental*\nyou are on the bench",
+		},
+		{
+			name:   "should unbench a member even if the raid is full",
+			args:   []string{"unbench", "1", "3"},
+			author: "123",
+			want:   "<@3> moved to the roster of raid **Molten Core** (**1**)",
+			wantNotices: []prototype.Notice{{Member: "3", Message: prototype.Message{
+				Text: "an officer has moved you to the roster of raid **Molten Core** (**1**) on " + message.Timestamp(date),
+			}}},
+		},
+		{
+			name:   "should fail unbenching a member in the roster",
+			args:   []string{"unbench", "1", "3"},
+			author: "123",
+			want:   "<@3> is already in the roster of raid **Molten Core** (**1**)",
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			got := rc.raid(newRequest(tt.args, tt.author, fakeGuild))
+			if got.String() != tt.want {
+				t.Errorf("want %q, got %q", tt.want, got.String())
+			}
+			if len(got.Notices) != 0 || len(tt.wantNotices) != 0 {
+				if !reflect.DeepEqual(got.Notices, tt.wantNotices) {
+					t.Errorf("want notices %v, got %v", tt.wantNotices, got.Notices)
+				}
+			}
+		})
+	}
+
+	t.Run("should show the waitlist and bench in the roster", func(t *testing.T) {
+		_ = rc.raid(newRequest([]string{"sign", "up", "1", "Sylvanas", "hunter", "marksmanship"}, "5", fakeGuild))
+		_ = rc.raid(newRequest([]string{"bench", "1", "2"}, "123", fakeGuild))
+
+		got := rc.raid(newRequest([]string{"roster", "1"}, "1", fakeGuild))
+		want := &prototype.Embed{
+			Title:       "Molten Core (1)",
+			Description: message.Timestamp(date),
+			Colour:      rosterColour,
+			Fields: []prototype.EmbedField{
+				{Name: "Tank (0/1)", Value: "-", Inline: true},
+				{Name: "Healer (0)", Value: "-", Inline: true},
+				{Name: "Melee (0)", Value: "-", Inline: true},
+				{Name: "Ranged (2)", Value: "**Thrall** *Shaman* *Elemental* <@3>\n**Jaina** *Mage* *Frost* <@4>", Inline: true},
+				{Name: "Classes", Value: "Mage 1, Shaman 1"},
+				{Name: "Waitlist", Value: "1. **Sylvanas** *Hunter* *Marksmanship* <@5>"},
+				{Name: "Bench", Value: "1. **Varian** *Warrior* *Protection* <@2>"},
+			},
+			Footer: "Total : 2/2",
+		}
+		assertEmbed(t, got, want)
+	})
+
+	t.Run("should keep the order of the waitlist", func(t *testing.T) {
+		signups, _ := data.GetSignups("1")
+		statuses := make([]string, 0, len(signups))
+		for _, signup := range signups {
+			statuses = append(statuses, signup.Status)
+		}
+		want := []string{entities.SignupBenched, entities.SignupConfirmed, entities.SignupConfirmed, entities.SignupWaitlisted}
+		if !reflect.DeepEqual(statuses, want) {
+			t.Errorf("want %v, got %v", want, statuses)
+		}
+	})
+}
+
+// slowStore waits after reading the signups, so the signups running at the same time could change them meanwhile
+type slowStore struct {
+	prototype.RaidDataStore
+}
+
+func (s slowStore) Guild(id string) prototype.RaidDataProvider {
+	return slowData{s.RaidDataStore.Guild(id)}
+}
+
+type slowData struct {
+	prototype.RaidDataProvider
+}
+
+func (s slowData) GetSignups(raidId string) ([]entities.Signup, error) {
+	signups, err := s.RaidDataProvider.GetSignups(raidId)
+	time.Sleep(time.Millisecond)
+	return signups, err
+}
+
+func Test_raidCommands_parallelSignups(t *testing.T) {
+	prc := fakeProcessor{}
+	store := slowStore{memory.New()}
+	data := store.Guild(fakeGuild)
+	rc := newRaidCommands(prc, store, fakeNow)
+
+	raid := addRaid(t, data, "Molten Core", time.Date(2019, 11, 20, 20, 0, 0, 0, time.Local))
+	raid.Size = 5
+	_ = data.UpdateRaid(raid)
+
+	const members = 20
+	run := func(args func(i int) []string) []*prototype.Response {
+		responses := make([]*prototype.Response, members)
+		var wg sync.WaitGroup
+		for i := 0; i < members; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				responses[i] = rc.raid(newRequest(args(i), strconv.Itoa(i+1), fakeGuild))
+			}(i)
+		}
+		wg.Wait()
+		return responses
+	}
+	confirmed := func() int {
+		signups, _ := data.GetSignups(raid.Id)
+		return len(byStatus(signups, entities.SignupConfirmed))
+	}
+
+	run(func(i int) []string {
+		return []string{"sign", "up", raid.Id, "Char" + strconv.Itoa(i), "Mage", "Arcane"}
+	})
+	if got := confirmed(); got != raid.Size {
+		t.Fatalf("want %d confirmed, got %d", raid.Size, got)
+	}
+
+	responses := run(func(i int) []string {
+		if i%2 == 0 {
+			return []string{"sign", "down", raid.Id}
+		}
+		return []string{"roster", raid.Id}
+	})
+	promoted := make(map[string]int)
+	for _, response := range responses {
+		for _, notice := range response.Notices {
+			promoted[notice.Member]++
+		}
+	}
+	for member, count := range promoted {
+		if count != 1 {
+			t.Errorf("want <@%s> promoted once, got %d", member, count)
+		}
+	}
+	if got := confirmed(); got != raid.Size {
+		t.Errorf("want %d confirmed, got %d", raid.Size, got)
+	}
+}
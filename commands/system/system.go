@@ -4,26 +4,84 @@ import (
 	"fmt"
 	"github.com/juan-medina/cecibot/command"
 	"github.com/juan-medina/cecibot/command/provider"
+	"github.com/juan-medina/cecibot/commands/raid/shared"
 	"github.com/juan-medina/cecibot/prototype"
 	"go.uber.org/zap"
+	"strings"
 )
 
+const maxPrefixLength = 5
+
 type systemCommands struct {
 	*provider.BaseProvider
 }
 
-func (d systemCommands) help(args []string, author string) string {
-	argc := len(args)
+func (d systemCommands) help(req *prototype.Request) *prototype.Response {
+	argc := len(req.Args)
 	if argc > 0 {
-		key := args[0]
+		key := req.Args[0]
 		help := d.GetProcessor().GetCommandHelp(key)
 		if help != "" {
-			return fmt.Sprintf("Command **%s** : \n%s", key, help)
+			return command.Text(fmt.Sprintf("Command **%s** : \n%s", key, help))
 		}
 
-		return "Unknown command in help. " + d.GetProcessor().GetHelp()
+		return command.Text("Unknown command in help. " + d.GetProcessor().GetHelp())
+	}
+	return command.Embed(d.GetProcessor().GetHelpEmbed())
+}
+
+func (d systemCommands) setPrefix(req *prototype.Request, prefix string) string {
+	log, _ := zap.NewProduction()
+	defer log.Sync()
+
+	if err := d.GetProcessor().SetPrefix(req.Guild, prefix); err != nil {
+		log.Error("Error setting prefix.", zap.Error(err))
+		return "there was an error changing the prefix, please try again later"
 	}
-	return d.GetProcessor().GetHelp()
+
+	if prefix == "" {
+		return "command prefix restored to the default"
+	}
+	return fmt.Sprintf("command prefix set to **%s**", prefix)
+}
+
+func (d systemCommands) prefix(req *prototype.Request) *prototype.Response {
+	if req.Guild == "" {
+		return command.Text("this command is only available in a **server**")
+	}
+
+	argc := len(req.Args)
+	if argc == 0 {
+		prefix := d.GetProcessor().GetPrefix(req.Guild)
+		if prefix == "" {
+			return command.Text("there is no command prefix, mention me to send commands")
+		}
+		return command.Text(fmt.Sprintf("the command prefix is **%s**", prefix))
+	}
+
+	sub := req.Args[0]
+	if sub != "set" && sub != "reset" {
+		return command.Text("")
+	}
+
+	prc := d.GetProcessor()
+	if !shared.IsOfficer(prc, prc.GetStore().Guild(req.Guild), req.Author) {
+		return command.Text(shared.OfficersOnly)
+	}
+
+	if sub == "reset" {
+		return command.Text(d.setPrefix(req, ""))
+	}
+
+	if argc < 2 {
+		return command.Text("")
+	}
+
+	prefix := req.Args[1]
+	if len(prefix) > maxPrefixLength || strings.ContainsAny(prefix, " \t\n") {
+		return command.Text(fmt.Sprintf("the prefix should have up to %d characters without spaces", maxPrefixLength))
+	}
+	return command.Text(d.setPrefix(req, prefix))
 }
 
 func New(p prototype.Processor) prototype.Provider {
@@ -39,6 +97,20 @@ func New(p prototype.Processor) prototype.Provider {
 		prov.help),
 	)
 
+	prov.AddCommand(command.New("prefix",
+		"Shows or changes the command *prefix*.",
+		`Usage:
+	**prefix**
+		shows the prefix for sending commands in this server without mentioning the bot
+*Options* for *officers* only are:
+	**prefix set** *prefix*
+		use the given *prefix* in this server, for example **prefix set !** allows to send **!raid list**
+	**prefix reset**
+		use the default prefix again
+`,
+		prov.prefix),
+	)
+
 	log.Info("System commands created", zap.Int("number of commands", len(*prov.GetCommands())))
 	return prov
 }
@@ -8,17 +8,47 @@ import (
 type Config interface {
 	GetOwner() string
 	GetToken() string
+	GetStorage() string
+	GetStoragePath() string
+	GetPrefix() string
+	GetApplicationId() string
+	GetPublicKey() string
+	GetInteractionsAddress() string
+	GetReminders() string
+	GetScheduleWeeks() string
+	GetTimezone() string
+	GetLegacyGuild() string
 }
 
 const configVariableNotSet = "config error, variable for %s not set"
 
+const defaultStorage = "memory"
+const defaultStoragePath = "cecibot.json"
+const defaultPrefix = ""
+const defaultReminders = "24h,1h"
+const defaultScheduleWeeks = "2"
+const defaultTimezone = ""
+const defaultLegacyGuild = ""
+
 var errNotTokenConfig = errors.New(fmt.Sprintf(configVariableNotSet, "TOKEN"))
 var errNotOwnerConfig = errors.New(fmt.Sprintf(configVariableNotSet, "OWNER"))
+var errNotApplicationIdConfig = errors.New(fmt.Sprintf(configVariableNotSet, "APPLICATION_ID"))
+var errNotPublicKeyConfig = errors.New(fmt.Sprintf(configVariableNotSet, "PUBLIC_KEY"))
 
 type config struct {
-	token    string
-	owner    string
-	provider Provider
+	token       string
+	owner       string
+	storage     string
+	storagePath string
+	prefix      string
+	application string
+	publicKey   string
+	address     string
+	reminders   string
+	weeks       string
+	timezone    string
+	legacyGuild string
+	provider    Provider
 }
 
 func (c config) GetOwner() string {
@@ -29,6 +59,54 @@ func (c config) GetToken() string {
 	return c.token
 }
 
+func (c config) GetStorage() string {
+	return c.storage
+}
+
+func (c config) GetStoragePath() string {
+	return c.storagePath
+}
+
+func (c config) GetPrefix() string {
+	return c.prefix
+}
+
+func (c config) GetApplicationId() string {
+	return c.application
+}
+
+func (c config) GetPublicKey() string {
+	return c.publicKey
+}
+
+func (c config) GetInteractionsAddress() string {
+	return c.address
+}
+
+func (c config) GetReminders() string {
+	return c.reminders
+}
+
+func (c config) GetScheduleWeeks() string {
+	return c.weeks
+}
+
+func (c config) GetTimezone() string {
+	return c.timezone
+}
+
+func (c config) GetLegacyGuild() string {
+	return c.legacyGuild
+}
+
+func (c config) getOptionalValue(key string, defaultValue string) (string, error) {
+	value, err := c.provider.getConfigValue(key)
+	if err == errKeyNotFound {
+		return defaultValue, nil
+	}
+	return value, err
+}
+
 func (c *config) read() error {
 	var err error = nil
 
@@ -42,7 +120,76 @@ func (c *config) read() error {
 		}
 	}
 
-	return err
+	if err != nil {
+		return err
+	}
+
+	c.storage, err = c.getOptionalValue("STORAGE", defaultStorage)
+	if err != nil {
+		return err
+	}
+
+	c.storagePath, err = c.getOptionalValue("STORAGE_PATH", defaultStoragePath)
+	if err != nil {
+		return err
+	}
+
+	c.prefix, err = c.getOptionalValue("PREFIX", defaultPrefix)
+	if err != nil {
+		return err
+	}
+
+	c.reminders, err = c.getOptionalValue("REMINDERS", defaultReminders)
+	if err != nil {
+		return err
+	}
+
+	c.weeks, err = c.getOptionalValue("SCHEDULE_WEEKS", defaultScheduleWeeks)
+	if err != nil {
+		return err
+	}
+
+	c.timezone, err = c.getOptionalValue("TIMEZONE", defaultTimezone)
+	if err != nil {
+		return err
+	}
+
+	c.legacyGuild, err = c.getOptionalValue("LEGACY_GUILD", defaultLegacyGuild)
+	if err != nil {
+		return err
+	}
+
+	return c.readInteractions()
+}
+
+// readInteractions reads the slash commands settings, that are only required when the endpoint address is set
+func (c *config) readInteractions() error {
+	var err error
+
+	c.address, err = c.getOptionalValue("INTERACTIONS_ADDRESS", "")
+	if err != nil {
+		return err
+	}
+
+	c.application, err = c.getOptionalValue("APPLICATION_ID", "")
+	if err != nil {
+		return err
+	}
+
+	c.publicKey, err = c.getOptionalValue("PUBLIC_KEY", "")
+	if err != nil {
+		return err
+	}
+
+	if c.address != "" {
+		if c.application == "" {
+			return errNotApplicationIdConfig
+		}
+		if c.publicKey == "" {
+			return errNotPublicKeyConfig
+		}
+	}
+	return nil
 }
 
 func FromProvider(provider Provider) (Config, error) {
@@ -112,3 +112,269 @@ func Test_config_read(t *testing.T) {
 		})
 	}
 }
+
+type MapProvider map[string]string
+
+func (m MapProvider) getConfigValue(key string) (string, error) {
+	if value, found := m[key]; found {
+		return value, nil
+	}
+	return "", errKeyNotFound
+}
+
+func Test_config_prefix(t *testing.T) {
+	tests := []struct {
+		name       string
+		provider   Provider
+		wantPrefix string
+	}{
+		{
+			"we should get no prefix by default",
+			MapProvider{"TOKEN": "fake token", "OWNER": "fake owner"},
+			"",
+		},
+		{
+			"we should get the configured prefix",
+			MapProvider{"TOKEN": "fake token", "OWNER": "fake owner", "PREFIX": "!"},
+			"!",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FromProvider(tt.provider)
+			if err != nil {
+				t.Errorf("FromProvider() error = %v", err)
+				return
+			}
+			if got.GetPrefix() != tt.wantPrefix {
+				t.Errorf("FromProvider() got prefix = %q, want %q", got.GetPrefix(), tt.wantPrefix)
+			}
+		})
+	}
+}
+
+func Test_config_reminders(t *testing.T) {
+	tests := []struct {
+		name          string
+		provider      Provider
+		wantReminders string
+	}{
+		{
+			"we should get the default reminders",
+			MapProvider{"TOKEN": "fake token", "OWNER": "fake owner"},
+			"24h,1h",
+		},
+		{
+			"we should get the configured reminders",
+			MapProvider{"TOKEN": "fake token", "OWNER": "fake owner", "REMINDERS": "2h,15m"},
+			"2h,15m",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FromProvider(tt.provider)
+			if err != nil {
+				t.Errorf("FromProvider() error = %v", err)
+				return
+			}
+			if got.GetReminders() != tt.wantReminders {
+				t.Errorf("FromProvider() got reminders = %q, want %q", got.GetReminders(), tt.wantReminders)
+			}
+		})
+	}
+}
+
+func Test_config_scheduleWeeks(t *testing.T) {
+	tests := []struct {
+		name      string
+		provider  Provider
+		wantWeeks string
+	}{
+		{
+			"we should get the default schedule weeks",
+			MapProvider{"TOKEN": "fake token", "OWNER": "fake owner"},
+			"2",
+		},
+		{
+			"we should get the configured schedule weeks",
+			MapProvider{"TOKEN": "fake token", "OWNER": "fake owner", "SCHEDULE_WEEKS": "4"},
+			"4",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FromProvider(tt.provider)
+			if err != nil {
+				t.Errorf("FromProvider() error = %v", err)
+				return
+			}
+			if got.GetScheduleWeeks() != tt.wantWeeks {
+				t.Errorf("FromProvider() got schedule weeks = %q, want %q", got.GetScheduleWeeks(), tt.wantWeeks)
+			}
+		})
+	}
+}
+
+func Test_config_timezone(t *testing.T) {
+	tests := []struct {
+		name         string
+		provider     Provider
+		wantTimezone string
+	}{
+		{
+			"we should get the local timezone by default",
+			MapProvider{"TOKEN": "fake token", "OWNER": "fake owner"},
+			"",
+		},
+		{
+			"we should get the configured timezone",
+			MapProvider{"TOKEN": "fake token", "OWNER": "fake owner", "TIMEZONE": "Europe/Madrid"},
+			"Europe/Madrid",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FromProvider(tt.provider)
+			if err != nil {
+				t.Errorf("FromProvider() error = %v", err)
+				return
+			}
+			if got.GetTimezone() != tt.wantTimezone {
+				t.Errorf("FromProvider() got timezone = %q, want %q", got.GetTimezone(), tt.wantTimezone)
+			}
+		})
+	}
+}
+
+func Test_config_legacyGuild(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider Provider
+		want     string
+	}{
+		{
+			"we should get no legacy guild by default",
+			MapProvider{"TOKEN": "fake token", "OWNER": "fake owner"},
+			"",
+		},
+		{
+			"we should get the configured legacy guild",
+			MapProvider{"TOKEN": "fake token", "OWNER": "fake owner", "LEGACY_GUILD": "guild1"},
+			"guild1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FromProvider(tt.provider)
+			if err != nil {
+				t.Errorf("FromProvider() error = %v", err)
+				return
+			}
+			if got.GetLegacyGuild() != tt.want {
+				t.Errorf("FromProvider() got legacy guild = %q, want %q", got.GetLegacyGuild(), tt.want)
+			}
+		})
+	}
+}
+
+func Test_config_interactions(t *testing.T) {
+	tests := []struct {
+		name            string
+		provider        Provider
+		wantAddress     string
+		wantApplication string
+		wantPublicKey   string
+		wantErr         error
+	}{
+		{
+			"we should get interactions disabled by default",
+			MapProvider{"TOKEN": "fake token", "OWNER": "fake owner"},
+			"",
+			"",
+			"",
+			nil,
+		},
+		{
+			"we should get the interactions config",
+			MapProvider{"TOKEN": "fake token", "OWNER": "fake owner", "INTERACTIONS_ADDRESS": ":8080", "APPLICATION_ID": "123", "PUBLIC_KEY": "abcd"},
+			":8080",
+			"123",
+			"abcd",
+			nil,
+		},
+		{
+			"we should get an application id error",
+			MapProvider{"TOKEN": "fake token", "OWNER": "fake owner", "INTERACTIONS_ADDRESS": ":8080", "PUBLIC_KEY": "abcd"},
+			"",
+			"",
+			"",
+			errNotApplicationIdConfig,
+		},
+		{
+			"we should get a public key error",
+			MapProvider{"TOKEN": "fake token", "OWNER": "fake owner", "INTERACTIONS_ADDRESS": ":8080", "APPLICATION_ID": "123"},
+			"",
+			"",
+			"",
+			errNotPublicKeyConfig,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FromProvider(tt.provider)
+			if err != tt.wantErr {
+				t.Errorf("FromProvider() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				return
+			}
+			if got.GetInteractionsAddress() != tt.wantAddress {
+				t.Errorf("FromProvider() got address = %q, want %q", got.GetInteractionsAddress(), tt.wantAddress)
+			}
+			if got.GetApplicationId() != tt.wantApplication {
+				t.Errorf("FromProvider() got application id = %q, want %q", got.GetApplicationId(), tt.wantApplication)
+			}
+			if got.GetPublicKey() != tt.wantPublicKey {
+				t.Errorf("FromProvider() got public key = %q, want %q", got.GetPublicKey(), tt.wantPublicKey)
+			}
+		})
+	}
+}
+
+func Test_config_storage(t *testing.T) {
+	tests := []struct {
+		name            string
+		provider        Provider
+		wantStorage     string
+		wantStoragePath string
+	}{
+		{
+			"we should get the default storage",
+			MapProvider{"TOKEN": "fake token", "OWNER": "fake owner"},
+			defaultStorage,
+			defaultStoragePath,
+		},
+		{
+			"we should get the configured storage",
+			MapProvider{"TOKEN": "fake token", "OWNER": "fake owner", "STORAGE": "file", "STORAGE_PATH": "/tmp/raids.json"},
+			"file",
+			"/tmp/raids.json",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FromProvider(tt.provider)
+			if err != nil {
+				t.Errorf("FromProvider() error = %v", err)
+				return
+			}
+			if got.GetStorage() != tt.wantStorage {
+				t.Errorf("FromProvider() got storage = %q, want %q", got.GetStorage(), tt.wantStorage)
+			}
+			if got.GetStoragePath() != tt.wantStoragePath {
+				t.Errorf("FromProvider() got storage path = %q, want %q", got.GetStoragePath(), tt.wantStoragePath)
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ go 1.13
 
 require (
 	github.com/bwmarrin/discordgo v0.20.1
+	github.com/mattn/go-sqlite3 v1.14.0
 	go.uber.org/atomic v1.4.0 // indirect
 	go.uber.org/multierr v1.2.0 // indirect
 	go.uber.org/zap v1.11.0
@@ -0,0 +1,132 @@
+package interactions
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/juan-medina/cecibot/prototype"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const apiURL = "https://discord.com/api/v10"
+
+type Client struct {
+	http        *http.Client
+	baseURL     string
+	application string
+	token       string
+}
+
+func NewClient(application string, token string) *Client {
+	return &Client{
+		http:        &http.Client{Timeout: 10 * time.Second},
+		baseURL:     apiURL,
+		application: application,
+		token:       token,
+	}
+}
+
+func (c *Client) do(method string, path string, body interface{}, result interface{}) error {
+	var payload io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		payload = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, c.baseURL+path, payload)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bot "+c.token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		text, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("discord api %s %s failed with status %d: %s", method, path, resp.StatusCode, text)
+	}
+
+	if result != nil {
+		return json.NewDecoder(resp.Body).Decode(result)
+	}
+	return nil
+}
+
+// description removes the markdown of a command description, that discord does not render, and limit its size
+func description(text string) string {
+	text = strings.TrimSpace(strings.Replace(text, "*", "", -1))
+	if utf8.RuneCountInString(text) > maxDescriptionLength {
+		text = string([]rune(text)[:maxDescriptionLength-3]) + "..."
+	}
+	if text == "" {
+		text = "-"
+	}
+	return text
+}
+
+func applicationCommands(commands []*prototype.Command) []applicationCommand {
+	result := make([]applicationCommand, 0, len(commands))
+	for _, cmd := range commands {
+		result = append(result, applicationCommand{
+			Name:        cmd.Key,
+			Description: description(cmd.Desc),
+			Options: []commandOption{{
+				Type:         stringOption,
+				Name:         argumentsOption,
+				Description:  "the arguments of the command, as in a message",
+				Autocomplete: cmd.Complete != nil,
+			}},
+		})
+	}
+	return result
+}
+
+func (c *Client) Register(commands []*prototype.Command) error {
+	return c.do(http.MethodPut, fmt.Sprintf("/applications/%s/commands", c.application), applicationCommands(commands), nil)
+}
+
+func (c *Client) followUp(token string, data messageData) (sentMessage, error) {
+	result := sentMessage{}
+	err := c.do(http.MethodPost, fmt.Sprintf("/webhooks/%s/%s", c.application, token), data, &result)
+	return result, err
+}
+
+func (c *Client) original(token string) (sentMessage, error) {
+	result := sentMessage{}
+	err := c.do(http.MethodGet, fmt.Sprintf("/webhooks/%s/%s/messages/@original", c.application, token), nil, &result)
+	return result, err
+}
+
+func (c *Client) react(channel string, messageId string, emoji string) error {
+	path := fmt.Sprintf("/channels/%s/messages/%s/reactions/%s/@me", channel, messageId, url.PathEscape(emoji))
+	return c.do(http.MethodPut, path, nil, nil)
+}
+
+func (c *Client) edit(channel string, messageId string, data editData) error {
+	return c.do(http.MethodPatch, fmt.Sprintf("/channels/%s/messages/%s", channel, messageId), data, nil)
+}
+
+// direct sends a message to the direct messages of an user, opening the channel first
+func (c *Client) direct(user string, data messageData) error {
+	channel := dmChannel{}
+	if err := c.do(http.MethodPost, "/users/@me/channels", dmRecipient{RecipientId: user}, &channel); err != nil {
+		return err
+	}
+	return c.do(http.MethodPost, fmt.Sprintf("/channels/%s/messages", channel.Id), data, nil)
+}
@@ -0,0 +1,60 @@
+package interactions
+
+import (
+	"github.com/juan-medina/cecibot/command"
+	"github.com/juan-medina/cecibot/prototype"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestClient_Register(t *testing.T) {
+	api := &fakeDiscordApi{}
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	client := NewClient("app1", "token1")
+	client.baseURL = server.URL
+
+	raid := command.New("raid", "Manage *raid* attendance.", "", nil)
+	raid.Complete = func(req *prototype.Request) []prototype.Choice {
+		return nil
+	}
+	commands := []*prototype.Command{
+		command.New("hello", "Say *hello*. "+strings.Repeat("x", 100), "", nil),
+		raid,
+	}
+
+	if err := client.Register(commands); err != nil {
+		t.Fatalf("want not error, got %v", err)
+	}
+
+	wantRequests := []string{"PUT /applications/app1/commands Bot token1"}
+	if !reflect.DeepEqual(api.requests, wantRequests) {
+		t.Errorf("want requests %v, got %v", wantRequests, api.requests)
+	}
+
+	wantBody := `[{"name":"hello","description":"Say hello. ` + strings.Repeat("x", 86) + `...",` +
+		`"options":[{"type":3,"name":"arguments","description":"the arguments of the command, as in a message","required":false,"autocomplete":false}]},` +
+		`{"name":"raid","description":"Manage raid attendance.",` +
+		`"options":[{"type":3,"name":"arguments","description":"the arguments of the command, as in a message","required":false,"autocomplete":true}]}]`
+	if api.bodies[0] != wantBody {
+		t.Errorf("want body %s, got %s", wantBody, api.bodies[0])
+	}
+}
+
+func TestClient_failure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	client := NewClient("app1", "token1")
+	client.baseURL = server.URL
+
+	if err := client.Register(nil); err == nil {
+		t.Errorf("want error, got nil")
+	}
+}
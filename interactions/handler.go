@@ -0,0 +1,307 @@
+// Package interactions serves the discord interactions endpoint, running slash commands through the processor.
+package interactions
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/bwmarrin/discordgo"
+	"github.com/juan-medina/cecibot/message"
+	"github.com/juan-medina/cecibot/prototype"
+	"go.uber.org/zap"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+const maxBodySize = 1 << 20
+
+const emptyResponse = "there is nothing to show, send **help** *command* to get help with a command"
+
+// filesNotAttached is added to the messages with files, that are only attached to the bot messages
+const filesNotAttached = "\n*%s* is only attached when the command is sent in a message"
+
+// userMention finds the users mentioned in the arguments, that discord does not resolve since they are plain text
+var userMention = regexp.MustCompile(`<@!?(\d+)>`)
+
+var errInvalidPublicKey = errors.New("invalid public key, it should be the hexadecimal key of the discord application")
+
+type Handler struct {
+	prc    prototype.Processor
+	key    ed25519.PublicKey
+	client *Client
+	now    func() time.Time
+	// SharedGuilds returns the servers shared with an user, for commands sent in direct messages
+	SharedGuilds func(userID string) []prototype.GuildRef
+	pending      sync.WaitGroup
+}
+
+func New(prc prototype.Processor, publicKey string, client *Client) (*Handler, error) {
+	key, err := hex.DecodeString(publicKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, errInvalidPublicKey
+	}
+
+	return &Handler{
+		prc:    prc,
+		key:    key,
+		client: client,
+		now:    time.Now,
+	}, nil
+}
+
+func (h *Handler) verify(r *http.Request) ([]byte, bool) {
+	signature, err := hex.DecodeString(r.Header.Get("X-Signature-Ed25519"))
+	if err != nil || len(signature) != ed25519.SignatureSize {
+		return nil, false
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, maxBodySize))
+	if err != nil {
+		return nil, false
+	}
+
+	signed := append([]byte(r.Header.Get("X-Signature-Timestamp")), body...)
+	return body, ed25519.Verify(h.key, signed, signature)
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log, _ := zap.NewProduction()
+	defer log.Sync()
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, valid := h.verify(r)
+	if !valid {
+		http.Error(w, "invalid request signature", http.StatusUnauthorized)
+		return
+	}
+
+	var i interaction
+	if err := json.Unmarshal(body, &i); err != nil {
+		log.Error("Error decoding interaction.", zap.Error(err))
+		http.Error(w, "invalid interaction", http.StatusBadRequest)
+		return
+	}
+
+	switch i.Type {
+	case pingInteraction:
+		h.reply(w, response{Type: pongResponse})
+	case commandInteraction:
+		h.command(w, &i)
+	case autocompleteInteraction:
+		h.autocomplete(w, &i)
+	default:
+		http.Error(w, "unknown interaction type", http.StatusBadRequest)
+	}
+}
+
+func (h *Handler) Wait() {
+	h.pending.Wait()
+}
+
+func (h *Handler) reply(w http.ResponseWriter, resp response) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log, _ := zap.NewProduction()
+		defer log.Sync()
+
+		log.Error("Error sending interaction response.", zap.Error(err))
+	}
+}
+
+func (i *interaction) arguments() string {
+	for _, opt := range i.Data.Options {
+		if opt.Name == argumentsOption {
+			return opt.Value
+		}
+	}
+	return ""
+}
+
+func (h *Handler) newRequest(i *interaction, text string) *prototype.Request {
+	log, _ := zap.NewProduction()
+
+	req := &prototype.Request{
+		Text:      text,
+		Guild:     i.GuildId,
+		Channel:   i.ChannelId,
+		MessageId: i.Id,
+		DM:        i.GuildId == "",
+		Timestamp: h.now(),
+	}
+
+	for _, match := range userMention.FindAllStringSubmatch(text, -1) {
+		req.Mentions = append(req.Mentions, match[1])
+	}
+
+	user := i.User
+	if i.Member != nil {
+		user = i.Member.User
+		req.AuthorName = i.Member.Nick
+		req.Roles = i.Member.Roles
+	}
+	if user != nil {
+		req.Author = user.ID
+		if req.AuthorName == "" {
+			req.AuthorName = user.Username
+		}
+	}
+
+	if req.DM && h.SharedGuilds != nil {
+		req.Guilds = h.SharedGuilds(req.Author)
+	}
+
+	req.Log = log.With(
+		zap.String("author", req.Author),
+		zap.String("guild", req.Guild),
+		zap.String("channel", req.Channel),
+		zap.String("interaction", i.Id),
+	)
+	return req
+}
+
+func messages(resp *prototype.Response) []messageData {
+	flags := 0
+	if resp.Private {
+		flags = ephemeralFlag
+	}
+
+	result := make([]messageData, 0)
+	for _, msg := range resp.Messages {
+		text := msg.Text
+		for _, file := range msg.Files {
+			text += fmt.Sprintf(filesNotAttached, file.Name)
+		}
+		chunks := message.Split(text, message.MaxLength)
+		for _, chunk := range chunks[:len(chunks)-1] {
+			result = append(result, messageData{Content: chunk, Flags: flags})
+		}
+		data := messageData{Content: chunks[len(chunks)-1], Flags: flags, reactions: msg.Reactions, sent: msg.Sent}
+		if msg.Embed != nil {
+			data.Embeds = []*discordgo.MessageEmbed{message.Embed(msg.Embed)}
+		}
+		result = append(result, data)
+	}
+	return result
+}
+
+// command runs a slash command, the reactions to the request are ignored since there is no message to react to
+func (h *Handler) command(w http.ResponseWriter, i *interaction) {
+	text := i.Data.Name
+	if args := i.arguments(); args != "" {
+		text += " " + args
+	}
+
+	resp := h.prc.ProcessMessage(h.newRequest(i, text))
+	if resp.IsEmpty() || len(resp.Messages) == 0 {
+		help := h.prc.GetCommandHelp(i.Data.Name)
+		if help == "" {
+			help = emptyResponse
+		}
+		h.reply(w, response{Type: messageResponse, Data: messageData{Content: help, Flags: ephemeralFlag}})
+		return
+	}
+
+	msgs := messages(resp)
+	h.reply(w, response{Type: messageResponse, Data: msgs[0]})
+
+	if (len(msgs) > 1 || hasHooks(msgs[0]) || len(resp.Edits) > 0 || len(resp.Notices) > 0) && h.client != nil {
+		h.pending.Add(1)
+		go h.followUp(i.Token, msgs, resp.Edits, resp.Notices)
+	}
+}
+
+func hasHooks(data messageData) bool {
+	return len(data.reactions) > 0 || data.sent != nil
+}
+
+func (h *Handler) created(data messageData, msg sentMessage, log *zap.Logger) {
+	for _, reaction := range data.reactions {
+		if err := h.client.react(msg.ChannelId, msg.Id, reaction); err != nil {
+			log.Error("Error adding reaction.", zap.Error(err))
+		}
+	}
+	if data.sent != nil {
+		data.sent(msg.ChannelId, msg.Id)
+	}
+}
+
+// followUp runs after replying, once discord has created the response message that the hooks need
+func (h *Handler) followUp(token string, msgs []messageData, edits []prototype.Edit, notices []prototype.Notice) {
+	defer h.pending.Done()
+
+	log, _ := zap.NewProduction()
+	defer log.Sync()
+
+	if hasHooks(msgs[0]) {
+		if msg, err := h.client.original(token); err != nil {
+			log.Error("Error getting the interaction response.", zap.Error(err))
+		} else {
+			h.created(msgs[0], msg, log)
+		}
+	}
+
+	for _, edit := range edits {
+		data := editData{Content: edit.Message.Text}
+		if edit.Message.Embed != nil {
+			data.Embeds = []*discordgo.MessageEmbed{message.Embed(edit.Message.Embed)}
+		}
+		if err := h.client.edit(edit.Channel, edit.MessageId, data); err != nil {
+			log.Error("Error editing message.", zap.Error(err))
+		}
+	}
+
+	for _, notice := range notices {
+		for _, data := range messages(&prototype.Response{Messages: []prototype.Message{notice.Message}}) {
+			if err := h.client.direct(notice.Member, data); err != nil {
+				log.Error("Error sending notice.", zap.Error(err))
+			}
+		}
+	}
+
+	for _, data := range msgs[1:] {
+		msg, err := h.client.followUp(token, data)
+		if err != nil {
+			log.Error("Error sending follow up message.", zap.Error(err))
+			return
+		}
+		h.created(data, msg, log)
+	}
+}
+
+// autocomplete suggests values for the argument being typed, keeping the arguments before it
+func (h *Handler) autocomplete(w http.ResponseWriter, i *interaction) {
+	partial := i.arguments()
+
+	base := ""
+	if index := strings.LastIndexAny(partial, " \t"); index >= 0 {
+		base = partial[:index+1]
+	}
+
+	result := make([]choice, 0)
+	for _, c := range h.prc.Complete(h.newRequest(i, i.Data.Name+" "+partial)) {
+		name, value := base+c.Name, base+c.Value
+		if len(value) > maxChoiceLength {
+			continue
+		}
+		if len(name) > maxChoiceLength {
+			name = value
+		}
+		result = append(result, choice{Name: name, Value: value})
+		if len(result) == maxChoices {
+			break
+		}
+	}
+
+	h.reply(w, response{Type: autocompleteResponse, Data: autocompleteData{Choices: result}})
+}
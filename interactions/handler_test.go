@@ -0,0 +1,428 @@
+package interactions
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/bwmarrin/discordgo"
+	"github.com/juan-medina/cecibot/command"
+	"github.com/juan-medina/cecibot/config"
+	"github.com/juan-medina/cecibot/interactions/interactionstest"
+	"github.com/juan-medina/cecibot/prototype"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type fakeProcessor struct {
+	lastRequest *prototype.Request
+	announced   string
+}
+
+func (f *fakeProcessor) ProcessMessage(req *prototype.Request) *prototype.Response {
+	f.lastRequest = req
+	switch req.Text {
+	case "hello":
+		return command.Text("hello " + req.AuthorName + "!")
+	case "raid roster 1":
+		return command.Embed(&prototype.Embed{Title: "Molten Core (1)", Footer: "Total : 0"})
+	case "raid secret":
+		resp := command.Text("secret")
+		resp.Private = true
+		return resp
+	case "raid long":
+		return command.Text(strings.Repeat(strings.Repeat("x", 99)+"\n", 30))
+	case "raid sign down 1":
+		resp := command.Text("signed down")
+		resp.Edits = []prototype.Edit{{Channel: "channel2", MessageId: "m1", Message: prototype.Message{Embed: &prototype.Embed{Title: "Molten Core (1)"}}}}
+		return resp
+	case "raid export raids":
+		return command.Files("raids exported", prototype.File{Name: "raids.csv", ContentType: "text/csv", Data: []byte("id,name\n")})
+	case "raid announce 1":
+		return &prototype.Response{Messages: []prototype.Message{{
+			Embed:     &prototype.Embed{Title: "Molten Core (1)"},
+			Reactions: []string{"💚"},
+			Sent: func(channel string, messageId string) {
+				f.announced = channel + "/" + messageId
+			},
+		}}}
+	case "raid bench 1 2":
+		resp := command.Text("benched")
+		resp.Notices = []prototype.Notice{{Member: "2", Message: prototype.Message{Text: "you have been benched"}}}
+		return resp
+	}
+	return command.Text("")
+}
+
+func (f *fakeProcessor) Init(bot prototype.Bot) error {
+	return nil
+}
+
+func (f *fakeProcessor) End() {
+}
+
+func (f *fakeProcessor) IsOwner(userId string) bool {
+	return false
+}
+
+func (f *fakeProcessor) GetCommandHelp(key string) string {
+	if key == "raid" {
+		return "raid help"
+	}
+	return ""
+}
+
+func (f *fakeProcessor) GetHelp() string {
+	return ""
+}
+
+func (f *fakeProcessor) GetHelpEmbed() *prototype.Embed {
+	return nil
+}
+
+func (f *fakeProcessor) GetConfig() config.Config {
+	return nil
+}
+
+func (f *fakeProcessor) GetStore() prototype.RaidDataStore {
+	return nil
+}
+
+func (f *fakeProcessor) GetPrefix(guild string) string {
+	return ""
+}
+
+func (f *fakeProcessor) SetPrefix(guild string, prefix string) error {
+	return nil
+}
+
+func (f *fakeProcessor) GetCommands() []*prototype.Command {
+	return nil
+}
+
+func (f *fakeProcessor) Send(channel string, message prototype.Message) {
+}
+
+func (f *fakeProcessor) ProcessReaction(reaction *prototype.Reaction) *prototype.Response {
+	return nil
+}
+
+func (f *fakeProcessor) Complete(req *prototype.Request) []prototype.Choice {
+	f.lastRequest = req
+	if req.Text == "raid sign up 1 Thrall sh" {
+		return []prototype.Choice{{Name: "Shaman", Value: "Shaman"}, {Name: "Long", Value: strings.Repeat("x", 100)}}
+	}
+	return nil
+}
+
+type fakeDiscordApi struct {
+	mu       sync.Mutex
+	requests []string
+	bodies   []string
+}
+
+func (f *fakeDiscordApi) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests = append(f.requests, r.Method+" "+r.URL.Path+" "+r.Header.Get("Authorization"))
+	f.bodies = append(f.bodies, string(body))
+	w.WriteHeader(http.StatusOK)
+	switch {
+	case r.URL.Path == "/users/@me/channels":
+		_, _ = w.Write([]byte(`{"id":"dm1"}`))
+	case strings.HasPrefix(r.URL.Path, "/webhooks/"):
+		_, _ = w.Write([]byte(`{"id":"m2","channel_id":"channel1"}`))
+	}
+}
+
+func newTestHandler(t *testing.T, prc prototype.Processor, api *fakeDiscordApi) (*Handler, *interactionstest.Signer, func()) {
+	t.Helper()
+
+	signer := interactionstest.NewSigner()
+	apiServer := httptest.NewServer(api)
+	client := NewClient("app1", "token1")
+	client.baseURL = apiServer.URL
+
+	handler, err := New(prc, signer.PublicKey(), client)
+	if err != nil {
+		t.Fatalf("want not error, got %v", err)
+	}
+	return handler, signer, apiServer.Close
+}
+
+func post(t *testing.T, handler http.Handler, signer *interactionstest.Signer, i interaction) (int, response) {
+	t.Helper()
+
+	req, err := signer.NewRequest("/interactions", i)
+	if err != nil {
+		t.Fatalf("want not error, got %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	var resp response
+	if rec.Code == http.StatusOK {
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("want a json response, got %q", rec.Body.String())
+		}
+	}
+	return rec.Code, resp
+}
+
+func commandInteractionOf(name string, arguments string) interaction {
+	i := interaction{
+		Type:      commandInteraction,
+		Id:        "i1",
+		Token:     "itoken",
+		GuildId:   "guild1",
+		ChannelId: "channel1",
+		Member: &member{
+			User:  &discordgo.User{ID: "456", Username: "thrall"},
+			Nick:  "Thrall",
+			Roles: []string{"r1"},
+		},
+		Data: commandData{Name: name},
+	}
+	if arguments != "" {
+		i.Data.Options = []option{{Name: argumentsOption, Type: stringOption, Value: arguments}}
+	}
+	return i
+}
+
+func TestNew(t *testing.T) {
+	if _, err := New(&fakeProcessor{}, "zzz", nil); err != errInvalidPublicKey {
+		t.Errorf("want invalid public key error, got %v", err)
+	}
+	if _, err := New(&fakeProcessor{}, "abcd", nil); err != errInvalidPublicKey {
+		t.Errorf("want invalid public key error with a short key, got %v", err)
+	}
+}
+
+func TestHandler_verify(t *testing.T) {
+	handler, signer, clean := newTestHandler(t, &fakeProcessor{}, &fakeDiscordApi{})
+	defer clean()
+
+	ping, _ := json.Marshal(interaction{Type: pingInteraction})
+
+	t.Run("should answer a signed ping", func(t *testing.T) {
+		code, resp := post(t, handler, signer, interaction{Type: pingInteraction})
+		if code != http.StatusOK || resp.Type != pongResponse {
+			t.Errorf("want pong, got %d %+v", code, resp)
+		}
+	})
+
+	t.Run("should reject requests without signature", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/interactions", bytes.NewReader(ping))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("want status %d, got %d", http.StatusUnauthorized, rec.Code)
+		}
+	})
+
+	t.Run("should reject tampered requests", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/interactions", bytes.NewReader([]byte(`{"type":2}`)))
+		signer.Sign(req, ping)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("want status %d, got %d", http.StatusUnauthorized, rec.Code)
+		}
+	})
+
+	t.Run("should reject other methods", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/interactions", nil)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusMethodNotAllowed {
+			t.Errorf("want status %d, got %d", http.StatusMethodNotAllowed, rec.Code)
+		}
+	})
+
+	t.Run("should reject unknown interactions", func(t *testing.T) {
+		if code, _ := post(t, handler, signer, interaction{Type: 99}); code != http.StatusBadRequest {
+			t.Errorf("want status %d, got %d", http.StatusBadRequest, code)
+		}
+	})
+}
+
+func TestHandler_command(t *testing.T) {
+	prc := &fakeProcessor{}
+	api := &fakeDiscordApi{}
+	handler, signer, clean := newTestHandler(t, prc, api)
+	defer clean()
+
+	t.Run("should run the command", func(t *testing.T) {
+		_, resp := post(t, handler, signer, commandInteractionOf("hello", ""))
+
+		want := response{Type: messageResponse, Data: map[string]interface{}{"content": "hello Thrall!"}}
+		if !reflect.DeepEqual(resp, want) {
+			t.Errorf("want %+v, got %+v", want, resp)
+		}
+
+		req := prc.lastRequest
+		if req.Author != "456" || req.Guild != "guild1" || req.Channel != "channel1" || req.DM || !reflect.DeepEqual(req.Roles, []string{"r1"}) {
+			t.Errorf("want request from the interaction, got %+v", req)
+		}
+	})
+
+	t.Run("should take the user mentions of the arguments", func(t *testing.T) {
+		post(t, handler, signer, commandInteractionOf("raid", "dkp award <@1> 50 <@!2> <@&3>"))
+
+		if want := []string{"1", "2"}; !reflect.DeepEqual(prc.lastRequest.Mentions, want) {
+			t.Errorf("want mentions %v, got %v", want, prc.lastRequest.Mentions)
+		}
+	})
+
+	t.Run("should reply with embeds", func(t *testing.T) {
+		_, resp := post(t, handler, signer, commandInteractionOf("raid", "roster 1"))
+
+		embeds := resp.Data.(map[string]interface{})["embeds"].([]interface{})
+		if len(embeds) != 1 || embeds[0].(map[string]interface{})["title"] != "Molten Core (1)" {
+			t.Errorf("want roster embed, got %+v", resp.Data)
+		}
+	})
+
+	t.Run("should tell that the files are not attached", func(t *testing.T) {
+		_, resp := post(t, handler, signer, commandInteractionOf("raid", "export raids"))
+
+		want := map[string]interface{}{"content": "raids exported\n*raids.csv* is only attached when the command is sent in a message"}
+		if !reflect.DeepEqual(resp.Data, want) {
+			t.Errorf("want %+v, got %+v", want, resp.Data)
+		}
+	})
+
+	t.Run("should reply privately", func(t *testing.T) {
+		_, resp := post(t, handler, signer, commandInteractionOf("raid", "secret"))
+
+		want := map[string]interface{}{"content": "secret", "flags": float64(ephemeralFlag)}
+		if !reflect.DeepEqual(resp.Data, want) {
+			t.Errorf("want %+v, got %+v", want, resp.Data)
+		}
+	})
+
+	t.Run("should reply with the help of the command when there is nothing to show", func(t *testing.T) {
+		_, resp := post(t, handler, signer, commandInteractionOf("raid", ""))
+
+		want := map[string]interface{}{"content": "raid help", "flags": float64(ephemeralFlag)}
+		if !reflect.DeepEqual(resp.Data, want) {
+			t.Errorf("want %+v, got %+v", want, resp.Data)
+		}
+	})
+
+	t.Run("should send long replies as follow up messages", func(t *testing.T) {
+		_, resp := post(t, handler, signer, commandInteractionOf("raid", "long"))
+		handler.Wait()
+
+		if content := resp.Data.(map[string]interface{})["content"].(string); len(content) > 2000 {
+			t.Errorf("want the first chunk in the response, got %d characters", len(content))
+		}
+
+		want := []string{"POST /webhooks/app1/itoken Bot token1"}
+		if !reflect.DeepEqual(api.requests, want) {
+			t.Errorf("want follow ups %v, got %v", want, api.requests)
+		}
+	})
+
+	t.Run("should edit messages", func(t *testing.T) {
+		api.requests = nil
+		api.bodies = nil
+		post(t, handler, signer, commandInteractionOf("raid", "sign down 1"))
+		handler.Wait()
+
+		want := []string{"PATCH /channels/channel2/messages/m1 Bot token1"}
+		if !reflect.DeepEqual(api.requests, want) {
+			t.Errorf("want edits %v, got %v", want, api.requests)
+		}
+		if wantBody := `{"content":"","embeds":[{"title":"Molten Core (1)"}]}`; len(api.bodies) != 1 || api.bodies[0] != wantBody {
+			t.Errorf("want body %s, got %v", wantBody, api.bodies)
+		}
+	})
+
+	t.Run("should send notices", func(t *testing.T) {
+		api.requests = nil
+		api.bodies = nil
+		post(t, handler, signer, commandInteractionOf("raid", "bench 1 2"))
+		handler.Wait()
+
+		want := []string{"POST /users/@me/channels Bot token1", "POST /channels/dm1/messages Bot token1"}
+		if !reflect.DeepEqual(api.requests, want) {
+			t.Errorf("want requests %v, got %v", want, api.requests)
+		}
+		wantBodies := []string{`{"recipient_id":"2"}`, `{"content":"you have been benched"}`}
+		if !reflect.DeepEqual(api.bodies, wantBodies) {
+			t.Errorf("want bodies %v, got %v", wantBodies, api.bodies)
+		}
+	})
+
+	t.Run("should run the hooks of the response message", func(t *testing.T) {
+		api.requests = nil
+		api.bodies = nil
+		post(t, handler, signer, commandInteractionOf("raid", "announce 1"))
+		handler.Wait()
+
+		want := []string{"GET /webhooks/app1/itoken/messages/@original Bot token1", "PUT /channels/channel1/messages/m2/reactions/💚/@me Bot token1"}
+		if !reflect.DeepEqual(api.requests, want) {
+			t.Errorf("want requests %v, got %v", want, api.requests)
+		}
+		if prc.announced != "channel1/m2" {
+			t.Errorf("want the message sent hook called, got %q", prc.announced)
+		}
+	})
+
+	t.Run("should resolve servers in direct messages", func(t *testing.T) {
+		handler.SharedGuilds = func(userID string) []prototype.GuildRef {
+			return []prototype.GuildRef{{Id: "guild1", Name: "First"}}
+		}
+		i := commandInteractionOf("hello", "")
+		i.GuildId = ""
+		i.Member = nil
+		i.User = &discordgo.User{ID: "456", Username: "thrall"}
+
+		_, resp := post(t, handler, signer, i)
+
+		if content := resp.Data.(map[string]interface{})["content"]; content != "hello thrall!" {
+			t.Errorf("want the username in direct messages, got %q", content)
+		}
+		if req := prc.lastRequest; !req.DM || len(req.Guilds) != 1 {
+			t.Errorf("want a direct message with shared guilds, got %+v", req)
+		}
+	})
+}
+
+func TestHandler_autocomplete(t *testing.T) {
+	prc := &fakeProcessor{}
+	handler, signer, clean := newTestHandler(t, prc, &fakeDiscordApi{})
+	defer clean()
+
+	i := commandInteractionOf("raid", "sign up 1 Thrall sh")
+	i.Type = autocompleteInteraction
+	i.Data.Options[0].Focused = true
+
+	_, resp := post(t, handler, signer, i)
+
+	want := response{Type: autocompleteResponse, Data: map[string]interface{}{
+		"choices": []interface{}{
+			map[string]interface{}{"name": "sign up 1 Thrall Shaman", "value": "sign up 1 Thrall Shaman"},
+		},
+	}}
+	if !reflect.DeepEqual(resp, want) {
+		t.Errorf("want %+v, got %+v", want, resp)
+	}
+
+	t.Run("should send empty choices", func(t *testing.T) {
+		i.Data.Options[0].Value = "zzz"
+		_, resp := post(t, handler, signer, i)
+
+		want := response{Type: autocompleteResponse, Data: map[string]interface{}{"choices": []interface{}{}}}
+		if !reflect.DeepEqual(resp, want) {
+			t.Errorf("want %+v, got %+v", want, resp)
+		}
+	})
+}
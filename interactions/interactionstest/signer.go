@@ -0,0 +1,51 @@
+// Package interactionstest signs interactions like discord does, for testing the endpoint without discord.
+package interactionstest
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// a fixed seed, so the test key is always the same
+var testSeed = []byte("cecibot interactions test seed!!")
+
+type Signer struct {
+	key ed25519.PrivateKey
+	now func() time.Time
+}
+
+func NewSigner() *Signer {
+	return &Signer{key: ed25519.NewKeyFromSeed(testSeed), now: time.Now}
+}
+
+func (s *Signer) PublicKey() string {
+	return hex.EncodeToString(s.key.Public().(ed25519.PublicKey))
+}
+
+func (s *Signer) Sign(req *http.Request, body []byte) {
+	timestamp := strconv.FormatInt(s.now().Unix(), 10)
+	signature := ed25519.Sign(s.key, append([]byte(timestamp), body...))
+
+	req.Header.Set("X-Signature-Ed25519", hex.EncodeToString(signature))
+	req.Header.Set("X-Signature-Timestamp", timestamp)
+}
+
+func (s *Signer) NewRequest(url string, interaction interface{}) (*http.Request, error) {
+	body, err := json.Marshal(interaction)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	s.Sign(req, body)
+	return req, nil
+}
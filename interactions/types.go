@@ -0,0 +1,111 @@
+package interactions
+
+import "github.com/bwmarrin/discordgo"
+
+const (
+	pingInteraction         = 1
+	commandInteraction      = 2
+	autocompleteInteraction = 4
+)
+
+const (
+	pongResponse         = 1
+	messageResponse      = 4
+	autocompleteResponse = 8
+)
+
+const ephemeralFlag = 64
+
+const stringOption = 3
+
+// every command has a single free text option with the same arguments that a message would have
+const argumentsOption = "arguments"
+
+const maxChoices = 25
+const maxChoiceLength = 100
+const maxDescriptionLength = 100
+
+type member struct {
+	User  *discordgo.User `json:"user"`
+	Nick  string          `json:"nick"`
+	Roles []string        `json:"roles"`
+}
+
+type option struct {
+	Name    string `json:"name"`
+	Type    int    `json:"type"`
+	Value   string `json:"value"`
+	Focused bool   `json:"focused,omitempty"`
+}
+
+type commandData struct {
+	Name    string   `json:"name"`
+	Options []option `json:"options"`
+}
+
+type interaction struct {
+	Type      int             `json:"type"`
+	Id        string          `json:"id"`
+	Token     string          `json:"token"`
+	GuildId   string          `json:"guild_id"`
+	ChannelId string          `json:"channel_id"`
+	Member    *member         `json:"member"`
+	User      *discordgo.User `json:"user"`
+	Data      commandData     `json:"data"`
+}
+
+// messageData is a message to send, its reactions are added and sent is called once discord has created it
+type messageData struct {
+	Content   string                    `json:"content,omitempty"`
+	Embeds    []*discordgo.MessageEmbed `json:"embeds,omitempty"`
+	Flags     int                       `json:"flags,omitempty"`
+	reactions []string
+	sent      func(channel string, messageId string)
+}
+
+type sentMessage struct {
+	Id        string `json:"id"`
+	ChannelId string `json:"channel_id"`
+}
+
+// editData always has the content, since an empty one removes the text of the message
+type editData struct {
+	Content string                    `json:"content"`
+	Embeds  []*discordgo.MessageEmbed `json:"embeds,omitempty"`
+}
+
+type dmRecipient struct {
+	RecipientId string `json:"recipient_id"`
+}
+
+type dmChannel struct {
+	Id string `json:"id"`
+}
+
+type choice struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type autocompleteData struct {
+	Choices []choice `json:"choices"`
+}
+
+type response struct {
+	Type int         `json:"type"`
+	Data interface{} `json:"data,omitempty"`
+}
+
+type commandOption struct {
+	Type         int    `json:"type"`
+	Name         string `json:"name"`
+	Description  string `json:"description"`
+	Required     bool   `json:"required"`
+	Autocomplete bool   `json:"autocomplete"`
+}
+
+type applicationCommand struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Options     []commandOption `json:"options,omitempty"`
+}
@@ -2,23 +2,56 @@ package main
 
 import (
 	"github.com/juan-medina/cecibot/bot"
+	"github.com/juan-medina/cecibot/commands/raid/data"
+	"github.com/juan-medina/cecibot/commands/raid/export"
 	"github.com/juan-medina/cecibot/config"
 	"go.uber.org/zap"
+	"os"
+	"time"
 )
 
-func main() {
+func migrate(log *zap.Logger, cfg config.Config) {
+	log.Info("Migrating raid data.", zap.String("storage", cfg.GetStorage()))
 
-	log, _ := zap.NewProduction()
-	defer log.Sync()
+	applied, err := data.Migrate(cfg)
+	if err != nil {
+		log.Error("Error migrating raid data", zap.Error(err))
+		return
+	}
 
-	log.Info("Reading config.")
-	var cfg, err = config.FromProvider(config.EnvironmentVariables())
+	log.Info("Raid data migrated.", zap.Ints("applied versions", applied))
+}
+
+// exportData writes to the standard output the raid data of a guild, as the raid export command does
+func exportData(log *zap.Logger, cfg config.Config, args []string) {
+	if len(args) < 2 {
+		log.Error("Missing arguments, use export guild-id raids|roster raid-id|attendance [csv|json] or export guild-id calendar")
+		return
+	}
 
+	store, err := data.New(cfg)
 	if err != nil {
-		log.Error("Error reading config", zap.Error(err))
+		log.Error("Error opening raid data", zap.Error(err))
+		return
+	}
+	defer store.Close()
+
+	log.Info("Exporting raid data.", zap.String("guild", args[0]), zap.Strings("export", args[1:]))
+	file, err := export.Export(store.Guild(args[0]), args[1:], time.Now())
+	if err != nil {
+		log.Error("Error exporting raid data", zap.Error(err))
+		return
+	}
+
+	if _, err := os.Stdout.Write(file.Data); err != nil {
+		log.Error("Error writing raid data", zap.Error(err))
 		return
 	}
 
+	log.Info("Raid data exported.", zap.String("file", file.Name))
+}
+
+func run(log *zap.Logger, cfg config.Config) {
 	log.Info("Creating bot.")
 
 	bt, err := bot.New(cfg)
@@ -37,5 +70,34 @@ func main() {
 	}
 
 	log.Info("Bot stopped.")
+}
+
+func main() {
 
+	log, _ := zap.NewProduction()
+	defer log.Sync()
+
+	log.Info("Reading config.")
+	var cfg, err = config.FromProvider(config.EnvironmentVariables())
+
+	if err != nil {
+		log.Error("Error reading config", zap.Error(err))
+		return
+	}
+
+	mode := "run"
+	if len(os.Args) > 1 {
+		mode = os.Args[1]
+	}
+
+	switch mode {
+	case "run":
+		run(log, cfg)
+	case "migrate":
+		migrate(log, cfg)
+	case "export":
+		exportData(log, cfg, os.Args[2:])
+	default:
+		log.Error("Unknown mode, valid modes are run, migrate and export", zap.String("mode", mode))
+	}
 }
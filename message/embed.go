@@ -0,0 +1,27 @@
+package message
+
+import (
+	"github.com/bwmarrin/discordgo"
+	"github.com/juan-medina/cecibot/prototype"
+)
+
+const MaxFieldLength = 1024
+
+func Embed(embed *prototype.Embed) *discordgo.MessageEmbed {
+	result := &discordgo.MessageEmbed{
+		Title:       embed.Title,
+		Description: embed.Description,
+		Color:       embed.Colour,
+	}
+	for _, field := range embed.Fields {
+		result.Fields = append(result.Fields, &discordgo.MessageEmbedField{
+			Name:   field.Name,
+			Value:  field.Value,
+			Inline: field.Inline,
+		})
+	}
+	if embed.Footer != "" {
+		result.Footer = &discordgo.MessageEmbedFooter{Text: embed.Footer}
+	}
+	return result
+}
@@ -0,0 +1,181 @@
+package message
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxLength is the longest message that discord accepts, we count bytes so we never go over the character limit
+const MaxLength = 2000
+
+const codeFence = "```"
+
+// markers that we keep balanced across chunks, longer markers first so "**" is not read as two "*"
+var inlineMarkers = []string{"**", "__", "~~", "*", "`"}
+
+type markdown struct {
+	fence  string   // the line that opened the current code block, if any
+	inline []string // inline markers currently open, in opening order
+}
+
+func (md *markdown) toggle(marker string) {
+	for i := len(md.inline) - 1; i >= 0; i-- {
+		if md.inline[i] == marker {
+			md.inline = append(md.inline[:i], md.inline[i+1:]...)
+			return
+		}
+	}
+	md.inline = append(md.inline, marker)
+}
+
+func (md markdown) inlineCode() bool {
+	return len(md.inline) > 0 && md.inline[len(md.inline)-1] == "`"
+}
+
+func (md *markdown) scan(line string) {
+	if md.fence != "" {
+		if strings.HasPrefix(strings.TrimSpace(line), codeFence) {
+			md.fence = ""
+		}
+		return
+	}
+
+	for i := 0; i < len(line); {
+		rest := line[i:]
+		if strings.HasPrefix(rest, codeFence) && !md.inlineCode() {
+			end := strings.Index(rest[len(codeFence):], codeFence)
+			if end < 0 {
+				md.fence = strings.TrimSpace(rest)
+				return
+			}
+			i += end + 2*len(codeFence)
+			continue
+		}
+		if rest[0] == '\\' {
+			i += 2
+			continue
+		}
+		matched := false
+		for _, marker := range inlineMarkers {
+			if strings.HasPrefix(rest, marker) && (marker == "`" || !md.inlineCode()) {
+				md.toggle(marker)
+				i += len(marker)
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			i++
+		}
+	}
+}
+
+func (md markdown) closing() string {
+	text := ""
+	if md.fence != "" {
+		text = "\n" + codeFence
+		if len(md.inline) > 0 {
+			text += "\n"
+		}
+	}
+	for i := len(md.inline) - 1; i >= 0; i-- {
+		text += md.inline[i]
+	}
+	return text
+}
+
+func (md markdown) opening() string {
+	text := strings.Join(md.inline, "")
+	if md.fence != "" {
+		if text != "" {
+			text += "\n"
+		}
+		text += md.fence + "\n"
+	}
+	return text
+}
+
+func (md markdown) clone() markdown {
+	return markdown{fence: md.fence, inline: append([]string(nil), md.inline...)}
+}
+
+// flush ends a chunk closing its formatting before the whitespace it ends with, so the text is not changed
+func flush(current string, state markdown) string {
+	closing := state.closing()
+	if closing == "" {
+		return current
+	}
+	body := strings.TrimRight(current, " \t\n")
+	return body + closing + current[len(body):]
+}
+
+// cutLine is the longest start of a line that fits in room, cut after a whitespace if there is any and never inside
+// a rune
+func cutLine(line string, room int) string {
+	if room >= len(line) {
+		return line
+	}
+	cut := room
+	for cut > 0 && !utf8.RuneStart(line[cut]) {
+		cut--
+	}
+	if space := strings.LastIndexAny(line[:cut], " \t"); space >= 0 {
+		cut = space + 1
+	}
+	return line[:cut]
+}
+
+// Split cuts on line boundaries, closing and reopening the formatting that is open between chunks, the lines longer
+// than a chunk are cut on whitespace
+func Split(text string, limit int) []string {
+	if len(text) <= limit {
+		return []string{text}
+	}
+
+	var chunks []string
+	state := markdown{}
+	current := ""
+	opening := ""
+
+	for _, line := range strings.SplitAfter(text, "\n") {
+		for line != "" {
+			next := state.clone()
+			next.scan(line)
+			if len(current)+len(line)+len(next.closing()) <= limit {
+				current += line
+				state = next
+				break
+			}
+
+			if current != opening {
+				chunks = append(chunks, flush(current, state))
+				opening = state.opening()
+				current = opening
+				continue
+			}
+
+			// the line does not fit in an empty chunk, take as much as fits of it
+			room := limit - len(current) - len(next.closing())
+			for {
+				part := cutLine(line, room)
+				partState := state.clone()
+				partState.scan(part)
+				if len(current)+len(part)+len(partState.closing()) <= limit || room <= 1 {
+					current += part
+					state = partState
+					line = line[len(part):]
+					break
+				}
+				room--
+			}
+			chunks = append(chunks, flush(current, state))
+			opening = state.opening()
+			current = opening
+		}
+	}
+
+	if current != opening || len(chunks) == 0 {
+		chunks = append(chunks, current)
+	}
+	return chunks
+}
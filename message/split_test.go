@@ -0,0 +1,119 @@
+package message
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_Split(t *testing.T) {
+	type args struct {
+		text  string
+		limit int
+	}
+	tests := []struct {
+		name string
+		args args
+		want []string
+	}{
+		{
+			name: "short messages are not split",
+			args: args{text: "hello\nworld", limit: 20},
+			want: []string{"hello\nworld"},
+		},
+		{
+			name: "it should split on line boundaries",
+			args: args{text: "first line\nsecond line\nthird line", limit: 25},
+			want: []string{"first line\nsecond line\n", "third line"},
+		},
+		{
+			name: "it should keep bold balanced",
+			args: args{text: "**bold line one\nbold line two\nend of bold**", limit: 30},
+			want: []string{"**bold line one**\n", "**bold line two\nend of bold**"},
+		},
+		{
+			name: "it should keep nested markers balanced",
+			args: args{text: "**a ~~one\ntwo three\nfour~~ b** c", limit: 24},
+			want: []string{"**a ~~one\ntwo three~~**\n", "**~~four~~ b** c"},
+		},
+		{
+			name: "it should keep code blocks balanced",
+			args: args{text: "```go\nline one\nline two\n```", limit: 24},
+			want: []string{"```go\nline one\n```\n", "```go\nline two\n```"},
+		},
+		{
+			name: "it should not read markers inside code",
+			args: args{text: "`a*b` text\nline two\n`c` end", limit: 20},
+			want: []string{"`a*b` text\nline two\n", "`c` end"},
+		},
+		{
+			name: "it should ignore escaped markers",
+			args: args{text: "\\*one two\nthree four\nfive", limit: 22},
+			want: []string{"\\*one two\nthree four\n", "five"},
+		},
+		{
+			name: "it should split long lines on spaces",
+			args: args{text: "one two three four five six", limit: 20},
+			want: []string{"one two three four ", "five six"},
+		},
+		{
+			name: "it should only split the lines that do not fit",
+			args: args{text: "short\none two three four five", limit: 20},
+			want: []string{"short\n", "one two three four ", "five"},
+		},
+		{
+			name: "it should split long words without breaking runes",
+			args: args{text: "ñññññññññññ", limit: 10},
+			want: []string{"ñññññ", "ñññññ", "ñ"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Split(tt.args.text, tt.args.limit)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("want %q, got %q", tt.want, got)
+			}
+			for _, chunk := range got {
+				if len(chunk) > tt.args.limit {
+					t.Errorf("want chunks up to %d, got %d in %q", tt.args.limit, len(chunk), chunk)
+				}
+			}
+		})
+	}
+}
+
+func TestSplit_keepsText(t *testing.T) {
+	texts := []string{
+		"first line\nsecond line\nthird line",
+		"one two three four five six",
+		"short\none two three four five\n\nlast",
+		"ñññññññññññ",
+	}
+	for _, text := range texts {
+		if joined := strings.Join(Split(text, 20), ""); joined != text {
+			t.Errorf("want %q, got %q", text, joined)
+		}
+	}
+}
+
+func TestSplit_discordLimit(t *testing.T) {
+	text := "**roster**\n" + strings.Repeat("**Char** *Warrior* *Protection* <@123456789012345678>\n", 200)
+
+	chunks := Split(text, MaxLength)
+
+	if len(chunks) < 2 {
+		t.Fatalf("want the message split, got %d chunks", len(chunks))
+	}
+	for _, chunk := range chunks {
+		if len(chunk) > MaxLength {
+			t.Errorf("want chunks up to %d, got %d", MaxLength, len(chunk))
+		}
+		if strings.Count(chunk, "**")%2 != 0 {
+			t.Errorf("want balanced bold in chunk, got %q", chunk)
+		}
+	}
+
+	if joined := strings.Join(chunks, ""); joined != text {
+		t.Errorf("want chunks to keep all the text")
+	}
+}
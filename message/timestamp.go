@@ -0,0 +1,11 @@
+package message
+
+import (
+	"fmt"
+	"time"
+)
+
+// Timestamp writes a date with the discord markup that shows it in the time zone of each reader
+func Timestamp(date time.Time) string {
+	return fmt.Sprintf("<t:%d:F>", date.Unix())
+}
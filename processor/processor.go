@@ -2,18 +2,38 @@ package processor
 
 import (
 	"fmt"
+	"github.com/juan-medina/cecibot/command"
 	"github.com/juan-medina/cecibot/commands"
+	"github.com/juan-medina/cecibot/commands/raid/data"
+	"github.com/juan-medina/cecibot/config"
 	"github.com/juan-medina/cecibot/prototype"
 	"go.uber.org/zap"
+	"sort"
 	"strings"
+	"sync"
 	"unicode"
 )
 
+const helpColour = 0x3498db
+
+const prefixSetting = "prefix"
+
 type processorImpl struct {
-	bot      prototype.Bot
-	owner    string
-	commands prototype.CommandsMap
-	help     string
+	bot       prototype.Bot
+	owner     string
+	prefix    string
+	store     prototype.RaidDataStore
+	commands  prototype.CommandsMap
+	providers []prototype.Provider
+	help      string
+	helpEmbed *prototype.Embed
+	prefixes  *prefixCache
+}
+
+// prefixCache keeps the prefix of each guild, so the messages do not read the storage
+type prefixCache struct {
+	mu       sync.Mutex
+	prefixes map[string]string
 }
 
 func (p *processorImpl) AddCommand(cmd *prototype.Command) {
@@ -21,13 +41,27 @@ func (p *processorImpl) AddCommand(cmd *prototype.Command) {
 }
 
 func (p *processorImpl) generateHelp() {
+	keys := make([]string, 0, len(p.commands))
+	for key := range p.commands {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
 	help := "Available commands are:"
-	for key, cmd := range p.commands {
+	embed := &prototype.Embed{
+		Title:  "Available commands",
+		Colour: helpColour,
+		Fields: make([]prototype.EmbedField, 0, len(keys)),
+		Footer: "To get help on any command send: help command",
+	}
+	for _, key := range keys {
+		cmd := p.commands[key]
 		help += fmt.Sprintf("\n\t **%s** : %q", key, cmd.Desc)
+		embed.Fields = append(embed.Fields, prototype.EmbedField{Name: key, Value: cmd.Desc})
 	}
 	help += "\n\nTo get help on any *command* send:\n\t**help** *command*"
 	p.help = help
-
+	p.helpEmbed = embed
 }
 
 func (p *processorImpl) addCommands(provider prototype.Provider) {
@@ -38,6 +72,7 @@ func (p *processorImpl) addCommands(provider prototype.Provider) {
 
 func (p *processorImpl) configure() {
 	p.owner = p.bot.GetConfig().GetOwner()
+	p.prefix = p.bot.GetConfig().GetPrefix()
 }
 
 func (p *processorImpl) Init(bot prototype.Bot) error {
@@ -51,10 +86,19 @@ func (p *processorImpl) Init(bot prototype.Bot) error {
 	log.Info("Configuring processor.")
 	p.configure()
 
+	log.Info("Opening raid data.")
+	store, err := data.New(p.bot.GetConfig())
+	if err != nil {
+		return err
+	}
+	p.store = store
+
 	log.Info("Adding commands.")
-	for _, prov := range commands.New(p) {
+	providers := commands.New(p)
+	for _, prov := range providers {
 		p.addCommands(prov)
 	}
+	p.providers = providers
 	log.Info("Commands added.", zap.Int("number of commands", len(p.commands)))
 
 	log.Info("Generating commands help.")
@@ -68,11 +112,26 @@ func (p processorImpl) End() {
 	log, _ := zap.NewProduction()
 	defer log.Sync()
 
+	log.Info("Ending command providers.")
+	for _, prov := range p.providers {
+		prov.End()
+	}
+
+	if p.store != nil {
+		log.Info("Closing raid data.")
+		if err := p.store.Close(); err != nil {
+			log.Error("Error closing raid data.", zap.Error(err))
+		}
+	}
+
 	log.Info("Processor end.")
 }
 
 func New() *prototype.Processor {
-	var prc prototype.Processor = &processorImpl{commands: make(prototype.CommandsMap)}
+	var prc prototype.Processor = &processorImpl{
+		commands: make(prototype.CommandsMap),
+		prefixes: &prefixCache{prefixes: make(map[string]string)},
+	}
 	return &prc
 }
 
@@ -88,10 +147,91 @@ func (p processorImpl) GetCommandHelp(key string) string {
 	return ""
 }
 
+func (p processorImpl) GetConfig() config.Config {
+	return p.bot.GetConfig()
+}
+
+func (p processorImpl) GetStore() prototype.RaidDataStore {
+	return p.store
+}
+
+func (p processorImpl) GetPrefix(guild string) string {
+	if guild == "" || p.store == nil {
+		return p.prefix
+	}
+
+	p.prefixes.mu.Lock()
+	defer p.prefixes.mu.Unlock()
+
+	prefix, found := p.prefixes.prefixes[guild]
+	if !found {
+		var err error
+		prefix, err = p.store.Guild(guild).GetSetting(prefixSetting)
+		if err != nil {
+			log, _ := zap.NewProduction()
+			defer log.Sync()
+
+			log.Error("Error reading guild prefix.", zap.Error(err))
+			return p.prefix
+		}
+		p.prefixes.prefixes[guild] = prefix
+	}
+
+	if prefix != "" {
+		return prefix
+	}
+	return p.prefix
+}
+
+// an empty prefix restores the configured one
+func (p processorImpl) SetPrefix(guild string, prefix string) error {
+	p.prefixes.mu.Lock()
+	defer p.prefixes.mu.Unlock()
+
+	if err := p.store.Guild(guild).SetSetting(prefixSetting, prefix); err != nil {
+		return err
+	}
+	p.prefixes.prefixes[guild] = prefix
+	return nil
+}
+
 func (p processorImpl) GetHelp() string {
 	return p.help
 }
 
+func (p processorImpl) GetHelpEmbed() *prototype.Embed {
+	return p.helpEmbed
+}
+
+func (p processorImpl) GetCommands() []*prototype.Command {
+	keys := make([]string, 0, len(p.commands))
+	for key := range p.commands {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	result := make([]*prototype.Command, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, p.commands[key])
+	}
+	return result
+}
+
+// Complete suggests values for the last argument of a command, an empty argument is added after a trailing space
+func (p processorImpl) Complete(req *prototype.Request) []prototype.Choice {
+	key, args := p.parseCommand(req.Text)
+	cmd, found := p.commands[key]
+	if !found || cmd.Complete == nil {
+		return nil
+	}
+
+	if strings.HasSuffix(req.Text, " ") {
+		args = append(args, "")
+	}
+	req.Args = args
+	return cmd.Complete(req)
+}
+
 func (p processorImpl) parseCommand(text string) (key string, args []string) {
 	var m []string = nil
 	var s string
@@ -138,13 +278,32 @@ func (p processorImpl) parseCommand(text string) (key string, args []string) {
 	return m[0], m[1:]
 }
 
-func (p processorImpl) ProcessMessage(text string, author string) string {
+func (p processorImpl) ProcessMessage(req *prototype.Request) *prototype.Response {
 
-	key, args := p.parseCommand(text)
+	key, args := p.parseCommand(req.Text)
 	cmd, found := p.commands[key]
 	if found {
-		return cmd.Fun(args, author)
+		req.Args = args
+		return cmd.Fun(req)
+	}
+
+	return command.Text("Unknown command. " + p.help)
+}
+
+func (p processorImpl) ProcessReaction(reaction *prototype.Reaction) *prototype.Response {
+	for _, prov := range p.providers {
+		if handler, ok := prov.(prototype.ReactionHandler); ok {
+			if response := handler.OnReaction(reaction); !response.IsEmpty() {
+				return response
+			}
+		}
 	}
+	return nil
+}
 
-	return "Unknown command. " + p.help
+// Send posts a message to a channel, if the bot could send messages that are not a reply
+func (p processorImpl) Send(channel string, message prototype.Message) {
+	if sender, ok := p.bot.(prototype.Sender); ok {
+		sender.Send(channel, message)
+	}
 }
@@ -1,12 +1,17 @@
 package processor
 
 import (
+	"github.com/juan-medina/cecibot/commands/raid/data/entities"
 	"github.com/juan-medina/cecibot/config"
+	"github.com/juan-medina/cecibot/prototype"
 	"reflect"
 	"testing"
+	"time"
 )
 
 type fakeCfg struct {
+	storage string
+	prefix  string
 }
 
 func (f fakeCfg) GetOwner() string {
@@ -17,6 +22,49 @@ func (f fakeCfg) GetToken() string {
 	return "12345"
 }
 
+func (f fakeCfg) GetStorage() string {
+	if f.storage == "" {
+		return "memory"
+	}
+	return f.storage
+}
+
+func (f fakeCfg) GetStoragePath() string {
+	return ""
+}
+
+func (f fakeCfg) GetPrefix() string {
+	return f.prefix
+}
+
+func (f fakeCfg) GetApplicationId() string {
+	return ""
+}
+
+func (f fakeCfg) GetPublicKey() string {
+	return ""
+}
+
+func (f fakeCfg) GetInteractionsAddress() string {
+	return ""
+}
+
+func (f fakeCfg) GetReminders() string {
+	return "none"
+}
+
+func (f fakeCfg) GetScheduleWeeks() string {
+	return "2"
+}
+
+func (f fakeCfg) GetLegacyGuild() string {
+	return ""
+}
+
+func (f fakeCfg) GetTimezone() string {
+	return ""
+}
+
 type fakeBot struct {
 	cfg config.Config
 }
@@ -65,7 +113,7 @@ func TestDefaultProcessor_ProcessMessage(t *testing.T) {
 		{
 			"help command",
 			"help",
-			help,
+			proc.GetHelpEmbed().String(),
 			"6789",
 		},
 		{
@@ -86,11 +134,47 @@ func TestDefaultProcessor_ProcessMessage(t *testing.T) {
 			"Unknown command. " + help,
 			"6789",
 		},
+		{
+			"prefix without one",
+			"prefix",
+			"there is no command prefix, mention me to send commands",
+			"6789",
+		},
+		{
+			"set prefix by a member",
+			"prefix set !",
+			"this command is for **officers** only",
+			"6789",
+		},
+		{
+			"set a long prefix",
+			"prefix set !!!!!!",
+			"the prefix should have up to 5 characters without spaces",
+			cfg.GetOwner(),
+		},
+		{
+			"set prefix by the owner",
+			"prefix set !",
+			"command prefix set to **!**",
+			cfg.GetOwner(),
+		},
+		{
+			"prefix after setting it",
+			"prefix",
+			"the command prefix is **!**",
+			"6789",
+		},
+		{
+			"reset prefix",
+			"prefix reset",
+			"command prefix restored to the default",
+			cfg.GetOwner(),
+		},
 	}
 
 	for _, tt := range cases {
 		t.Run(tt.name, func(t *testing.T) {
-			got := proc.ProcessMessage(tt.text, tt.user)
+			got := proc.ProcessMessage(&prototype.Request{Text: tt.text, Author: tt.user, Guild: "guild1"}).String()
 			if got != tt.want {
 				t.Errorf("processor error want %q, got %q", tt.want, got)
 			}
@@ -178,3 +262,134 @@ func TestDefaultProcessor_parseCommand(t *testing.T) {
 	}
 
 }
+
+func TestDefaultProcessor_GetHelpEmbed(t *testing.T) {
+	proc := *New()
+	_ = proc.Init(fakeBot{cfg: fakeCfg{}})
+
+	embed := proc.GetHelpEmbed()
+	if embed == nil {
+		t.Errorf("want help embed, got nil")
+		return
+	}
+
+	got := make([]string, 0)
+	for _, field := range embed.Fields {
+		got = append(got, field.Name)
+	}
+	want := []string{"dkp", "hello", "help", "loot", "ping", "prefix", "raid"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want commands %v, got %v", want, got)
+	}
+
+	resp := proc.ProcessMessage(&prototype.Request{Text: "help", Author: "6789"})
+	if len(resp.Messages) != 1 || resp.Messages[0].Embed != embed {
+		t.Errorf("want help embed response, got %+v", resp)
+	}
+}
+
+func TestDefaultProcessor_GetPrefix(t *testing.T) {
+	proc := *New()
+	if err := proc.Init(fakeBot{cfg: fakeCfg{prefix: "!"}}); err != nil {
+		t.Fatalf("want not error, got %v", err)
+	}
+	defer proc.End()
+
+	if got := proc.GetPrefix("guild1"); got != "!" {
+		t.Errorf("want configured prefix %q, got %q", "!", got)
+	}
+
+	if err := proc.SetPrefix("guild1", "?"); err != nil {
+		t.Fatalf("want not error, got %v", err)
+	}
+	if got := proc.GetPrefix("guild1"); got != "?" {
+		t.Errorf("want guild prefix %q, got %q", "?", got)
+	}
+	if got := proc.GetPrefix("guild2"); got != "!" {
+		t.Errorf("want configured prefix in other guild %q, got %q", "!", got)
+	}
+	if err := proc.GetStore().Guild("guild2").SetSetting(prefixSetting, "#"); err != nil {
+		t.Fatalf("want not error, got %v", err)
+	}
+	if got := proc.GetPrefix("guild2"); got != "!" {
+		t.Errorf("want cached prefix in other guild %q, got %q", "!", got)
+	}
+	if got := proc.GetPrefix(""); got != "!" {
+		t.Errorf("want configured prefix without guild %q, got %q", "!", got)
+	}
+
+	if err := proc.SetPrefix("guild1", ""); err != nil {
+		t.Fatalf("want not error, got %v", err)
+	}
+	if got := proc.GetPrefix("guild1"); got != "!" {
+		t.Errorf("want configured prefix after reset %q, got %q", "!", got)
+	}
+}
+
+func TestDefaultProcessor_Init_invalidStorage(t *testing.T) {
+	proc := *New()
+	if err := proc.Init(fakeBot{cfg: fakeCfg{storage: "zzz"}}); err == nil {
+		t.Errorf("want error, got nil")
+	}
+}
+
+func TestDefaultProcessor_GetCommands(t *testing.T) {
+	proc := *New()
+	_ = proc.Init(fakeBot{cfg: fakeCfg{}})
+	defer proc.End()
+
+	got := make([]string, 0)
+	for _, cmd := range proc.GetCommands() {
+		got = append(got, cmd.Key)
+	}
+	want := []string{"dkp", "hello", "help", "loot", "ping", "prefix", "raid"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want commands %v, got %v", want, got)
+	}
+}
+
+func TestDefaultProcessor_Complete(t *testing.T) {
+	proc := *New()
+	_ = proc.Init(fakeBot{cfg: fakeCfg{}})
+	defer proc.End()
+
+	tests := []struct {
+		name string
+		text string
+		want []prototype.Choice
+	}{
+		{"complete the last argument", "raid si", []prototype.Choice{{Name: "sign", Value: "sign"}}},
+		{"complete a new argument after a space", "raid sign ", []prototype.Choice{{Name: "up", Value: "up"}, {Name: "down", Value: "down"}}},
+		{"commands without completion", "ping ", nil},
+		{"unknown commands", "zzz ", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := proc.Complete(&prototype.Request{Text: tt.text, Author: "6789", Guild: "guild1"})
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("want %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestDefaultProcessor_ProcessReaction(t *testing.T) {
+	proc := *New()
+	_ = proc.Init(fakeBot{cfg: fakeCfg{}})
+	defer proc.End()
+
+	data := proc.GetStore().Guild("guild1")
+	raid, _ := data.AddRaid(entities.Raid{Name: "Molten Core", Date: time.Now().Add(24 * time.Hour)})
+	_ = data.AddAnnouncement(raid.Id, "m1")
+
+	reaction := &prototype.Reaction{Emoji: "💚", Member: "6789", MemberName: "Thrall", Guild: "guild1", MessageId: "m1", Added: true}
+	want := "signed up as **Healer** for raid **Molten Core** (**1**)"
+	if got := proc.ProcessReaction(reaction).String(); got != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+
+	reaction.MessageId = "m2"
+	if got := proc.ProcessReaction(reaction); !got.IsEmpty() {
+		t.Errorf("want no response for other messages, got %+v", got)
+	}
+}
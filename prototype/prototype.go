@@ -3,6 +3,9 @@ package prototype
 import (
 	"github.com/juan-medina/cecibot/commands/raid/data/entities"
 	"github.com/juan-medina/cecibot/config"
+	"go.uber.org/zap"
+	"strings"
+	"time"
 )
 
 type Bot interface {
@@ -11,21 +14,171 @@ type Bot interface {
 }
 
 type Processor interface {
-	ProcessMessage(text string, author string) string
+	ProcessMessage(req *Request) *Response
 	Init(bot Bot) error
 	End()
 	IsOwner(userId string) bool
 	GetCommandHelp(key string) string
 	GetHelp() string
+	GetHelpEmbed() *Embed
+	GetConfig() config.Config
+	GetStore() RaidDataStore
+	GetPrefix(guild string) string
+	SetPrefix(guild string, prefix string) error
+	GetCommands() []*Command
+	Complete(req *Request) []Choice
+	ProcessReaction(reaction *Reaction) *Response
+	Send(channel string, message Message)
+}
+
+type Sender interface {
+	Send(channel string, message Message)
+	SendDirect(member string, message Message)
+}
+
+type GuildRef struct {
+	Id   string
+	Name string
+}
+
+// in direct messages Guilds are the servers shared with the author
+type Request struct {
+	Text        string
+	Args        []string
+	Author      string
+	AuthorName  string
+	Guild       string
+	Channel     string
+	MessageId   string
+	DM          bool
+	Guilds      []GuildRef
+	Mentions    []string
+	Roles       []string
+	Attachments []string
+	Timestamp   time.Time
+	Log         *zap.Logger
+}
+
+type EmbedField struct {
+	Name   string
+	Value  string
+	Inline bool
+}
+
+type Embed struct {
+	Title       string
+	Description string
+	Colour      int
+	Fields      []EmbedField
+	Footer      string
+}
+
+type File struct {
+	Name        string
+	ContentType string
+	Data        []byte
+}
+
+// Message is a message to send, Reactions are added to it once sent and Sent is called with where it was sent
+type Message struct {
+	Text      string
+	Embed     *Embed
+	Files     []File
+	Reactions []string
+	Sent      func(channel string, messageId string)
 }
 
-type CommandFunction func(args []string, author string) string
+type Reaction struct {
+	Emoji      string
+	Member     string
+	MemberName string
+	Guild      string
+	Channel    string
+	MessageId  string
+	Added      bool
+	Log        *zap.Logger
+}
+
+type Edit struct {
+	Channel   string
+	MessageId string
+	Message   Message
+}
+
+// Notice is a message sent to the direct messages of a member, other than the one that sent the request
+type Notice struct {
+	Member  string
+	Message Message
+}
+
+// Response is what a command replies, the messages are sent in order, the reactions added to the request message,
+// the edits applied to previous messages and the notices sent to other members
+type Response struct {
+	Messages  []Message
+	Reactions []string
+	Edits     []Edit
+	Notices   []Notice
+	Private   bool
+}
+
+func (r *Response) IsEmpty() bool {
+	return r == nil || (len(r.Messages) == 0 && len(r.Reactions) == 0 && len(r.Edits) == 0 && len(r.Notices) == 0)
+}
+
+func (r *Response) String() string {
+	if r == nil {
+		return ""
+	}
+
+	lines := make([]string, 0)
+	for _, msg := range r.Messages {
+		if msg.Text != "" {
+			lines = append(lines, msg.Text)
+		}
+		if msg.Embed != nil {
+			lines = append(lines, msg.Embed.String())
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (e *Embed) String() string {
+	lines := make([]string, 0)
+	if e.Title != "" {
+		lines = append(lines, "**"+e.Title+"**")
+	}
+	if e.Description != "" {
+		lines = append(lines, e.Description)
+	}
+	for _, field := range e.Fields {
+		lines = append(lines, "**"+field.Name+"**", field.Value)
+	}
+	if e.Footer != "" {
+		lines = append(lines, e.Footer)
+	}
+	return strings.Join(lines, "\n")
+}
+
+type CommandFunction func(req *Request) *Response
+
+// Choice is a suggested value for an argument, Name is what is shown to the user
+type Choice struct {
+	Name  string
+	Value string
+}
+
+// CompleteFunction suggests values for the last argument of a request, that may be partially typed
+type CompleteFunction func(req *Request) []Choice
+
+// SimpleCommandFunction is a command that only needs the arguments and the author, use command.Adapt to run it
+type SimpleCommandFunction func(args []string, author string) string
 
 type Command struct {
-	Key  string
-	Desc string
-	Fun  CommandFunction
-	Help string
+	Key      string
+	Desc     string
+	Fun      CommandFunction
+	Help     string
+	Complete CompleteFunction
 }
 
 type CommandsMap map[string]*Command
@@ -34,10 +187,48 @@ type Provider interface {
 	GetCommands() *CommandsMap
 	AddCommand(cmd *Command)
 	GetProcessor() Processor
+	End()
+}
+
+type ReactionHandler interface {
+	OnReaction(reaction *Reaction) *Response
 }
 
 type RaidDataProvider interface {
-	AddOfficer(id string)
-	DeleteOfficer(id string)
-	GetOfficers() []entities.Officer
+	AddOfficer(id string) error
+	DeleteOfficer(id string) error
+	GetOfficers() ([]entities.Officer, error)
+	AddRaid(raid entities.Raid) (entities.Raid, error)
+	GetRaid(id string) (entities.Raid, error)
+	GetRaids() ([]entities.Raid, error)
+	UpdateRaid(raid entities.Raid) error
+	DeleteRaid(id string) error
+	SignUp(raidId string, signup entities.Signup) error
+	SignDown(raidId string, member string) error
+	GetSignups(raidId string) ([]entities.Signup, error)
+	SetSignupStatus(raidId string, member string, status string) error
+	CloseRaid(raidId string, attendance []entities.Attendance) error
+	GetAttendance(raidId string) ([]entities.Attendance, error)
+	GetSetting(key string) (string, error)
+	SetSetting(key string, value string) error
+	AddAnnouncement(raidId string, messageId string) error
+	GetAnnouncedRaid(messageId string) (entities.Raid, error)
+	AddSchedule(schedule entities.Schedule) (entities.Schedule, error)
+	GetSchedules() ([]entities.Schedule, error)
+	UpdateSchedule(schedule entities.Schedule) error
+	DeleteSchedule(id string) error
+	AddCharacter(character entities.Character) error
+	GetCharacters(member string) ([]entities.Character, error)
+	DeleteCharacter(member string, name string) error
+	AddTransaction(transaction entities.Transaction) (entities.Transaction, error)
+	GetTransactions(member string) ([]entities.Transaction, error)
+}
+
+type RaidDataStore interface {
+	Guild(id string) RaidDataProvider
+	Guilds() ([]string, error)
+	// the settings of an user are the same in every guild
+	GetUserSetting(user string, key string) (string, error)
+	SetUserSetting(user string, key string, value string) error
+	Close() error
 }
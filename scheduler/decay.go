@@ -0,0 +1,77 @@
+package scheduler
+
+import (
+	"fmt"
+	"github.com/juan-medina/cecibot/commands/raid/data/entities"
+	"github.com/juan-medina/cecibot/commands/raid/dkp"
+	"github.com/juan-medina/cecibot/prototype"
+	"sort"
+	"time"
+)
+
+const decayInterval = 7 * 24 * time.Hour
+
+// Decay applies the weekly decay to the members with points for every week passed since the last time it was applied,
+// the first time it only starts counting
+func Decay(data prototype.RaidDataProvider, now time.Time) ([]entities.Transaction, error) {
+	result := make([]entities.Transaction, 0)
+
+	value, err := data.GetSetting(dkp.DecaySetting)
+	if err != nil || value == "" {
+		return result, err
+	}
+	percentage, err := dkp.ParsePercentage(value)
+	if err != nil {
+		return result, err
+	}
+
+	decayed, err := data.GetSetting(dkp.DecayedSetting)
+	if err != nil {
+		return result, err
+	}
+	if decayed == "" {
+		return result, data.SetSetting(dkp.DecayedSetting, now.UTC().Format(time.RFC3339))
+	}
+	last, err := time.Parse(time.RFC3339, decayed)
+	if err != nil {
+		return result, err
+	}
+
+	for next := last.Add(decayInterval); !next.After(now); next = next.Add(decayInterval) {
+		transactions, err := data.GetTransactions("")
+		if err != nil {
+			return result, err
+		}
+
+		balances := dkp.Balances(transactions)
+		members := make([]string, 0, len(balances))
+		for member := range balances {
+			members = append(members, member)
+		}
+		sort.Strings(members)
+
+		for _, member := range members {
+			points := balances[member] * percentage / 100
+			if points <= 0 {
+				continue
+			}
+			transaction, err := data.AddTransaction(entities.Transaction{
+				Member: member,
+				Points: -points,
+				Kind:   entities.TransactionDecay,
+				Reason: fmt.Sprintf("%d%% weekly decay", percentage),
+				Date:   next.In(time.Local),
+			})
+			if err != nil {
+				return result, err
+			}
+			result = append(result, transaction)
+		}
+
+		// the decay is kept every week so a failure does not apply it twice
+		if err := data.SetSetting(dkp.DecayedSetting, next.UTC().Format(time.RFC3339)); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,67 @@
+package scheduler
+
+import (
+	"github.com/juan-medina/cecibot/commands/raid/data/entities"
+	"github.com/juan-medina/cecibot/commands/raid/data/memory"
+	"github.com/juan-medina/cecibot/commands/raid/dkp"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDecay(t *testing.T) {
+	data := memory.New().Guild("guild1")
+	start := time.Date(2019, 11, 4, 12, 0, 0, 0, time.UTC)
+
+	award := func(member string, points int) {
+		_, _ = data.AddTransaction(entities.Transaction{Member: member, Points: points, Kind: entities.TransactionAward, Date: start})
+	}
+	award("1", 100)
+	award("2", 55)
+	award("3", -20)
+
+	balances := func() map[string]int {
+		transactions, _ := data.GetTransactions("")
+		return dkp.Balances(transactions)
+	}
+
+	t.Run("should not decay without a percentage", func(t *testing.T) {
+		if got, err := Decay(data, start); err != nil || len(got) != 0 {
+			t.Errorf("want no decay, got %v, %v", got, err)
+		}
+	})
+
+	_ = data.SetSetting(dkp.DecaySetting, "10")
+
+	t.Run("should start counting the first time", func(t *testing.T) {
+		if got, err := Decay(data, start); err != nil || len(got) != 0 {
+			t.Errorf("want no decay, got %v, %v", got, err)
+		}
+	})
+
+	t.Run("should not decay before a week", func(t *testing.T) {
+		if got, err := Decay(data, start.AddDate(0, 0, 6)); err != nil || len(got) != 0 {
+			t.Errorf("want no decay, got %v, %v", got, err)
+		}
+	})
+
+	t.Run("should decay the members with points every week", func(t *testing.T) {
+		got, err := Decay(data, start.AddDate(0, 0, 14))
+		if err != nil || len(got) != 4 {
+			t.Fatalf("want 4 decays, got %v, %v", got, err)
+		}
+		if got[0].Reason != "10% weekly decay" || !got[0].Date.Equal(start.AddDate(0, 0, 7)) {
+			t.Errorf("want the decay of the first week, got %v", got[0])
+		}
+		want := map[string]int{"1": 81, "2": 45, "3": -20}
+		if got := balances(); !reflect.DeepEqual(got, want) {
+			t.Errorf("want balances %v, got %v", want, got)
+		}
+	})
+
+	t.Run("should not decay twice", func(t *testing.T) {
+		if got, err := Decay(data, start.AddDate(0, 0, 15)); err != nil || len(got) != 0 {
+			t.Errorf("want no decay, got %v, %v", got, err)
+		}
+	})
+}
@@ -0,0 +1,278 @@
+// Package scheduler creates the raids of the recurring schedules, sends the reminders of the upcoming raids and
+// applies the weekly decay of the loot points.
+package scheduler
+
+import (
+	"fmt"
+	"github.com/juan-medina/cecibot/commands/raid/data/entities"
+	"github.com/juan-medina/cecibot/commands/raid/shared"
+	"github.com/juan-medina/cecibot/message"
+	"github.com/juan-medina/cecibot/prototype"
+	"go.uber.org/zap"
+	"sort"
+	"strings"
+	"time"
+)
+
+const checkInterval = time.Minute
+
+const noReminders = "none"
+
+// remindedSetting keeps until when the reminders of a guild have been sent, to send the ones due while the bot was
+// not running
+const remindedSetting = "reminders:sent"
+
+// activeWindow is how far from a raid the other raids are looked at to find the members that have not responded
+const activeWindow = 4 * 7 * 24 * time.Hour
+
+// Clock gives the time to the scheduler, tests use a fake one to run it deterministically
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+var SystemClock Clock = systemClock{}
+
+type Scheduler struct {
+	store   prototype.RaidDataStore
+	offsets []time.Duration
+	weeks   int
+	sender  prototype.Sender
+	clock   Clock
+	started time.Time
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// ParseOffsets reads the time before the raids to send reminders, as comma separated durations like 24h,1h
+func ParseOffsets(text string) ([]time.Duration, error) {
+	result := make([]time.Duration, 0)
+	if strings.TrimSpace(text) == noReminders {
+		return result, nil
+	}
+
+	for _, value := range strings.Split(text, ",") {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		offset, err := time.ParseDuration(value)
+		if err != nil || offset <= 0 {
+			return nil, fmt.Errorf("invalid reminder %q, use durations like 24h or 30m", value)
+		}
+		result = append(result, offset)
+	}
+	return result, nil
+}
+
+func New(store prototype.RaidDataStore, offsets []time.Duration, weeks int, sender prototype.Sender, clock Clock) *Scheduler {
+	return &Scheduler{
+		store:   store,
+		offsets: offsets,
+		weeks:   weeks,
+		sender:  sender,
+		clock:   clock,
+	}
+}
+
+// Start runs the scheduler, the reminders that were due while it was not running are sent on the first check if their
+// raid has not started
+func (s *Scheduler) Start() {
+	s.started = s.clock.Now()
+	s.stop = make(chan struct{})
+	s.done = make(chan struct{})
+	go s.run()
+}
+
+func (s *Scheduler) Stop() {
+	if s.stop == nil {
+		return
+	}
+	close(s.stop)
+	<-s.done
+	s.stop = nil
+}
+
+func (s *Scheduler) run() {
+	defer close(s.done)
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-s.clock.After(checkInterval):
+			s.check(s.clock.Now())
+		}
+	}
+}
+
+func (s *Scheduler) check(now time.Time) {
+	log, _ := zap.NewProduction()
+	defer log.Sync()
+
+	guilds, err := s.store.Guilds()
+	if err != nil {
+		log.Error("Error reading guilds.", zap.Error(err))
+		return
+	}
+
+	for _, guild := range guilds {
+		data := s.store.Guild(guild)
+		if err := s.materialize(data, now); err != nil {
+			log.Error("Error creating scheduled raids.", zap.String("guild", guild), zap.Error(err))
+		}
+		if err := s.checkGuild(data, now); err != nil {
+			log.Error("Error sending reminders.", zap.String("guild", guild), zap.Error(err))
+		}
+		if _, err := Decay(data, now); err != nil {
+			log.Error("Error applying the loot points decay.", zap.String("guild", guild), zap.Error(err))
+		}
+	}
+}
+
+// reminded is until when the reminders of the guild have been sent, the guilds without reminders yet start when the
+// scheduler started
+func (s *Scheduler) reminded(data prototype.RaidDataProvider) (time.Time, error) {
+	value, err := data.GetSetting(remindedSetting)
+	if err != nil || value == "" {
+		return s.started, err
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+func (s *Scheduler) checkGuild(data prototype.RaidDataProvider, now time.Time) error {
+	last, err := s.reminded(data)
+	if err != nil {
+		return err
+	}
+
+	raids, err := data.GetRaids()
+	if err != nil {
+		return err
+	}
+
+	for _, raid := range raids {
+		if raid.Cancelled || !raid.Date.After(now) {
+			continue
+		}
+		// after being stopped several reminders could be due, only the closest to the raid is sent
+		due := time.Duration(-1)
+		for _, offset := range s.offsets {
+			at := raid.Date.Add(-offset)
+			if at.After(last) && !at.After(now) && (due < 0 || offset < due) {
+				due = offset
+			}
+		}
+		if due < 0 {
+			continue
+		}
+
+		left := due
+		if late := now.Sub(raid.Date.Add(-due)); late > checkInterval {
+			left = raid.Date.Sub(now).Truncate(time.Minute)
+		}
+		if err := s.remind(data, raids, raid, left); err != nil {
+			return err
+		}
+	}
+	return data.SetSetting(remindedSetting, now.UTC().Format(time.RFC3339))
+}
+
+// remind posts the reminder in the channel where the raid was announced, the raids not announced only get the direct
+// reminders as there is no channel to post to
+func (s *Scheduler) remind(data prototype.RaidDataProvider, raids []entities.Raid, raid entities.Raid, offset time.Duration) error {
+	signups, err := data.GetSignups(raid.Id)
+	if err != nil {
+		return err
+	}
+
+	if raid.Channel != "" {
+		s.sender.Send(raid.Channel, prototype.Message{Text: reminder(raid, offset, signups)})
+	}
+
+	members, err := nonResponders(data, raids, raid, signups)
+	if err != nil {
+		return err
+	}
+	for _, member := range members {
+		s.sender.SendDirect(member, prototype.Message{Text: directReminder(raid, offset)})
+	}
+	return nil
+}
+
+// nonResponders are the members that have signed up for other raids of the guild close to this one but not for it
+func nonResponders(data prototype.RaidDataProvider, raids []entities.Raid, raid entities.Raid, signups []entities.Signup) ([]string, error) {
+	responded := make(map[string]bool)
+	for _, signup := range signups {
+		responded[signup.Member] = true
+	}
+
+	members := make(map[string]bool)
+	for _, other := range raids {
+		if other.Id == raid.Id || other.Cancelled {
+			continue
+		}
+		if other.Date.Before(raid.Date.Add(-activeWindow)) || other.Date.After(raid.Date.Add(activeWindow)) {
+			continue
+		}
+		others, err := data.GetSignups(other.Id)
+		if err != nil {
+			return nil, err
+		}
+		for _, signup := range others {
+			if !responded[signup.Member] {
+				members[signup.Member] = true
+			}
+		}
+	}
+
+	result := make([]string, 0, len(members))
+	for member := range members {
+		result = append(result, member)
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+// formatOffset writes an offset without the zero units, as 24h instead of 24h0m0s
+func formatOffset(offset time.Duration) string {
+	text := offset.String()
+	if strings.HasSuffix(text, "m0s") {
+		text = strings.TrimSuffix(text, "0s")
+	}
+	if strings.HasSuffix(text, "h0m") {
+		text = strings.TrimSuffix(text, "0m")
+	}
+	return text
+}
+
+func reminder(raid entities.Raid, offset time.Duration, signups []entities.Signup) string {
+	if len(signups) == 0 {
+		return fmt.Sprintf("raid %s starts in %s, nobody has signed up yet", shared.RaidTitle(raid), formatOffset(offset))
+	}
+
+	mentions := make([]string, 0, len(signups))
+	for _, signup := range signups {
+		mentions = append(mentions, fmt.Sprintf("<@%s>", signup.Member))
+	}
+	return fmt.Sprintf("raid %s starts in %s, signed up members: %s", shared.RaidTitle(raid), formatOffset(offset), strings.Join(mentions, " "))
+}
+
+func directReminder(raid entities.Raid, offset time.Duration) string {
+	how := fmt.Sprintf("send **raid sign up %s** *char* *class* *spec*", raid.Id)
+	if raid.Message != "" {
+		how = "react to its announcement or " + how
+	}
+	return fmt.Sprintf("raid %s on %s starts in %s and you have not signed up yet, %s",
+		shared.RaidTitle(raid), message.Timestamp(raid.Date), formatOffset(offset), how)
+}
@@ -0,0 +1,252 @@
+package scheduler
+
+import (
+	"github.com/juan-medina/cecibot/commands/raid/data/entities"
+	"github.com/juan-medina/cecibot/commands/raid/data/memory"
+	"github.com/juan-medina/cecibot/prototype"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters chan chan time.Time
+}
+
+func newFakeClock(now time.Time) *fakeClock {
+	return &fakeClock{now: now, waiters: make(chan chan time.Time, 1)}
+}
+
+func (f *fakeClock) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+func (f *fakeClock) After(d time.Duration) <-chan time.Time {
+	c := make(chan time.Time, 1)
+	f.waiters <- c
+	return c
+}
+
+// advance moves the clock and waits until the scheduler has checked the reminders
+func (f *fakeClock) advance(to time.Time) {
+	c := <-f.waiters
+	f.mu.Lock()
+	f.now = to
+	f.mu.Unlock()
+	c <- to
+
+	// the scheduler waits again once the check is done
+	f.waiters <- <-f.waiters
+}
+
+type sent struct {
+	to   string
+	text string
+}
+
+type fakeSender struct {
+	mu       sync.Mutex
+	channels []sent
+	direct   []sent
+}
+
+func (f *fakeSender) Send(channel string, message prototype.Message) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.channels = append(f.channels, sent{to: channel, text: message.Text})
+}
+
+func (f *fakeSender) SendDirect(member string, message prototype.Message) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.direct = append(f.direct, sent{to: member, text: message.Text})
+}
+
+func (f *fakeSender) reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.channels = nil
+	f.direct = nil
+}
+
+func TestParseOffsets(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		want    []time.Duration
+		wantErr bool
+	}{
+		{"several offsets", "24h, 1h30m", []time.Duration{24 * time.Hour, 90 * time.Minute}, false},
+		{"no reminders", "none", []time.Duration{}, false},
+		{"empty", "", []time.Duration{}, false},
+		{"invalid duration", "24h,tomorrow", nil, true},
+		{"negative duration", "-1h", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseOffsets(tt.text)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("want error %v, got %v", tt.wantErr, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("want %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func Test_formatOffset(t *testing.T) {
+	tests := map[time.Duration]string{
+		24 * time.Hour:   "24h",
+		90 * time.Minute: "1h30m",
+		30 * time.Minute: "30m",
+		45 * time.Second: "45s",
+	}
+	for offset, want := range tests {
+		if got := formatOffset(offset); got != want {
+			t.Errorf("want %q, got %q", want, got)
+		}
+	}
+}
+
+func TestScheduler(t *testing.T) {
+	start := time.Date(2019, 11, 19, 12, 0, 0, 0, time.UTC)
+	raidDate := time.Date(2019, 11, 20, 20, 0, 0, 0, time.UTC)
+
+	store := memory.New()
+	data := store.Guild("guild1")
+
+	mc, _ := data.AddRaid(entities.Raid{Name: "Molten Core", Date: raidDate})
+	mc.Channel = "channel1"
+	mc.Message = "m1"
+	_ = data.UpdateRaid(mc)
+	_ = data.SignUp(mc.Id, entities.Signup{Member: "1", Char: "Thrall", Class: "Shaman", Spec: "Restoration"})
+
+	past, _ := data.AddRaid(entities.Raid{Name: "Onyxia's Lair", Date: start.Add(-24 * time.Hour)})
+	_ = data.SignUp(past.Id, entities.Signup{Member: "1", Char: "Thrall", Class: "Shaman", Spec: "Restoration"})
+	_ = data.SignUp(past.Id, entities.Signup{Member: "2", Char: "Brox", Class: "Warrior", Spec: "Protection"})
+
+	cancelled, _ := data.AddRaid(entities.Raid{Name: "Zul'Gurub", Date: raidDate})
+	cancelled.Cancelled = true
+	cancelled.Channel = "channel1"
+	_ = data.UpdateRaid(cancelled)
+	_ = data.SignUp(cancelled.Id, entities.Signup{Member: "4", Char: "Sylvanas", Class: "Hunter", Spec: "Marksmanship"})
+
+	// the members of the raids far from the reminded one are not active anymore
+	old, _ := data.AddRaid(entities.Raid{Name: "Molten Core", Date: raidDate.Add(-60 * 24 * time.Hour)})
+	_ = data.SignUp(old.Id, entities.Signup{Member: "5", Char: "Varian", Class: "Warrior", Spec: "Fury"})
+
+	other := store.Guild("guild2")
+	bwl, _ := other.AddRaid(entities.Raid{Name: "Blackwing Lair", Date: raidDate.Add(time.Hour)})
+
+	clock := newFakeClock(start)
+	sender := &fakeSender{}
+	scheduler := New(store, []time.Duration{24 * time.Hour, time.Hour}, 2, sender, clock)
+	scheduler.Start()
+	defer func() { scheduler.Stop() }()
+
+	t.Run("should not send reminders before they are due", func(t *testing.T) {
+		clock.advance(raidDate.Add(-24*time.Hour - time.Minute))
+
+		if len(sender.channels) != 0 || len(sender.direct) != 0 {
+			t.Errorf("want no reminders, got %v %v", sender.channels, sender.direct)
+		}
+	})
+
+	t.Run("should remind signed up members and members that have not responded", func(t *testing.T) {
+		clock.advance(raidDate.Add(-24 * time.Hour))
+
+		wantChannels := []sent{{to: "channel1", text: "raid **Molten Core** (**1**) starts in 24h, signed up members: <@1>"}}
+		if !reflect.DeepEqual(sender.channels, wantChannels) {
+			t.Errorf("want %v, got %v", wantChannels, sender.channels)
+		}
+
+		wantDirect := []sent{{to: "2", text: "raid **Molten Core** (**1**) on <t:1574280000:F> starts in 24h and you have not signed up yet, " +
+			"react to its announcement or send **raid sign up 1** *char* *class* *spec*"}}
+		if !reflect.DeepEqual(sender.direct, wantDirect) {
+			t.Errorf("want %v, got %v", wantDirect, sender.direct)
+		}
+	})
+
+	t.Run("should send each reminder once", func(t *testing.T) {
+		sender.reset()
+		clock.advance(raidDate.Add(-2 * time.Hour))
+
+		if len(sender.channels) != 0 || len(sender.direct) != 0 {
+			t.Errorf("want no reminders, got %v %v", sender.channels, sender.direct)
+		}
+	})
+
+	t.Run("should send the reminders due since the last check with the time left", func(t *testing.T) {
+		sender.reset()
+		clock.advance(raidDate.Add(-30 * time.Minute))
+
+		wantChannels := []sent{{to: "channel1", text: "raid **Molten Core** (**1**) starts in 30m, signed up members: <@1>"}}
+		if !reflect.DeepEqual(sender.channels, wantChannels) {
+			t.Errorf("want %v, got %v", wantChannels, sender.channels)
+		}
+
+		// the raid of guild2 is not announced and nobody has attended other raids there
+		if len(sender.direct) != 1 || sender.direct[0].to != "2" {
+			t.Errorf("want a direct reminder to member 2, got %v", sender.direct)
+		}
+	})
+
+	t.Run("should send the reminders due while it was stopped", func(t *testing.T) {
+		scheduler.Stop()
+		sender.reset()
+
+		bwl.Channel = "channel2"
+		_ = other.UpdateRaid(bwl)
+		aq, _ := other.AddRaid(entities.Raid{Name: "Ahn'Qiraj", Date: start})
+		_ = other.SignUp(aq.Id, entities.Signup{Member: "3", Char: "Jaina", Class: "Mage", Spec: "Frost"})
+
+		// the 1h reminder of Blackwing Lair was due while stopped, Molten Core has started and gets no reminder
+		restart := newFakeClock(bwl.Date.Add(-30 * time.Minute))
+		scheduler = New(store, []time.Duration{24 * time.Hour, time.Hour}, 2, sender, restart)
+		scheduler.Start()
+		restart.advance(bwl.Date.Add(-29 * time.Minute))
+
+		wantChannels := []sent{{to: "channel2", text: "raid **Blackwing Lair** (**1**) starts in 29m, nobody has signed up yet"}}
+		if !reflect.DeepEqual(sender.channels, wantChannels) {
+			t.Errorf("want %v, got %v", wantChannels, sender.channels)
+		}
+		wantDirect := []sent{{to: "3", text: "raid **Blackwing Lair** (**1**) on <t:1574283600:F> starts in 29m and you have not signed up yet, " +
+			"send **raid sign up 1** *char* *class* *spec*"}}
+		if !reflect.DeepEqual(sender.direct, wantDirect) {
+			t.Errorf("want %v, got %v", wantDirect, sender.direct)
+		}
+	})
+
+	t.Run("should only send the direct reminders of the raids not announced", func(t *testing.T) {
+		scheduler.Stop()
+		sender.reset()
+
+		naxx, _ := data.AddRaid(entities.Raid{Name: "Naxxramas", Date: bwl.Date.Add(2 * time.Hour)})
+		restart := newFakeClock(bwl.Date.Add(-29 * time.Minute))
+		scheduler = New(store, []time.Duration{time.Hour}, 2, sender, restart)
+		scheduler.Start()
+		restart.advance(naxx.Date.Add(-time.Hour))
+
+		if len(sender.channels) != 0 {
+			t.Errorf("want no channel reminders, got %v", sender.channels)
+		}
+		if len(sender.direct) != 2 {
+			t.Errorf("want direct reminders to members 1 and 2, got %v", sender.direct)
+		}
+	})
+}
+
+func TestScheduler_Stop(t *testing.T) {
+	scheduler := New(memory.New(), []time.Duration{time.Hour}, 2, &fakeSender{}, newFakeClock(time.Now()))
+	scheduler.Stop()
+
+	scheduler.Start()
+	scheduler.Stop()
+	scheduler.Stop()
+}
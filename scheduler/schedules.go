@@ -0,0 +1,89 @@
+package scheduler
+
+import (
+	"fmt"
+	"github.com/juan-medina/cecibot/commands/raid/data/entities"
+	"github.com/juan-medina/cecibot/prototype"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func ParseWeeks(text string) (int, error) {
+	weeks, err := strconv.Atoi(strings.TrimSpace(text))
+	if err != nil || weeks < 1 {
+		return 0, fmt.Errorf("invalid schedule weeks %q, use a number of weeks greater than 0", text)
+	}
+	return weeks, nil
+}
+
+// Location gets the time zone of a schedule, the local one if it is not set
+func Location(schedule entities.Schedule) (*time.Location, error) {
+	if schedule.Location == "" {
+		return time.Local, nil
+	}
+	return time.LoadLocation(schedule.Location)
+}
+
+func scheduledOn(schedule entities.Schedule, day time.Weekday) bool {
+	for _, current := range schedule.Days {
+		if current == day {
+			return true
+		}
+	}
+	return false
+}
+
+// Materialize does not create again the raids up to the last one created before, even if they have been deleted
+func Materialize(data prototype.RaidDataProvider, schedule entities.Schedule, now time.Time, weeks int) ([]entities.Raid, error) {
+	result := make([]entities.Raid, 0)
+
+	loc, err := Location(schedule)
+	if err != nil {
+		return nil, err
+	}
+
+	from := schedule.Until
+	if from.Before(now) {
+		from = now
+	}
+
+	until := now.AddDate(0, 0, 7*weeks)
+	first := from.In(loc)
+	for day := time.Date(first.Year(), first.Month(), first.Day(), 0, 0, 0, 0, loc); !day.After(until); day = day.AddDate(0, 0, 1) {
+		if !scheduledOn(schedule, day.Weekday()) {
+			continue
+		}
+		date := time.Date(day.Year(), day.Month(), day.Day(), schedule.Hour, schedule.Minute, 0, 0, loc)
+		if !date.After(from) || date.After(until) {
+			continue
+		}
+
+		raid, err := data.AddRaid(entities.Raid{Name: schedule.Name, Date: date.In(time.Local)})
+		if err != nil {
+			return result, err
+		}
+		result = append(result, raid)
+
+		// the schedule is updated with every raid so a failure does not create it twice
+		schedule.Until = date
+		if err := data.UpdateSchedule(schedule); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+func (s *Scheduler) materialize(data prototype.RaidDataProvider, now time.Time) error {
+	schedules, err := data.GetSchedules()
+	if err != nil {
+		return err
+	}
+
+	for _, schedule := range schedules {
+		if _, err := Materialize(data, schedule, now, s.weeks); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package scheduler
+
+import (
+	"github.com/juan-medina/cecibot/commands/raid/data/entities"
+	"github.com/juan-medina/cecibot/commands/raid/data/memory"
+	"testing"
+	"time"
+)
+
+func TestParseWeeks(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		want    int
+		wantErr bool
+	}{
+		{"weeks", "3", 3, false},
+		{"not a number", "two", 0, true},
+		{"no weeks", "0", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseWeeks(tt.text)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("want error %v, got %v", tt.wantErr, err)
+			}
+			if got != tt.want {
+				t.Errorf("want %d, got %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func assertDates(t *testing.T, raids []entities.Raid, want ...time.Time) {
+	t.Helper()
+	if len(raids) != len(want) {
+		t.Fatalf("want %d raids, got %v", len(want), raids)
+	}
+	for i, raid := range raids {
+		if raid.Name != "Molten Core" || !raid.Date.Equal(want[i]) {
+			t.Errorf("want raid Molten Core on %v, got %v", want[i], raid)
+		}
+	}
+}
+
+func TestMaterialize(t *testing.T) {
+	madrid, err := time.LoadLocation("Europe/Madrid")
+	if err != nil {
+		t.Fatalf("want not error, got %v", err)
+	}
+
+	data := memory.New().Guild("guild1")
+	schedule, _ := data.AddSchedule(entities.Schedule{
+		Name:     "Molten Core",
+		Days:     []time.Weekday{time.Wednesday, time.Sunday},
+		Hour:     20,
+		Location: "Europe/Madrid",
+	})
+	now := time.Date(2019, 11, 18, 12, 0, 0, 0, time.UTC)
+
+	t.Run("should create the raids the weeks ahead", func(t *testing.T) {
+		raids, err := Materialize(data, schedule, now, 2)
+		if err != nil {
+			t.Fatalf("want not error, got %v", err)
+		}
+		assertDates(t, raids,
+			time.Date(2019, 11, 20, 20, 0, 0, 0, madrid),
+			time.Date(2019, 11, 24, 20, 0, 0, 0, madrid),
+			time.Date(2019, 11, 27, 20, 0, 0, 0, madrid),
+			time.Date(2019, 12, 1, 20, 0, 0, 0, madrid),
+		)
+
+		all, _ := data.GetRaids()
+		assertDates(t, all, raids[0].Date, raids[1].Date, raids[2].Date, raids[3].Date)
+	})
+
+	schedules, _ := data.GetSchedules()
+	schedule = schedules[0]
+
+	t.Run("should not create the raids again", func(t *testing.T) {
+		_ = data.DeleteRaid("1")
+
+		raids, err := Materialize(data, schedule, now.Add(time.Hour), 2)
+		if err != nil {
+			t.Fatalf("want not error, got %v", err)
+		}
+		assertDates(t, raids)
+	})
+
+	t.Run("should create the next raids as time passes", func(t *testing.T) {
+		raids, err := Materialize(data, schedule, now.AddDate(0, 0, 7), 2)
+		if err != nil {
+			t.Fatalf("want not error, got %v", err)
+		}
+		assertDates(t, raids,
+			time.Date(2019, 12, 4, 20, 0, 0, 0, madrid),
+			time.Date(2019, 12, 8, 20, 0, 0, 0, madrid),
+		)
+	})
+
+	t.Run("should skip the raids missed while stopped", func(t *testing.T) {
+		schedules, _ := data.GetSchedules()
+		raids, err := Materialize(data, schedules[0], time.Date(2019, 12, 20, 12, 0, 0, 0, time.UTC), 1)
+		if err != nil {
+			t.Fatalf("want not error, got %v", err)
+		}
+		assertDates(t, raids,
+			time.Date(2019, 12, 22, 20, 0, 0, 0, madrid),
+			time.Date(2019, 12, 25, 20, 0, 0, 0, madrid),
+		)
+	})
+
+	t.Run("should fail with an unknown location", func(t *testing.T) {
+		schedule.Location = "Azeroth/Orgrimmar"
+		if _, err := Materialize(data, schedule, now, 2); err == nil {
+			t.Errorf("want error, got nil")
+		}
+	})
+}
+
+func TestScheduler_schedules(t *testing.T) {
+	start := time.Date(2019, 11, 18, 12, 0, 0, 0, time.UTC)
+
+	store := memory.New()
+	data := store.Guild("guild1")
+	_, _ = data.AddSchedule(entities.Schedule{Name: "Molten Core", Days: []time.Weekday{time.Wednesday}, Hour: 20, Location: "UTC"})
+
+	clock := newFakeClock(start)
+	scheduler := New(store, []time.Duration{}, 1, &fakeSender{}, clock)
+	scheduler.Start()
+	defer scheduler.Stop()
+
+	clock.advance(start.Add(time.Minute))
+
+	raids, _ := data.GetRaids()
+	assertDates(t, raids, time.Date(2019, 11, 20, 20, 0, 0, 0, time.UTC))
+}